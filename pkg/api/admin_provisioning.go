@@ -2,17 +2,101 @@ package api
 
 import (
 	"context"
+	"encoding/json"
+
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 func (server *HTTPServer) AdminProvisioningReloadDasboards(c *models.ReqContext) Response {
-	err := server.ProvisioningService.ProvisionDashboards()
+	// skipDelete lets a partial set of dashboards be pushed without removing the ones missing from it.
+	opts := dashboards.ScanOptions{SkipDelete: c.QueryBool("skipDelete")}
+	if c.QueryBool("progress") {
+		return &reloadProgressResponse{provisioning: server.ProvisioningService, opts: opts}
+	}
+
+	err := server.ProvisioningService.ProvisionDashboardsWithOptions(opts)
 	if err != nil && err != context.Canceled {
 		return Error(500, "", err)
 	}
 	return Success("Dashboards config reloaded")
 }
 
+// reloadProgressBuffer is the number of progress events kept for a slow client before they are dropped.
+const reloadProgressBuffer = 100
+
+// reloadProgressResponse reloads the dashboards while streaming a json line for every dashboard file processed,
+// followed by a line with the result of the reload. The status is sent before the reload starts, so a failed reload
+// is only reported by the last line.
+type reloadProgressResponse struct {
+	provisioning ProvisioningService
+	opts         dashboards.ScanOptions
+}
+
+type reloadProgressLine struct {
+	Provider  string `json:"provider"`
+	Path      string `json:"path"`
+	Processed int    `json:"processed"`
+	Total     int    `json:"total"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (r *reloadProgressResponse) WriteTo(ctx *models.ReqContext) {
+	ctx.Resp.Header().Set("Content-Type", "application/x-ndjson")
+	ctx.Resp.WriteHeader(200)
+	encoder := json.NewEncoder(ctx.Resp)
+
+	writeProgress := func(event dashboards.ProgressEvent) {
+		line := reloadProgressLine{Provider: event.Provider, Path: event.Path, Processed: event.Processed, Total: event.Total}
+		if event.Error != nil {
+			line.Error = event.Error.Error()
+		}
+		if err := encoder.Encode(line); err == nil {
+			ctx.Resp.Flush()
+		}
+	}
+
+	progress := make(chan dashboards.ProgressEvent, reloadProgressBuffer)
+	done := make(chan error, 1)
+	opts := r.opts
+	opts.Progress = progress
+	go func() {
+		done <- r.provisioning.ProvisionDashboardsWithOptions(opts)
+	}()
+
+	var err error
+	for reloading := true; reloading; {
+		select {
+		case event := <-progress:
+			writeProgress(event)
+		case err = <-done:
+			reloading = false
+		}
+	}
+	// the scan is over, write the events sent right before it finished
+	for len(progress) > 0 {
+		writeProgress(<-progress)
+	}
+
+	result := map[string]interface{}{"message": "Dashboards config reloaded"}
+	if err != nil && err != context.Canceled {
+		ctx.Logger.Error("Failed to reload dashboards config", "error", err)
+		result["message"] = "Internal Server Error"
+		if setting.Env != setting.PROD {
+			result["error"] = err.Error()
+		}
+	}
+	if err := encoder.Encode(result); err == nil {
+		ctx.Resp.Flush()
+	}
+}
+
+// AdminProvisioningDashboardsStatus returns the result of the last scan of every dashboard provider.
+func (server *HTTPServer) AdminProvisioningDashboardsStatus(c *models.ReqContext) Response {
+	return JSON(200, server.ProvisioningService.GetDashboardProvisioningStatus())
+}
+
 func (server *HTTPServer) AdminProvisioningReloadDatasources(c *models.ReqContext) Response {
 	err := server.ProvisioningService.ProvisionDatasources()
 	if err != nil {
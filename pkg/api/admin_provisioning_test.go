@@ -0,0 +1,79 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	m "github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/provisioning"
+	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
+	"github.com/grafana/grafana/pkg/setting"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAdminProvisioningReloadDashboards(t *testing.T) {
+	Convey("Reloading dashboards with progress", t, func() {
+		mock := provisioning.NewProvisioningServiceMock()
+		var reloadErr error
+		mock.ProvisionDashboardsWithOptionsFunc = func(opts dashboards.ScanOptions) error {
+			if opts.Progress != nil {
+				opts.Progress <- dashboards.ProgressEvent{Provider: "default", Path: "/dashboards/cpu.json", Processed: 1, Total: 2}
+				opts.Progress <- dashboards.ProgressEvent{Provider: "default", Path: "/dashboards/disk.json", Processed: 2, Total: 2, Error: errors.New("invalid json")}
+			}
+			return reloadErr
+		}
+		hs := &HTTPServer{Cfg: setting.NewCfg(), ProvisioningService: mock}
+
+		readLines := func(sc *scenarioContext) []map[string]interface{} {
+			var lines []map[string]interface{}
+			scanner := bufio.NewScanner(sc.resp.Body)
+			for scanner.Scan() {
+				line := map[string]interface{}{}
+				So(json.Unmarshal(scanner.Bytes(), &line), ShouldBeNil)
+				lines = append(lines, line)
+			}
+			return lines
+		}
+
+		loggedInUserScenarioWithRole("When calling POST on", "POST", "/api/admin/provisioning/dashboards/reload", "/api/admin/provisioning/dashboards/reload", m.ROLE_ADMIN, func(sc *scenarioContext) {
+			sc.handlerFunc = hs.AdminProvisioningReloadDasboards
+
+			Convey("should stream a line for every file followed by the result", func() {
+				sc.fakeReqWithParams("POST", sc.url, map[string]string{"progress": "true"}).exec()
+
+				So(sc.resp.Code, ShouldEqual, 200)
+				So(sc.resp.Header().Get("Content-Type"), ShouldEqual, "application/x-ndjson")
+				lines := readLines(sc)
+				So(len(lines), ShouldEqual, 3)
+				So(lines[0]["path"], ShouldEqual, "/dashboards/cpu.json")
+				So(lines[0]["processed"], ShouldEqual, 1)
+				So(lines[0]["total"], ShouldEqual, 2)
+				So(lines[1]["error"], ShouldEqual, "invalid json")
+				So(lines[2]["message"], ShouldEqual, "Dashboards config reloaded")
+			})
+
+			Convey("should report a failed reload in the last line", func() {
+				reloadErr = errors.New("database is locked")
+				sc.fakeReqWithParams("POST", sc.url, map[string]string{"progress": "true"}).exec()
+
+				So(sc.resp.Code, ShouldEqual, 200)
+				lines := readLines(sc)
+				So(len(lines), ShouldEqual, 3)
+				So(lines[2]["message"], ShouldEqual, "Internal Server Error")
+				So(lines[2]["error"], ShouldEqual, "database is locked")
+			})
+
+			Convey("should only return the result without progress", func() {
+				sc.fakeReqWithParams("POST", sc.url, map[string]string{}).exec()
+
+				So(sc.resp.Code, ShouldEqual, 200)
+				So(sc.resp.Header().Get("Content-Type"), ShouldEqual, "application/json")
+				lines := readLines(sc)
+				So(len(lines), ShouldEqual, 1)
+				So(lines[0]["message"], ShouldEqual, "Dashboards config reloaded")
+			})
+		})
+	})
+}
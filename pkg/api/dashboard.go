@@ -113,7 +113,9 @@ func (hs *HTTPServer) GetDashboard(c *m.ReqContext) Response {
 	}
 
 	if provisioningData != nil {
-		meta.Provisioned = true
+		// dashboards users may save are not shown as provisioned, so the UI doesn't refuse to save them
+		meta.Provisioned = !provisioningData.AllowUiUpdates
+		meta.ProvisionedLockMessage = provisioningData.LockMessage
 		meta.ProvisionedExternalId, err = filepath.Rel(
 			hs.ProvisioningService.GetDashboardProvisionerResolvedPath(provisioningData.Name),
 			provisioningData.ExternalId,
@@ -123,6 +125,10 @@ func (hs *HTTPServer) GetDashboard(c *m.ReqContext) Response {
 			// is for better UX, showing in Save/Delete dialogs and so it won't break anything if it is empty.
 			hs.log.Warn("Failed to create ProvisionedExternalId", "err", err)
 		}
+		// the absolute path on the server is only shown to server admins
+		if c.IsGrafanaAdmin {
+			meta.ProvisionedSourcePath = provisioningData.SourcePath
+		}
 	}
 
 	// make sure db version is in sync with json model version
@@ -915,7 +915,10 @@ func TestDashboardApiEndpoint(t *testing.T) {
 		})
 
 		bus.AddHandler("test", func(query *m.GetProvisionedDashboardDataByIdQuery) error {
-			query.Result = &m.DashboardProvisioning{ExternalId: "/tmp/grafana/dashboards/test/dashboard1.json"}
+			query.Result = &m.DashboardProvisioning{
+				ExternalId: "/tmp/grafana/dashboards/test/dashboard1.json",
+				SourcePath: "/tmp/grafana/dashboards/test/dashboard1.json.gz",
+			}
 			return nil
 		})
 
@@ -957,6 +960,10 @@ func TestDashboardApiEndpoint(t *testing.T) {
 			Convey("Should return relative path to provisioning file", func() {
 				So(dash.Meta.ProvisionedExternalId, ShouldEqual, "test/dashboard1.json")
 			})
+
+			Convey("Should not return the source path to users who aren't server admins", func() {
+				So(dash.Meta.ProvisionedSourcePath, ShouldBeEmpty)
+			})
 		})
 	})
 }
@@ -7,29 +7,31 @@ import (
 )
 
 type DashboardMeta struct {
-	IsStarred             bool      `json:"isStarred,omitempty"`
-	IsHome                bool      `json:"isHome,omitempty"`
-	IsSnapshot            bool      `json:"isSnapshot,omitempty"`
-	Type                  string    `json:"type,omitempty"`
-	CanSave               bool      `json:"canSave"`
-	CanEdit               bool      `json:"canEdit"`
-	CanAdmin              bool      `json:"canAdmin"`
-	CanStar               bool      `json:"canStar"`
-	Slug                  string    `json:"slug"`
-	Url                   string    `json:"url"`
-	Expires               time.Time `json:"expires"`
-	Created               time.Time `json:"created"`
-	Updated               time.Time `json:"updated"`
-	UpdatedBy             string    `json:"updatedBy"`
-	CreatedBy             string    `json:"createdBy"`
-	Version               int       `json:"version"`
-	HasAcl                bool      `json:"hasAcl"`
-	IsFolder              bool      `json:"isFolder"`
-	FolderId              int64     `json:"folderId"`
-	FolderTitle           string    `json:"folderTitle"`
-	FolderUrl             string    `json:"folderUrl"`
-	Provisioned           bool      `json:"provisioned"`
-	ProvisionedExternalId string    `json:"provisionedExternalId"`
+	IsStarred              bool      `json:"isStarred,omitempty"`
+	IsHome                 bool      `json:"isHome,omitempty"`
+	IsSnapshot             bool      `json:"isSnapshot,omitempty"`
+	Type                   string    `json:"type,omitempty"`
+	CanSave                bool      `json:"canSave"`
+	CanEdit                bool      `json:"canEdit"`
+	CanAdmin               bool      `json:"canAdmin"`
+	CanStar                bool      `json:"canStar"`
+	Slug                   string    `json:"slug"`
+	Url                    string    `json:"url"`
+	Expires                time.Time `json:"expires"`
+	Created                time.Time `json:"created"`
+	Updated                time.Time `json:"updated"`
+	UpdatedBy              string    `json:"updatedBy"`
+	CreatedBy              string    `json:"createdBy"`
+	Version                int       `json:"version"`
+	HasAcl                 bool      `json:"hasAcl"`
+	IsFolder               bool      `json:"isFolder"`
+	FolderId               int64     `json:"folderId"`
+	FolderTitle            string    `json:"folderTitle"`
+	FolderUrl              string    `json:"folderUrl"`
+	Provisioned            bool      `json:"provisioned"`
+	ProvisionedExternalId  string    `json:"provisionedExternalId"`
+	ProvisionedLockMessage string    `json:"provisionedLockMessage"`
+	ProvisionedSourcePath  string    `json:"provisionedSourcePath,omitempty"`
 }
 
 type DashboardFullWithMeta struct {
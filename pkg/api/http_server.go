@@ -25,6 +25,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/cache"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/hooks"
+	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/setting"
@@ -45,7 +46,9 @@ type ProvisioningService interface {
 	ProvisionDatasources() error
 	ProvisionNotifications() error
 	ProvisionDashboards() error
+	ProvisionDashboardsWithOptions(opts dashboards.ScanOptions) error
 	GetDashboardProvisionerResolvedPath(name string) string
+	GetDashboardProvisioningStatus() []dashboards.ProviderStatus
 }
 
 type HTTPServer struct {
@@ -40,6 +40,17 @@ func runDbCommand(command func(commandLine CommandLine) error) func(context *cli
 	}
 }
 
+func runCommand(command func(commandLine CommandLine) error) func(context *cli.Context) {
+	return func(context *cli.Context) {
+		cmd := &contextCommandLine{context}
+		if err := command(cmd); err != nil {
+			logger.Errorf("\n%s: ", color.RedString("Error"))
+			logger.Errorf("%s\n\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
 func runPluginCommand(command func(commandLine CommandLine) error) func(context *cli.Context) {
 	return func(context *cli.Context) {
 
@@ -91,6 +102,37 @@ var pluginCommands = []cli.Command{
 	},
 }
 
+var provisioningDashboardsCommands = []cli.Command{
+	{
+		Name:   "lint",
+		Usage:  "lint <dashboards dir> --rules <provisioning config>",
+		Action: runCommand(lintDashboardsCommand),
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "rules",
+				Usage: "dashboard provisioning config file whose provider options the dashboards are checked against",
+			},
+			cli.StringFlag{
+				Name:  "provider",
+				Usage: "name of the provider in the rules file to use, defaults to the first one",
+			},
+		},
+	},
+	{
+		Name:   "validate",
+		Usage:  "validate <provisioning config file or dir>",
+		Action: runCommand(validateProvisioningCommand),
+	},
+}
+
+var provisioningCommands = []cli.Command{
+	{
+		Name:        "dashboards",
+		Usage:       "Dashboard provisioning commands",
+		Subcommands: provisioningDashboardsCommands,
+	},
+}
+
 var adminCommands = []cli.Command{
 	{
 		Name:   "reset-admin-password",
@@ -107,6 +149,34 @@ var adminCommands = []cli.Command{
 			},
 		},
 	},
+	{
+		Name:        "provisioning",
+		Usage:       "Provisioning commands",
+		Subcommands: provisioningCommands,
+	},
+	{
+		Name:   "prune-folders",
+		Usage:  "prune-folders --merge [--dry-run]",
+		Action: runDbCommand(pruneFoldersCommand),
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "homepath",
+				Usage: "path to grafana install/home path, defaults to working directory",
+			},
+			cli.StringFlag{
+				Name:  "config",
+				Usage: "path to config file",
+			},
+			cli.BoolFlag{
+				Name:  "merge",
+				Usage: "merge provisioned folders only differing by case or whitespace and delete the emptied ones",
+			},
+			cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "only print the folders that would be merged",
+			},
+		},
+	},
 }
 
 var Commands = []cli.Command{
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
+)
+
+func lintDashboardsCommand(c CommandLine) error {
+	path := c.Args().First()
+	if path == "" {
+		return fmt.Errorf("Missing path to the dashboards to lint")
+	}
+
+	cfg, err := lintRules(c.String("rules"), c.String("provider"))
+	if err != nil {
+		return err
+	}
+
+	results, err := dashboards.LintDashboards(path, cfg, log.New("lint"))
+	if err != nil {
+		return fmt.Errorf("Failed to lint dashboards. Error: %v", err)
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != nil {
+			failed++
+			logger.Infof("%s %s: %v\n", color.RedString("✗"), result.Path, result.Error)
+		} else {
+			logger.Infof("%s %s\n", color.GreenString("✔"), result.Path)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d dashboards failed linting", failed, len(results))
+	}
+	return nil
+}
+
+// lintRules returns the provider whose options the dashboards are linted against. Without a rules file dashboards
+// are only checked to be readable.
+func lintRules(rules string, provider string) (*dashboards.DashboardsAsConfig, error) {
+	if rules == "" {
+		return &dashboards.DashboardsAsConfig{Name: "lint", Type: "file", OrgId: 1}, nil
+	}
+
+	configs, err := dashboards.ReadConfigFile(rules, log.New("lint"))
+	if err != nil {
+		return nil, fmt.Errorf("Could not read rules from %s. Error: %v", rules, err)
+	}
+
+	for _, cfg := range configs {
+		if provider == "" || cfg.Name == provider {
+			return cfg, nil
+		}
+	}
+
+	if provider != "" {
+		return nil, fmt.Errorf("Provider %s not found in %s", provider, rules)
+	}
+	return nil, fmt.Errorf("No provider found in %s", rules)
+}
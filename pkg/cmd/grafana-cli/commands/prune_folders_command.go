@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+func pruneFoldersCommand(c CommandLine) error {
+	if !c.Bool("merge") {
+		return fmt.Errorf("Nothing to do, use --merge to merge folders only differing by case or whitespace")
+	}
+
+	dryRun := c.Bool("dry-run")
+	cmd := models.MergeDuplicateFoldersCommand{DryRun: dryRun}
+	if err := bus.Dispatch(&cmd); err != nil {
+		return fmt.Errorf("Failed to merge folders. Error: %v", err)
+	}
+
+	if len(cmd.Result) == 0 {
+		logger.Infof("No duplicate folders found %s\n", color.GreenString("✔"))
+		return nil
+	}
+
+	for _, merge := range cmd.Result {
+		if merge.Skipped != "" {
+			logger.Infof("Skipped folder %q in org %d: %s\n", merge.Title, merge.OrgId, merge.Skipped)
+			continue
+		}
+
+		verb := "Merged"
+		if dryRun {
+			verb = "Would merge"
+		}
+		logger.Infof("%s %q into folder %q in org %d, moving %d dashboards\n", verb, strings.Join(merge.MergedTitles, `", "`), merge.Title, merge.OrgId, merge.DashboardsMoved)
+	}
+
+	return nil
+}
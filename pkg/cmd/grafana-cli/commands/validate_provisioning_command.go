@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
+)
+
+func validateProvisioningCommand(c CommandLine) error {
+	path := c.Args().First()
+	if path == "" {
+		return fmt.Errorf("Missing path to the dashboard provisioning configs to validate")
+	}
+
+	validations, err := dashboards.ValidateConfigs(path, log.New("validate"))
+	if err != nil {
+		return fmt.Errorf("Failed to read provisioning configs. Error: %v", err)
+	}
+
+	invalid := 0
+	for _, validation := range validations {
+		if validation.Valid() {
+			logger.Infof("%s %s\n", color.GreenString("✔"), validation.Provider)
+			continue
+		}
+
+		invalid++
+		logger.Infof("%s %s\n", color.RedString("✗"), validation.Provider)
+		if validation.Error != nil {
+			logger.Infof("    %v\n", validation.Error)
+		}
+		for _, option := range validation.UnknownOptions {
+			logger.Infof("    unknown option %s\n", option)
+		}
+		for _, file := range validation.Files {
+			if file.Error != nil {
+				logger.Infof("    %s: %v\n", file.Path, file.Error)
+			}
+		}
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d providers are invalid", invalid, len(validations))
+	}
+	return nil
+}
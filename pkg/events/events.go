@@ -89,3 +89,15 @@ type UserUpdated struct {
 	Login     string    `json:"login"`
 	Email     string    `json:"email"`
 }
+
+// DashboardProvisioned is published after a provisioner saved, deleted or unprovisioned a dashboard. Action is one of
+// created, updated, moved, deleted or unprovisioned. Uid is empty for removed dashboards.
+type DashboardProvisioned struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Provider    string    `json:"provider"`
+	OrgId       int64     `json:"orgId"`
+	DashboardId int64     `json:"dashboardId"`
+	Uid         string    `json:"uid"`
+	Path        string    `json:"path"`
+	Action      string    `json:"action"`
+}
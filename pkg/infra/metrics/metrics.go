@@ -39,6 +39,8 @@ var (
 	M_Aws_CloudWatch_ListMetrics         prometheus.Counter
 	M_Aws_CloudWatch_GetMetricData       prometheus.Counter
 	M_DB_DataSource_QueryById            prometheus.Counter
+	M_Provisioning_Soft_Max_Exceeded     *prometheus.CounterVec
+	M_Provisioning_Dashboards            *prometheus.CounterVec
 
 	// Timers
 	M_DataSource_ProxyReq_Timer prometheus.Summary
@@ -52,6 +54,10 @@ var (
 	M_StatTotal_Orgs         prometheus.Gauge
 	M_StatTotal_Playlists    prometheus.Gauge
 
+	// Provisioning
+	M_Provisioning_Scan_Duration        *prometheus.GaugeVec
+	M_Provisioning_Last_Successful_Scan *prometheus.GaugeVec
+
 	// M_Grafana_Version is a gauge that contains build info about this binary
 	//
 	// Deprecated: use M_Grafana_Build_Version instead.
@@ -226,6 +232,18 @@ func init() {
 		Namespace: exporterName,
 	})
 
+	M_Provisioning_Soft_Max_Exceeded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "provisioning_soft_max_dashboards_exceeded_total",
+		Help:      "counter for dashboard provisioning scans finding more dashboards than the soft limit of the provider",
+		Namespace: exporterName,
+	}, []string{"provider"})
+
+	M_Provisioning_Dashboards = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "provisioning_dashboards_total",
+		Help:      "counter for dashboards provisioned by action: inserted, updated, unchanged, deleted, unprovisioned or failed",
+		Namespace: exporterName,
+	}, []string{"provider", "action"})
+
 	M_DataSource_ProxyReq_Timer = prometheus.NewSummary(prometheus.SummaryOpts{
 		Name:      "api_dataproxy_request_all_milliseconds",
 		Help:      "summary for dataproxy request duration",
@@ -274,6 +292,18 @@ func init() {
 		Namespace: exporterName,
 	})
 
+	M_Provisioning_Scan_Duration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "provisioning_scan_duration_seconds",
+		Help:      "duration of the last dashboard provisioning scan of the provider",
+		Namespace: exporterName,
+	}, []string{"provider"})
+
+	M_Provisioning_Last_Successful_Scan = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "provisioning_last_successful_scan_timestamp_seconds",
+		Help:      "time of the last dashboard provisioning scan of the provider that succeeded",
+		Namespace: exporterName,
+	}, []string{"provider"})
+
 	M_Grafana_Version = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Name:      "info",
 		Help:      "Information about the Grafana. This metric is deprecated. please use `grafana_build_info`",
@@ -332,6 +362,10 @@ func initMetricVars() {
 		M_Aws_CloudWatch_ListMetrics,
 		M_Aws_CloudWatch_GetMetricData,
 		M_DB_DataSource_QueryById,
+		M_Provisioning_Soft_Max_Exceeded,
+		M_Provisioning_Dashboards,
+		M_Provisioning_Scan_Duration,
+		M_Provisioning_Last_Successful_Scan,
 		M_Alerting_Active_Alerts,
 		M_StatTotal_Dashboards,
 		M_StatTotal_Users,
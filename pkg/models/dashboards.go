@@ -255,6 +255,16 @@ type DashboardProvisioning struct {
 	ExternalId  string
 	CheckSum    string
 	Updated     int64
+	LockMessage string
+	// InjectedTags are the tags added by the provider, they are removed again when the dashboard is unprovisioned.
+	InjectedTags []string
+	// Revision is the commit of the git repository the dashboard was last saved from, if any.
+	Revision string
+	// AllowUiUpdates lets users save the dashboard in the UI even though it is provisioned.
+	AllowUiUpdates bool
+	// SourcePath is the absolute path of the file the dashboard was last saved from, empty for dashboards not read
+	// from a file and for records saved before the path was stored.
+	SourcePath string
 }
 
 type SaveProvisionedDashboardCommand struct {
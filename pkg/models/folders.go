@@ -90,6 +90,26 @@ type UpdateFolderCommand struct {
 	Result *Folder
 }
 
+// MergeDuplicateFoldersCommand merges folders of an org whose titles only differ by case or whitespace into one
+// folder and deletes the emptied duplicates. Only folders holding nothing but provisioned dashboards are touched.
+// With DryRun the merges are only reported.
+type MergeDuplicateFoldersCommand struct {
+	DryRun bool
+
+	Result []*FolderMerge
+}
+
+// FolderMerge describes the merge of a group of near duplicate folders into the folder with the most dashboards.
+type FolderMerge struct {
+	OrgId           int64
+	FolderId        int64
+	Title           string
+	MergedTitles    []string
+	DashboardsMoved int64
+	// Skipped explains why the group was not merged, for example because dashboard titles would clash.
+	Skipped string
+}
+
 //
 // QUERIES
 //
@@ -55,10 +55,7 @@ func ImportDashboard(cmd *ImportDashboardCommand) error {
 		dashboard = m.NewDashboardFromJson(cmd.Dashboard)
 	}
 
-	evaluator := &DashTemplateEvaluator{
-		template: dashboard.Data,
-		inputs:   cmd.Inputs,
-	}
+	evaluator := NewDashTemplateEvaluator(dashboard.Data, cmd.Inputs)
 
 	generatedDash, err := evaluator.Eval()
 	if err != nil {
@@ -110,6 +107,15 @@ type DashTemplateEvaluator struct {
 	varRegex  *regexp.Regexp
 }
 
+// NewDashTemplateEvaluator creates an evaluator replacing the variables of the __inputs of the dashboard template with
+// the values of the inputs.
+func NewDashTemplateEvaluator(template *simplejson.Json, inputs []ImportDashboardInput) *DashTemplateEvaluator {
+	return &DashTemplateEvaluator{
+		template: template,
+		inputs:   inputs,
+	}
+}
+
 func (this *DashTemplateEvaluator) findInput(varName string, varType string) *ImportDashboardInput {
 
 	for _, input := range this.inputs {
@@ -124,7 +130,7 @@ func (this *DashTemplateEvaluator) findInput(varName string, varType string) *Im
 func (this *DashTemplateEvaluator) Eval() (*simplejson.Json, error) {
 	this.result = simplejson.New()
 	this.variables = make(map[string]string)
-	this.varRegex, _ = regexp.Compile(`(\$\{.+\})`)
+	this.varRegex, _ = regexp.Compile(`(\$\{.+?\})`)
 
 	// check that we have all inputs we need
 	for _, inputDef := range this.template.Get("__inputs").MustArray() {
@@ -59,7 +59,8 @@ func TestDashboardImport(t *testing.T) {
 			}
 		],
 		"test": {
-			"prop": "${DS_NAME}"
+			"prop": "${DS_NAME}",
+			"expr": "up{instance=\"${DS_NAME}\"}"
 		}
 		}`))
 
@@ -77,6 +78,10 @@ func TestDashboardImport(t *testing.T) {
 			So(res.GetPath("test", "prop").MustString(), ShouldEqual, "my-server")
 		})
 
+		Convey("should render variable within text", func() {
+			So(res.GetPath("test", "expr").MustString(), ShouldEqual, `up{instance="my-server"}`)
+		})
+
 		Convey("should not include inputs in output", func() {
 			inputs := res.Get("__inputs")
 			So(inputs.Interface(), ShouldBeNil)
@@ -141,7 +141,7 @@ func (dr *dashboardServiceImpl) buildSaveDashboardCommand(dto *SaveDashboardDTO,
 			return nil, err
 		}
 
-		if provisionedData != nil {
+		if provisionedData != nil && !provisionedData.AllowUiUpdates {
 			return nil, models.ErrDashboardCannotSaveProvisionedDashboard
 		}
 	}
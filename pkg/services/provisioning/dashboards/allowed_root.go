@@ -0,0 +1,58 @@
+package dashboards
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resolveAllowedRoot returns the absolute path of root with symlinks resolved.
+func resolveAllowedRoot(root string) (string, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// checkPathWithinRoot returns an error if path, once made absolute and with symlinks resolved, is outside root.
+// Paths that don't exist yet are checked as they are written.
+func checkPathWithinRoot(path string, root string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		resolved = abs
+	}
+
+	if !isPathWithinRoot(resolved, root) {
+		return fmt.Errorf("path %s resolves to %s outside of allowed root %s", path, resolved, root)
+	}
+	return nil
+}
+
+func isPathWithinRoot(path string, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// removeFilesOutsideRoot drops files found on disk that resolve to a path outside of the allowed root, for example
+// through a symlink.
+func (fr *fileReader) removeFilesOutsideRoot(filesFoundOnDisk map[string]os.FileInfo) {
+	for path := range filesFoundOnDisk {
+		if err := checkPathWithinRoot(path, fr.allowedRoot); err != nil {
+			fr.log.Warn("skipping dashboard file outside of allowed root", "file", path, "error", err)
+			delete(filesFoundOnDisk, path)
+		}
+	}
+}
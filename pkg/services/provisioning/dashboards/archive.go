@@ -0,0 +1,194 @@
+package dashboards
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// archiveSourceType is the type of providers reading dashboards from a tar or zip archive.
+const archiveSourceType = "archive"
+
+// archiveSource unpacks an archive of dashboards into its directory. The archive is only unpacked again when its
+// modification time or size changes.
+type archiveSource struct {
+	path    string
+	workDir string
+	log     log.Logger
+	// unpacked is the archive as it was when it was last unpacked successfully.
+	unpacked os.FileInfo
+}
+
+func newArchiveSource(path string, workDir string, log log.Logger) *archiveSource {
+	return &archiveSource{path: path, workDir: workDir, log: log}
+}
+
+func (s *archiveSource) dir() string {
+	return s.workDir
+}
+
+// sync unpacks the archive into a new directory replacing the one of the last sync once all entries are unpacked, so
+// a broken archive leaves the dashboards of the last one in place. The files get the modification time of the
+// archive.
+func (s *archiveSource) sync() error {
+	fileInfo, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+
+	if s.unpacked != nil && s.unpacked.ModTime().Equal(fileInfo.ModTime()) && s.unpacked.Size() == fileInfo.Size() {
+		if _, err := os.Stat(s.workDir); err == nil {
+			return nil
+		}
+	}
+
+	s.log.Debug("unpacking dashboard archive", "archive", s.path, "dir", s.workDir)
+	tmp := s.workDir + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := unpackArchive(s.path, tmp, fileInfo.ModTime()); err != nil {
+		os.RemoveAll(tmp)
+		return fmt.Errorf("failed to unpack archive %s: %v", s.path, err)
+	}
+
+	if err := os.RemoveAll(s.workDir); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.workDir); err != nil {
+		return err
+	}
+	s.unpacked = fileInfo
+	return nil
+}
+
+// unpackArchive unpacks the .tar, .tar.gz, .tgz or .zip archive at archivePath into dir. Links and entries that would
+// end up outside of dir are rejected.
+func unpackArchive(archivePath string, dir string, modTime time.Time) error {
+	name := strings.ToLower(archivePath)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return unpackZip(archivePath, dir, modTime)
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		file, err := os.Open(archivePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		reader, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		return unpackTar(reader, dir, modTime)
+	case strings.HasSuffix(name, ".tar"):
+		file, err := os.Open(archivePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return unpackTar(file, dir, modTime)
+	default:
+		return fmt.Errorf("unsupported archive, expected a .tar, .tar.gz, .tgz or .zip file")
+	}
+}
+
+func unpackTar(r io.Reader, dir string, modTime time.Time) error {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := pathWithinDir(dir, header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0750); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := writeArchiveEntry(target, reader, modTime); err != nil {
+				return err
+			}
+		case tar.TypeSymlink, tar.TypeLink:
+			return fmt.Errorf("entry %s is a link, links are not allowed", header.Name)
+		}
+	}
+}
+
+func unpackZip(archivePath string, dir string, modTime time.Time) error {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+
+	for _, file := range reader.File {
+		target, err := pathWithinDir(dir, file.Name)
+		if err != nil {
+			return err
+		}
+
+		mode := file.Mode()
+		switch {
+		case mode&os.ModeSymlink != 0:
+			return fmt.Errorf("entry %s is a link, links are not allowed", file.Name)
+		case mode.IsDir():
+			if err := os.MkdirAll(target, 0750); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			content, err := file.Open()
+			if err != nil {
+				return err
+			}
+			err = writeArchiveEntry(target, content, modTime)
+			content.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeArchiveEntry(target string, content io.Reader, modTime time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(target, data, 0640); err != nil {
+		return err
+	}
+	return os.Chtimes(target, modTime, modTime)
+}
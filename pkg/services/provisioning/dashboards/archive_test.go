@@ -0,0 +1,182 @@
+package dashboards
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// archiveEntry is a file of a test archive, a link if linkname is set.
+type archiveEntry struct {
+	name     string
+	content  string
+	linkname string
+}
+
+func writeTarGz(path string, entries []archiveEntry) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: 0644, Size: int64(len(entry.content)), Typeflag: tar.TypeReg}
+		if entry.linkname != "" {
+			header = &tar.Header{Name: entry.name, Mode: 0777, Linkname: entry.linkname, Typeflag: tar.TypeSymlink}
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write([]byte(entry.content)); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+func writeZip(path string, entries []archiveEntry) error {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		w, err := zw.Create(entry.name)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte(entry.content)); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+func TestArchiveDashboards(t *testing.T) {
+	Convey("Dashboards from an archive", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		dir, err := ioutil.TempDir("", "provisioning-archive")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		opts := ReaderOptions{WorkDir: filepath.Join(dir, "work")}
+
+		titles := func() []string {
+			var titles []string
+			for _, dto := range fakeService.inserted {
+				if !dto.Dashboard.IsFolder {
+					titles = append(titles, dto.Dashboard.Title)
+				}
+			}
+			sort.Strings(titles)
+			return titles
+		}
+
+		newReader := func(archive string) *fileReader {
+			cfg := &DashboardsAsConfig{
+				Name:    "Bundle",
+				Type:    "archive",
+				OrgId:   1,
+				Folder:  "Bundle",
+				Options: map[string]interface{}{"path": archive, "foldersFromFilesStructure": true},
+			}
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), opts)
+			So(err, ShouldBeNil)
+			return reader
+		}
+
+		entries := []archiveEntry{
+			{name: "nodes.json", content: `{"title": "Nodes"}`},
+			{name: "team/pods.json", content: `{"title": "Pods"}`},
+			{name: "README.md", content: "not a dashboard"},
+		}
+
+		Convey("should provision the dashboards of a tar.gz archive in folders of its directories", func() {
+			archive := filepath.Join(dir, "dashboards.tar.gz")
+			So(writeTarGz(archive, entries), ShouldBeNil)
+
+			So(newReader(archive).startWalkingDisk(context.Background()), ShouldBeNil)
+			So(titles(), ShouldResemble, []string{"Nodes", "Pods"})
+
+			var folders []string
+			for _, dto := range fakeService.inserted {
+				if dto.Dashboard.IsFolder {
+					folders = append(folders, dto.Dashboard.Title)
+				}
+			}
+			So(folders, ShouldContain, "team")
+		})
+
+		Convey("should provision the dashboards of a zip archive", func() {
+			archive := filepath.Join(dir, "dashboards.zip")
+			So(writeZip(archive, entries), ShouldBeNil)
+
+			So(newReader(archive).startWalkingDisk(context.Background()), ShouldBeNil)
+			So(titles(), ShouldResemble, []string{"Nodes", "Pods"})
+		})
+
+		Convey("should keep the dashboards of the last archive when the new one is broken", func() {
+			archive := filepath.Join(dir, "dashboards.tar.gz")
+			So(writeTarGz(archive, entries), ShouldBeNil)
+			reader := newReader(archive)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(ioutil.WriteFile(archive, []byte("not an archive"), 0644), ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(titles(), ShouldResemble, []string{"Nodes", "Pods"})
+			So(len(fakeService.provisioned["Bundle"]), ShouldEqual, 2)
+		})
+
+		Convey("should reject archives with links", func() {
+			archive := filepath.Join(dir, "dashboards.tar.gz")
+			So(writeTarGz(archive, append(entries, archiveEntry{name: "passwd.json", linkname: "/etc/passwd"})), ShouldBeNil)
+
+			So(newReader(archive).startWalkingDisk(context.Background()), ShouldNotBeNil)
+			So(titles(), ShouldBeEmpty)
+		})
+
+		Convey("should reject entries outside of the archive", func() {
+			archive := filepath.Join(dir, "dashboards.zip")
+			So(writeZip(archive, append(entries, archiveEntry{name: "../escaped.json", content: `{"title": "Escaped"}`})), ShouldBeNil)
+
+			So(newReader(archive).startWalkingDisk(context.Background()), ShouldNotBeNil)
+			So(titles(), ShouldBeEmpty)
+			_, err := os.Stat(filepath.Join(opts.WorkDir, "archive", "escaped.json"))
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+
+		Convey("pathWithinDir", func() {
+			for _, name := range []string{"/etc/passwd", "../x.json", "a/../../x.json", "..\\x.json"} {
+				_, err := pathWithinDir(dir, name)
+				So(err, ShouldNotBeNil)
+			}
+
+			target, err := pathWithinDir(dir, "a/./b/../c.json")
+			So(err, ShouldBeNil)
+			So(target, ShouldEqual, filepath.Join(dir, "a", "c.json"))
+		})
+	})
+}
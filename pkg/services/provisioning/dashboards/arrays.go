@@ -0,0 +1,93 @@
+package dashboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// arrayElementSeparator separates the path of a file from the uid of a dashboard in the external id of dashboards read
+// from a file holding an array of dashboards, like dashboards.json#node-exporter.
+const arrayElementSeparator = "#"
+
+// readDashboardArray reads the dashboards of a file holding an array of dashboards. Every dashboard is tracked on its
+// own by its uid, so removing one from the array only removes that dashboard, and has a checksum of its own, so only
+// changed dashboards are saved again.
+func (fr *fileReader) readDashboardArray(path string, elements []interface{}, lastModified time.Time, folder dashboardFolder) ([]*dashboardJsonFile, error) {
+	jsonFiles := make([]*dashboardJsonFile, 0, len(elements))
+	uids := map[string]bool{}
+	for i, element := range elements {
+		if _, ok := element.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("dashboard %d of the array is not an object", i)
+		}
+
+		// the checksum is taken before the dashboard is transformed
+		encoded, err := json.Marshal(element)
+		if err != nil {
+			return nil, err
+		}
+		checkSum, err := util.Sha256SumString(string(encoded))
+		if err != nil {
+			return nil, err
+		}
+
+		data := simplejson.NewFromAny(element)
+		uid := data.Get("uid").MustString()
+		if uid == "" {
+			return nil, fmt.Errorf("dashboard %d of the array has no uid", i)
+		}
+		if uids[uid] {
+			return nil, fmt.Errorf("uid %q is used by more than one dashboard of the array", uid)
+		}
+		uids[uid] = true
+
+		jsonFile, err := fr.readDashboardJson(path, data, lastModified, folder)
+		if err != nil {
+			return nil, fmt.Errorf("dashboard %q of the array: %v", uid, err)
+		}
+		jsonFile.externalId = externalIdOfPath(path) + arrayElementSeparator + uid
+		jsonFile.checkSum = checkSum
+		jsonFiles = append(jsonFiles, jsonFile)
+	}
+	return jsonFiles, nil
+}
+
+// pathOfExternalId returns the path of the file the dashboard with the external id was read from.
+func pathOfExternalId(externalId string, filesFoundOnDisk map[string]os.FileInfo) string {
+	path := externalId
+	if _, ok := filesFoundOnDisk[path]; !ok {
+		if i := strings.LastIndex(externalId, arrayElementSeparator); i >= 0 {
+			path = externalId[:i]
+		}
+	}
+
+	if _, ok := filesFoundOnDisk[path]; !ok {
+		if _, ok := filesFoundOnDisk[path+gzipExtension]; ok {
+			return path + gzipExtension
+		}
+	}
+	return path
+}
+
+// handleDashboardsRemovedFromFiles will unprovision or delete dashboards whose file is still on disk but doesn't hold
+// them anymore, like a dashboard removed from an array or a file changed from a single dashboard to an array.
+// readExternalIds are the external ids of the dashboards in each file read, dashboards of files that couldn't be read
+// are kept.
+func (fr *fileReader) handleDashboardsRemovedFromFiles(provisionedDashboardRefs map[string]*models.DashboardProvisioning, filesFoundOnDisk map[string]os.FileInfo, readExternalIds map[string][]string) {
+	var removed []*models.DashboardProvisioning
+	for externalId, provisioningData := range provisionedDashboardRefs {
+		read, ok := readExternalIds[pathOfExternalId(externalId, filesFoundOnDisk)]
+		if !ok || containsString(read, externalId) {
+			continue
+		}
+		removed = append(removed, provisioningData)
+	}
+
+	fr.removeProvisionedDashboards(removed)
+}
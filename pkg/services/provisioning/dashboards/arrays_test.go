@@ -0,0 +1,99 @@
+package dashboards
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDashboardArrays(t *testing.T) {
+	Convey("Dashboard files holding an array of dashboards", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		dir, err := ioutil.TempDir("", "provisioning-arrays")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		dir, err = filepath.EvalSymlinks(dir)
+		So(err, ShouldBeNil)
+		path := filepath.Join(dir, "dashboards.json")
+
+		// write replaces the content of the file and moves its modification time forward, so it is read again
+		modTime := time.Now().Add(-time.Hour)
+		write := func(content string) {
+			So(ioutil.WriteFile(path, []byte(content), 0644), ShouldBeNil)
+			modTime = modTime.Add(time.Minute)
+			So(os.Chtimes(path, modTime, modTime), ShouldBeNil)
+		}
+
+		provisionedIds := func() []string {
+			var ids []string
+			for _, p := range fakeService.provisioned["Default"] {
+				ids = append(ids, p.ExternalId)
+			}
+			sort.Strings(ids)
+			return ids
+		}
+
+		cfg := &DashboardsAsConfig{
+			Name:    "Default",
+			Type:    "file",
+			OrgId:   1,
+			Options: map[string]interface{}{"path": dir},
+		}
+		reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+		So(err, ShouldBeNil)
+
+		write(`[{"title": "Nodes", "uid": "nodes"}, {"title": "Pods", "uid": "pods"}, {"title": "Volumes", "uid": "volumes"}]`)
+		So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+		Convey("should provision every dashboard on its own", func() {
+			So(len(fakeService.inserted), ShouldEqual, 3)
+			So(provisionedIds(), ShouldResemble, []string{path + "#nodes", path + "#pods", path + "#volumes"})
+		})
+
+		Convey("should only save changed dashboards", func() {
+			write(`[{"title": "Nodes", "uid": "nodes"}, {"title": "Pods v2", "uid": "pods"}, {"title": "Volumes", "uid": "volumes"}]`)
+			fakeService.inserted = nil
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(len(fakeService.inserted), ShouldEqual, 1)
+			So(fakeService.inserted[0].Dashboard.Title, ShouldEqual, "Pods v2")
+		})
+
+		Convey("should only remove the dashboard removed from the array", func() {
+			write(`[{"title": "Nodes", "uid": "nodes"}, {"title": "Volumes", "uid": "volumes"}]`)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(provisionedIds(), ShouldResemble, []string{path + "#nodes", path + "#volumes"})
+		})
+
+		Convey("should replace the dashboards when changed to a single dashboard", func() {
+			write(`{"title": "Cluster", "uid": "cluster"}`)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(provisionedIds(), ShouldResemble, []string{path})
+		})
+
+		Convey("should keep the dashboards while the file is broken", func() {
+			write(`[{"title": "Nodes", "uid": "nodes"}, {"title": "Pods"}]`)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(len(provisionedIds()), ShouldEqual, 3)
+		})
+	})
+}
@@ -0,0 +1,159 @@
+package dashboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// backupTimeFormat is used in snapshot file names so they sort by the time they were taken.
+const backupTimeFormat = "20060102-150405"
+
+// dashboardBackup is the content of a snapshot file.
+type dashboardBackup struct {
+	Provider   string                 `json:"provider"`
+	OrgId      int64                  `json:"orgId"`
+	Created    time.Time              `json:"created"`
+	Checksum   string                 `json:"checksum"`
+	Dashboards []dashboardBackupEntry `json:"dashboards"`
+}
+
+type dashboardBackupEntry struct {
+	ExternalId string           `json:"externalId"`
+	FolderId   int64            `json:"folderId"`
+	Dashboard  *simplejson.Json `json:"dashboard"`
+}
+
+// writeBackup writes a snapshot of the dashboards provisioned by the provider, as stored in the database, to the
+// backupDir. No snapshot is written if nothing changed since the last one. Only the newest backupRetention
+// snapshots are kept when it is set.
+func (fr *fileReader) writeBackup(dir string, now time.Time) error {
+	provisioned, err := fr.dashboardProvisioningService.GetProvisionedDashboardData(fr.Cfg.Name)
+	if err != nil {
+		return err
+	}
+
+	backup := dashboardBackup{
+		Provider:   fr.Cfg.Name,
+		OrgId:      fr.Cfg.OrgId,
+		Created:    now,
+		Dashboards: []dashboardBackupEntry{},
+	}
+
+	if len(provisioned) > 0 {
+		externalIds := map[int64]string{}
+		query := &models.GetDashboardsQuery{}
+		for _, p := range provisioned {
+			externalIds[p.DashboardId] = p.ExternalId
+			query.DashboardIds = append(query.DashboardIds, p.DashboardId)
+		}
+
+		if err := bus.Dispatch(query); err != nil {
+			return err
+		}
+
+		for _, dash := range query.Result {
+			backup.Dashboards = append(backup.Dashboards, dashboardBackupEntry{
+				ExternalId: externalIds[dash.Id],
+				FolderId:   dash.FolderId,
+				Dashboard:  dash.Data,
+			})
+		}
+		sort.Slice(backup.Dashboards, func(i, j int) bool {
+			return backup.Dashboards[i].ExternalId < backup.Dashboards[j].ExternalId
+		})
+	}
+
+	content, err := json.Marshal(backup.Dashboards)
+	if err != nil {
+		return err
+	}
+	backup.Checksum, err = util.Md5SumString(string(content))
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+
+	prefix := models.SlugifyTitle(fr.Cfg.Name) + "-"
+	snapshots, err := listBackups(dir, prefix)
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) > 0 {
+		latest, err := readBackup(filepath.Join(dir, snapshots[len(snapshots)-1]))
+		if err == nil && latest.Checksum == backup.Checksum {
+			return nil
+		}
+	}
+
+	content, err = json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	name := prefix + now.UTC().Format(backupTimeFormat) + ".json"
+	if err := ioutil.WriteFile(filepath.Join(dir, name), content, 0640); err != nil {
+		return err
+	}
+	fr.log.Info("wrote dashboard backup", "file", name, "dashboards", len(backup.Dashboards))
+
+	retention := int(getInt64Option(fr.Cfg.Options, "backupRetention"))
+	snapshots = append(snapshots, name)
+	if retention <= 0 || len(snapshots) <= retention {
+		return nil
+	}
+
+	for _, old := range snapshots[:len(snapshots)-retention] {
+		if err := os.Remove(filepath.Join(dir, old)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listBackups returns the names of the snapshot files with the prefix, oldest first.
+func listBackups(dir string, prefix string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, file := range files {
+		// the length check keeps providers whose names start with the same prefix apart
+		name := file.Name()
+		if !file.IsDir() && strings.HasPrefix(name, prefix) && strings.HasSuffix(name, ".json") && len(name) == len(prefix)+len(backupTimeFormat)+len(".json") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func readBackup(path string) (*dashboardBackup, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	backup := &dashboardBackup{}
+	if err := json.Unmarshal(content, backup); err != nil {
+		return nil, fmt.Errorf("could not read backup %s: %v", path, err)
+	}
+	return backup, nil
+}
@@ -0,0 +1,94 @@
+package dashboards
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDashboardBackup(t *testing.T) {
+	Convey("Dashboard backup", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		bus.AddHandler("test", func(query *models.GetDashboardsQuery) error {
+			for _, id := range query.DashboardIds {
+				for _, dto := range fakeService.inserted {
+					if dto.Dashboard.Id == id {
+						query.Result = append(query.Result, dto.Dashboard)
+					}
+				}
+			}
+			return nil
+		})
+
+		dir, err := ioutil.TempDir("", "provisioning-backup")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		cfg := &DashboardsAsConfig{
+			Name:    "Default",
+			Type:    "file",
+			OrgId:   1,
+			Options: map[string]interface{}{"path": defaultDashboards, "backupDir": dir},
+		}
+		reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+		So(err, ShouldBeNil)
+
+		Convey("scan should write snapshot of provisioned dashboards", func() {
+			err := reader.startWalkingDisk(context.Background())
+			So(err, ShouldBeNil)
+
+			snapshots, err := listBackups(dir, "default-")
+			So(err, ShouldBeNil)
+			So(len(snapshots), ShouldEqual, 1)
+
+			backup, err := readBackup(filepath.Join(dir, snapshots[0]))
+			So(err, ShouldBeNil)
+			So(backup.Provider, ShouldEqual, "Default")
+			So(len(backup.Dashboards), ShouldEqual, 2)
+			So(backup.Dashboards[0].Dashboard.Get("title").MustString(), ShouldEqual, "Grafana1")
+
+			Convey("and skip snapshot when nothing changed", func() {
+				err := reader.writeBackup(dir, time.Now().Add(time.Hour))
+				So(err, ShouldBeNil)
+
+				snapshots, err := listBackups(dir, "default-")
+				So(err, ShouldBeNil)
+				So(len(snapshots), ShouldEqual, 1)
+			})
+		})
+
+		Convey("should only keep backupRetention snapshots", func() {
+			cfg.Options["backupRetention"] = 2
+			delete(cfg.Options, "backupDir")
+			err := reader.startWalkingDisk(context.Background())
+			So(err, ShouldBeNil)
+
+			now := time.Date(2019, 5, 1, 12, 0, 0, 0, time.UTC)
+			for i := 0; i < 3; i++ {
+				fakeService.inserted[0].Dashboard.Data.Set("version", i)
+				err = reader.writeBackup(dir, now.Add(time.Duration(i)*time.Hour))
+				So(err, ShouldBeNil)
+			}
+
+			snapshots, err := listBackups(dir, "default-")
+			So(err, ShouldBeNil)
+			So(snapshots, ShouldResemble, []string{"default-20190501-130000.json", "default-20190501-140000.json"})
+		})
+
+		Reset(func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		})
+	})
+}
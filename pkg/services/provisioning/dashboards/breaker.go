@@ -0,0 +1,76 @@
+package dashboards
+
+import (
+	"time"
+)
+
+// defaultMaxScanCooldown caps the cooldown of a provider whose scans keep failing when no cap is configured.
+const defaultMaxScanCooldown = 10 * time.Minute
+
+// scanBreaker stops a provider from scanning on every interval when its scans keep failing. After threshold
+// consecutive failures it opens for a cooldown that doubles with every further failure up to maxCooldown. The
+// first successful scan closes it again.
+type scanBreaker struct {
+	threshold   int
+	cooldown    time.Duration
+	maxCooldown time.Duration
+	failures    int
+	openUntil   time.Time
+	now         func() time.Time
+}
+
+func newScanBreaker(cfg *DashboardsAsConfig) *scanBreaker {
+	maxCooldown := time.Duration(getInt64Option(cfg.Options, "scanBackoffMaxSeconds")) * time.Second
+	if maxCooldown <= 0 {
+		maxCooldown = defaultMaxScanCooldown
+	}
+
+	return &scanBreaker{
+		threshold:   int(getInt64Option(cfg.Options, "scanFailureThreshold")),
+		cooldown:    time.Duration(cfg.UpdateIntervalSeconds) * time.Second,
+		maxCooldown: maxCooldown,
+		now:         time.Now,
+	}
+}
+
+// isOpen returns true while scans should be skipped.
+func (b *scanBreaker) isOpen() bool {
+	return b.now().Before(b.openUntil)
+}
+
+// tripped returns true if the provider failed often enough to be cooling down between scans.
+func (b *scanBreaker) tripped() bool {
+	return b.threshold > 0 && b.failures >= b.threshold
+}
+
+// run runs the scan unless the breaker is open and records its result. Returns true if the scan was run.
+func (b *scanBreaker) run(scan func() error) (bool, error) {
+	if b.isOpen() {
+		return false, nil
+	}
+
+	err := scan()
+	if err != nil {
+		b.failures++
+		if b.tripped() {
+			b.openUntil = b.now().Add(b.currentCooldown())
+		}
+		return true, err
+	}
+
+	b.failures = 0
+	b.openUntil = time.Time{}
+	return true, nil
+}
+
+func (b *scanBreaker) currentCooldown() time.Duration {
+	cooldown := b.cooldown
+	for i := b.threshold; i < b.failures && cooldown < b.maxCooldown; i++ {
+		cooldown *= 2
+	}
+
+	if cooldown > b.maxCooldown {
+		return b.maxCooldown
+	}
+	return cooldown
+}
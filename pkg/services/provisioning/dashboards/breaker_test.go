@@ -0,0 +1,80 @@
+package dashboards
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestScanBreaker(t *testing.T) {
+	Convey("Scan breaker", t, func() {
+		now := time.Date(2019, 5, 1, 12, 0, 0, 0, time.UTC)
+		cfg := &DashboardsAsConfig{
+			UpdateIntervalSeconds: 10,
+			Options: map[string]interface{}{
+				"scanFailureThreshold":  3,
+				"scanBackoffMaxSeconds": 30,
+			},
+		}
+		breaker := newScanBreaker(cfg)
+		breaker.now = func() time.Time { return now }
+
+		scans := 0
+		failing := true
+		scan := func() error {
+			scans++
+			if failing {
+				return errors.New("backend unavailable")
+			}
+			return nil
+		}
+
+		Convey("should open after threshold failures", func() {
+			for i := 0; i < 3; i++ {
+				scanned, err := breaker.run(scan)
+				So(scanned, ShouldBeTrue)
+				So(err, ShouldNotBeNil)
+			}
+
+			So(breaker.isOpen(), ShouldBeTrue)
+			scanned, _ := breaker.run(scan)
+			So(scanned, ShouldBeFalse)
+			So(scans, ShouldEqual, 3)
+
+			Convey("and double the cooldown up to the cap", func() {
+				So(breaker.openUntil, ShouldEqual, now.Add(10*time.Second))
+
+				now = now.Add(10 * time.Second)
+				_, _ = breaker.run(scan)
+				So(breaker.openUntil, ShouldEqual, now.Add(20*time.Second))
+
+				now = now.Add(20 * time.Second)
+				_, _ = breaker.run(scan)
+				So(breaker.openUntil, ShouldEqual, now.Add(30*time.Second))
+			})
+
+			Convey("and close after a success", func() {
+				now = now.Add(10 * time.Second)
+				failing = false
+
+				scanned, err := breaker.run(scan)
+				So(scanned, ShouldBeTrue)
+				So(err, ShouldBeNil)
+				So(breaker.isOpen(), ShouldBeFalse)
+				So(breaker.tripped(), ShouldBeFalse)
+			})
+		})
+
+		Convey("should never open without threshold", func() {
+			delete(cfg.Options, "scanFailureThreshold")
+			breaker := newScanBreaker(cfg)
+
+			for i := 0; i < 10; i++ {
+				scanned, _ := breaker.run(scan)
+				So(scanned, ShouldBeTrue)
+			}
+		})
+	})
+}
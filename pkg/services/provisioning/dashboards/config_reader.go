@@ -40,7 +40,7 @@ func (cr *configReader) parseConfigs(file os.FileInfo) ([]*DashboardsAsConfig, e
 		}
 
 		if v1 != nil {
-			return v1.mapToDashboardAsConfig(), nil
+			return withConfigPath(v1.mapToDashboardAsConfig(), filename), nil
 		}
 	} else {
 		var v0 []*DashboardsAsConfigV0
@@ -51,13 +51,21 @@ func (cr *configReader) parseConfigs(file os.FileInfo) ([]*DashboardsAsConfig, e
 
 		if v0 != nil {
 			cr.log.Warn("[Deprecated] the dashboard provisioning config is outdated. please upgrade", "filename", filename)
-			return mapV0ToDashboardAsConfig(v0), nil
+			return withConfigPath(mapV0ToDashboardAsConfig(v0), filename), nil
 		}
 	}
 
 	return []*DashboardsAsConfig{}, nil
 }
 
+// withConfigPath sets the config file the providers were read from.
+func withConfigPath(configs []*DashboardsAsConfig, filename string) []*DashboardsAsConfig {
+	for _, cfg := range configs {
+		cfg.ConfigPath = filename
+	}
+	return configs
+}
+
 func (cr *configReader) readConfig() ([]*DashboardsAsConfig, error) {
 	var dashboards []*DashboardsAsConfig
 
@@ -89,7 +97,7 @@ func (cr *configReader) readConfig() ([]*DashboardsAsConfig, error) {
 		}
 
 		if dashboard.UpdateIntervalSeconds == 0 {
-			dashboard.UpdateIntervalSeconds = 10
+			dashboard.UpdateIntervalSeconds = DefaultUpdateIntervalSeconds
 		}
 		if len(dashboard.FolderUid) > 0 {
 			uidUsage[dashboard.FolderUid] += 1
@@ -104,3 +112,15 @@ func (cr *configReader) readConfig() ([]*DashboardsAsConfig, error) {
 
 	return dashboards, nil
 }
+
+// ReadConfigFile reads the providers of a single dashboard provisioning config file, for example to lint dashboards
+// against the rules of a provider.
+func ReadConfigFile(path string, logger log.Logger) ([]*DashboardsAsConfig, error) {
+	file, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cr := &configReader{path: filepath.Dir(path), log: logger}
+	return cr.parseConfigs(file)
+}
@@ -12,6 +12,7 @@ var (
 	simpleDashboardConfig = "./testdata/test-configs/dashboards-from-disk"
 	oldVersion            = "./testdata/test-configs/version-0"
 	brokenConfigs         = "./testdata/test-configs/broken-configs"
+	severalOrgs           = "./testdata/test-configs/several-orgs"
 )
 
 func TestDashboardsAsConfig(t *testing.T) {
@@ -36,6 +37,18 @@ func TestDashboardsAsConfig(t *testing.T) {
 			validateDashboardAsConfig(t, cfg)
 		})
 
+		Convey("Can read providers provisioning into several orgs", func() {
+			cfgProvider := configReader{path: severalOrgs, log: logger}
+			cfg, err := cfgProvider.readConfig()
+			So(err, ShouldBeNil)
+			So(len(cfg), ShouldEqual, 2)
+
+			So(cfg[0].AllOrgs, ShouldBeTrue)
+			So(cfg[0].OrgIds, ShouldBeEmpty)
+			So(cfg[1].AllOrgs, ShouldBeFalse)
+			So(cfg[1].OrgIds, ShouldResemble, []int64{2, 3})
+		})
+
 		Convey("Should skip invalid path", func() {
 
 			cfgProvider := configReader{path: "/invalid-directory", log: logger}
@@ -71,10 +84,13 @@ func validateDashboardAsConfig(t *testing.T, cfg []*DashboardsAsConfig) {
 	So(ds.Folder, ShouldEqual, "developers")
 	So(ds.FolderUid, ShouldEqual, "xyz")
 	So(ds.Editable, ShouldBeTrue)
+	So(ds.EditableSet, ShouldBeTrue)
 	So(len(ds.Options), ShouldEqual, 1)
 	So(ds.Options["path"], ShouldEqual, "/var/lib/grafana/dashboards")
 	So(ds.DisableDeletion, ShouldBeTrue)
 	So(ds.UpdateIntervalSeconds, ShouldEqual, 15)
+	So(ds.Jpath, ShouldResemble, []string{"/var/lib/grafana/jsonnet", "vendor"})
+	So(ds.Tags, ShouldResemble, []string{"provisioned", "team:developers"})
 
 	ds2 := cfg[1]
 	So(ds2.Name, ShouldEqual, "default")
@@ -83,8 +99,11 @@ func validateDashboardAsConfig(t *testing.T, cfg []*DashboardsAsConfig) {
 	So(ds2.Folder, ShouldEqual, "")
 	So(ds2.FolderUid, ShouldEqual, "")
 	So(ds2.Editable, ShouldBeFalse)
+	So(ds2.EditableSet, ShouldBeFalse)
 	So(len(ds2.Options), ShouldEqual, 1)
 	So(ds2.Options["path"], ShouldEqual, "/var/lib/grafana/dashboards")
 	So(ds2.DisableDeletion, ShouldBeFalse)
 	So(ds2.UpdateIntervalSeconds, ShouldEqual, 10)
+	So(len(ds2.Jpath), ShouldEqual, 0)
+	So(len(ds2.Tags), ShouldEqual, 0)
 }
@@ -3,6 +3,7 @@ package dashboards
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/util/errutil"
@@ -13,7 +14,7 @@ type DashboardProvisionerImpl struct {
 	fileReaders []*fileReader
 }
 
-func NewDashboardProvisionerImpl(configDirectory string) (*DashboardProvisionerImpl, error) {
+func NewDashboardProvisionerImpl(configDirectory string, opts ReaderOptions) (*DashboardProvisionerImpl, error) {
 	logger := log.New("provisioning.dashboard")
 	cfgReader := &configReader{path: configDirectory, log: logger}
 	configs, err := cfgReader.readConfig()
@@ -22,12 +23,17 @@ func NewDashboardProvisionerImpl(configDirectory string) (*DashboardProvisionerI
 		return nil, errutil.Wrap("Failed to read dashboards config", err)
 	}
 
-	fileReaders, err := getFileReaders(configs, logger)
+	fileReaders, err := getFileReaders(configs, opts, logger)
 
 	if err != nil {
 		return nil, errutil.Wrap("Failed to initialize file readers", err)
 	}
 
+	owners := newUidOwners()
+	for _, reader := range fileReaders {
+		reader.uidOwners = owners
+	}
+
 	d := &DashboardProvisionerImpl{
 		log:         logger,
 		fileReaders: fileReaders,
@@ -37,8 +43,25 @@ func NewDashboardProvisionerImpl(configDirectory string) (*DashboardProvisionerI
 }
 
 func (provider *DashboardProvisionerImpl) Provision() error {
+	return provider.ProvisionWithOptions(context.Background(), ScanOptions{})
+}
+
+// ProvisionWithOptions scans all providers once with the behaviour of the scan changed by opts. Cancelling ctx stops
+// the scan before the next file.
+func (provider *DashboardProvisionerImpl) ProvisionWithOptions(ctx context.Context, opts ScanOptions) error {
+	groups := provider.transactionGroups()
 	for _, reader := range provider.fileReaders {
-		err := reader.startWalkingDisk()
+		if name := reader.Cfg.TransactionGroup; name != "" {
+			// the whole group is provisioned at its first provider
+			if readers := groups[name]; readers[0] == reader {
+				if err := provisionTransactionGroup(ctx, name, readers, opts); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		err := reader.startWalkingDiskWithOptions(ctx, opts)
 		if err != nil {
 			return errutil.Wrapf(err, "Failed to provision config %v", reader.Cfg.Name)
 		}
@@ -51,28 +74,56 @@ func (provider *DashboardProvisionerImpl) Provision() error {
 // defined in the config.
 func (provider *DashboardProvisionerImpl) PollChanges(ctx context.Context) {
 	for _, reader := range provider.fileReaders {
-		go reader.pollChanges(ctx)
+		if reader.Cfg.TransactionGroup == "" {
+			go reader.pollChanges(ctx)
+		}
+	}
+
+	for name, readers := range provider.transactionGroups() {
+		go pollTransactionGroupChanges(ctx, name, readers)
+	}
+}
+
+// transactionGroups returns the providers of each transaction group in the order they are configured.
+func (provider *DashboardProvisionerImpl) transactionGroups() map[string][]*fileReader {
+	groups := map[string][]*fileReader{}
+	for _, reader := range provider.fileReaders {
+		if name := reader.Cfg.TransactionGroup; name != "" {
+			groups[name] = append(groups[name], reader)
+		}
 	}
+	return groups
 }
 
 // GetProvisionerResolvedPath returns resolved path for the specified provisioner name. Can be used to generate
 // relative path to provisioning file from it's external_id.
 func (provider *DashboardProvisionerImpl) GetProvisionerResolvedPath(name string) string {
 	for _, reader := range provider.fileReaders {
-		if reader.Cfg.Name == name {
+		if reader.provisionsAs(name) {
 			return reader.resolvedPath()
 		}
 	}
 	return ""
 }
 
-func getFileReaders(configs []*DashboardsAsConfig, logger log.Logger) ([]*fileReader, error) {
+func getFileReaders(configs []*DashboardsAsConfig, opts ReaderOptions, logger log.Logger) ([]*fileReader, error) {
+	// all invalid providers are reported at once instead of one per restart
+	var invalid []string
+	for _, config := range configs {
+		if err := config.Validate(); err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s: %v", config.Name, err))
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("Invalid dashboard providers. %s", strings.Join(invalid, "; "))
+	}
+
 	var readers []*fileReader
 
 	for _, config := range configs {
 		switch config.Type {
-		case "file":
-			fileReader, err := NewDashboardFileReader(config, logger.New("type", config.Type, "name", config.Name))
+		case "file", archiveSourceType, httpSourceType, s3SourceType, gitSourceType:
+			fileReader, err := NewDashboardFileReader(config, logger.New("type", config.Type, "name", config.Name), opts)
 			if err != nil {
 				return nil, errutil.Wrapf(err, "Failed to create file reader for config %v", config.Name)
 			}
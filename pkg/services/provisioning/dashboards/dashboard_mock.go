@@ -4,14 +4,22 @@ import "context"
 
 type Calls struct {
 	Provision                  []interface{}
+	ProvisionWithOptions       []interface{}
 	PollChanges                []interface{}
+	Reload                     []interface{}
+	Stop                       []interface{}
+	Status                     []interface{}
 	GetProvisionerResolvedPath []interface{}
 }
 
 type DashboardProvisionerMock struct {
 	Calls                          *Calls
 	ProvisionFunc                  func() error
+	ProvisionWithOptionsFunc       func(ctx context.Context, opts ScanOptions) error
 	PollChangesFunc                func(ctx context.Context)
+	ReloadFunc                     func(ctx context.Context) error
+	StopFunc                       func()
+	StatusFunc                     func() []ProviderStatus
 	GetProvisionerResolvedPathFunc func(name string) string
 }
 
@@ -29,6 +37,18 @@ func (dpm *DashboardProvisionerMock) Provision() error {
 	return nil
 }
 
+func (dpm *DashboardProvisionerMock) ProvisionWithOptions(ctx context.Context, opts ScanOptions) error {
+	dpm.Calls.ProvisionWithOptions = append(dpm.Calls.ProvisionWithOptions, opts)
+	if dpm.ProvisionWithOptionsFunc != nil {
+		return dpm.ProvisionWithOptionsFunc(ctx, opts)
+	}
+	// Tests not interested in the options can stub Provision for both.
+	if dpm.ProvisionFunc != nil {
+		return dpm.ProvisionFunc()
+	}
+	return nil
+}
+
 func (dpm *DashboardProvisionerMock) PollChanges(ctx context.Context) {
 	dpm.Calls.PollChanges = append(dpm.Calls.PollChanges, ctx)
 	if dpm.PollChangesFunc != nil {
@@ -36,6 +56,29 @@ func (dpm *DashboardProvisionerMock) PollChanges(ctx context.Context) {
 	}
 }
 
+func (dpm *DashboardProvisionerMock) Reload(ctx context.Context) error {
+	dpm.Calls.Reload = append(dpm.Calls.Reload, ctx)
+	if dpm.ReloadFunc != nil {
+		return dpm.ReloadFunc(ctx)
+	}
+	return nil
+}
+
+func (dpm *DashboardProvisionerMock) Stop() {
+	dpm.Calls.Stop = append(dpm.Calls.Stop, nil)
+	if dpm.StopFunc != nil {
+		dpm.StopFunc()
+	}
+}
+
+func (dpm *DashboardProvisionerMock) Status() []ProviderStatus {
+	dpm.Calls.Status = append(dpm.Calls.Status, nil)
+	if dpm.StatusFunc != nil {
+		return dpm.StatusFunc()
+	}
+	return nil
+}
+
 func (dpm *DashboardProvisionerMock) GetProvisionerResolvedPath(name string) string {
 	dpm.Calls.PollChanges = append(dpm.Calls.GetProvisionerResolvedPath, name)
 	if dpm.GetProvisionerResolvedPathFunc != nil {
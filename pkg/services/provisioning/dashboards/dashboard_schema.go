@@ -0,0 +1,121 @@
+package dashboards
+
+// dashboardSchema is the json schema dashboards are checked against with the validateSchema option. It only covers
+// the parts of the dashboard model Grafana relies on when rendering, unknown properties are allowed so dashboards
+// using properties of plugins or newer Grafana versions still pass.
+const dashboardSchema = `{
+  "type": "object",
+  "required": ["title"],
+  "properties": {
+    "id": {"type": ["integer", "null"]},
+    "uid": {"type": ["string", "null"]},
+    "title": {"type": "string"},
+    "description": {"type": "string"},
+    "tags": {"type": "array", "items": {"type": "string"}},
+    "style": {"enum": ["dark", "light"]},
+    "timezone": {"type": "string"},
+    "editable": {"type": "boolean"},
+    "hideControls": {"type": "boolean"},
+    "graphTooltip": {"type": "integer", "minimum": 0},
+    "refresh": {"type": ["string", "boolean"]},
+    "schemaVersion": {"type": "integer", "minimum": 0},
+    "version": {"type": "integer", "minimum": 0},
+    "time": {
+      "type": "object",
+      "properties": {
+        "from": {"type": "string"},
+        "to": {"type": "string"}
+      }
+    },
+    "timepicker": {"type": "object"},
+    "templating": {
+      "type": "object",
+      "properties": {
+        "list": {
+          "type": "array",
+          "items": {
+            "type": "object",
+            "required": ["name", "type"],
+            "properties": {
+              "name": {"type": "string"},
+              "type": {"enum": ["query", "custom", "constant", "datasource", "interval", "textbox", "adhoc"]},
+              "label": {"type": ["string", "null"]},
+              "hide": {"type": "integer", "minimum": 0},
+              "options": {"type": "array"}
+            }
+          }
+        }
+      }
+    },
+    "annotations": {
+      "type": "object",
+      "properties": {
+        "list": {
+          "type": "array",
+          "items": {
+            "type": "object",
+            "properties": {
+              "name": {"type": "string"},
+              "enable": {"type": "boolean"},
+              "hide": {"type": "boolean"},
+              "iconColor": {"type": "string"}
+            }
+          }
+        }
+      }
+    },
+    "links": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "title": {"type": "string"},
+          "type": {"enum": ["link", "dashboards"]},
+          "url": {"type": "string"},
+          "tags": {"type": "array", "items": {"type": "string"}}
+        }
+      }
+    },
+    "panels": {"type": "array", "items": {"$ref": "#/definitions/panel"}},
+    "rows": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "title": {"type": "string"},
+          "collapse": {"type": "boolean"},
+          "panels": {"type": "array", "items": {"$ref": "#/definitions/panel"}}
+        }
+      }
+    }
+  },
+  "definitions": {
+    "panel": {
+      "type": "object",
+      "required": ["type"],
+      "properties": {
+        "id": {"type": "integer"},
+        "type": {"type": "string"},
+        "title": {"type": "string"},
+        "description": {"type": "string"},
+        "datasource": {"type": ["string", "object", "null"]},
+        "transparent": {"type": "boolean"},
+        "collapsed": {"type": "boolean"},
+        "gridPos": {
+          "type": "object",
+          "required": ["h", "w", "x", "y"],
+          "properties": {
+            "h": {"type": "integer", "minimum": 1},
+            "w": {"type": "integer", "minimum": 1},
+            "x": {"type": "integer", "minimum": 0},
+            "y": {"type": "integer", "minimum": 0}
+          }
+        },
+        "targets": {"type": "array", "items": {"type": "object"}},
+        "links": {"type": "array", "items": {"type": "object"}},
+        "fieldConfig": {"type": "object"},
+        "panels": {"type": "array", "items": {"$ref": "#/definitions/panel"}}
+      }
+    }
+  }
+}`
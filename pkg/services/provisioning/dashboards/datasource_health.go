@@ -0,0 +1,106 @@
+package dashboards
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// datasourceHealthCheckTimeout limits how long a single datasource health check may take.
+const datasourceHealthCheckTimeout = 10 * time.Second
+
+// checkDatasourceHealth checks a datasource for the requireHealthyDatasources option.
+var checkDatasourceHealth = pingDatasource
+
+// pingDatasource checks that the datasource can be reached. Datasources with a http url must answer without a server
+// error, for other datasources a tcp connection to the host in the url must succeed. Datasources without url are
+// considered healthy.
+func pingDatasource(ds *models.DataSource) error {
+	if ds.Url == "" {
+		return nil
+	}
+
+	u, err := url.Parse(ds.Url)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		conn, err := net.DialTimeout("tcp", ds.Url, datasourceHealthCheckTimeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	client, err := ds.GetHttpClient()
+	if err != nil {
+		return err
+	}
+	client.Timeout = datasourceHealthCheckTimeout
+
+	resp, err := client.Get(ds.Url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("datasource responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// referencedDatasources returns the names of the datasources referenced by panels, queries, annotations and template
+// variables of the dashboard. References through variables are left out.
+func referencedDatasources(data *simplejson.Json) []string {
+	names := map[string]bool{}
+	collect := func(holder *simplejson.Json) {
+		if name, ok := holder.Get("datasource").Interface().(string); ok && isHardcodedDatasource(name) {
+			names[name] = true
+		}
+	}
+
+	forEachPanel(data, func(panel *simplejson.Json) {
+		collect(panel)
+		for _, target := range panel.Get("targets").MustArray() {
+			collect(simplejson.NewFromAny(target))
+		}
+	})
+	for _, item := range data.GetPath("annotations", "list").MustArray() {
+		collect(simplejson.NewFromAny(item))
+	}
+	for _, item := range data.GetPath("templating", "list").MustArray() {
+		collect(simplejson.NewFromAny(item))
+	}
+
+	var result []string
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// findUnhealthyDatasource returns the name and health check error of the first datasource referenced by the dashboard
+// that is unhealthy. Datasources that don't exist in the org are not checked. Results are kept for the rest of the
+// scan so every datasource is only checked once.
+func (fr *fileReader) findUnhealthyDatasource(data *simplejson.Json) (string, error) {
+	for _, name := range referencedDatasources(data) {
+		err, checked := fr.datasourceHealth[name]
+		if !checked {
+			query := &models.GetDataSourceByNameQuery{Name: name, OrgId: fr.Cfg.OrgId}
+			if bus.Dispatch(query) == nil && query.Result != nil {
+				err = checkDatasourceHealth(query.Result)
+			}
+			fr.datasourceHealth[name] = err
+		}
+
+		if err != nil {
+			return name, err
+		}
+	}
+	return "", nil
+}
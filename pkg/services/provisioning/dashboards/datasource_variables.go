@@ -0,0 +1,138 @@
+package dashboards
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// variablizeDatasources replaces datasource names hardcoded in panels, queries, annotations and template variables
+// with a reference to a datasource template variable of the same plugin type, adding the variable if the dashboard
+// has none. This makes dashboards exported from other instances work with whatever datasources exist here. The
+// plugin type is taken from the datasource with the same name in the org or, if there is none, from the single
+// datasource plugin listed in __requires. Names whose type cannot be resolved are left as they are. Returns the
+// replaced names.
+func variablizeDatasources(data *simplejson.Json, orgId int64) []string {
+	requiredType := ""
+	for _, item := range data.Get("__requires").MustArray() {
+		required := simplejson.NewFromAny(item)
+		if required.Get("type").MustString() != "datasource" {
+			continue
+		}
+		if requiredType != "" {
+			// more than one plugin, can't tell which one a datasource is
+			requiredType = ""
+			break
+		}
+		requiredType = required.Get("id").MustString()
+	}
+
+	types := map[string]string{}
+	resolveType := func(name string) string {
+		if pluginType, ok := types[name]; ok {
+			return pluginType
+		}
+
+		query := &models.GetDataSourceByNameQuery{Name: name, OrgId: orgId}
+		if err := bus.Dispatch(query); err == nil && query.Result != nil {
+			types[name] = query.Result.Type
+		} else {
+			types[name] = requiredType
+		}
+		return types[name]
+	}
+
+	variables := map[string]string{}
+	for _, item := range data.GetPath("templating", "list").MustArray() {
+		variable := simplejson.NewFromAny(item)
+		if variable.Get("type").MustString() == "datasource" {
+			variables[variable.Get("query").MustString()] = variable.Get("name").MustString()
+		}
+	}
+
+	var added []interface{}
+	replaced := map[string]bool{}
+	replace := func(holder *simplejson.Json) {
+		name, ok := holder.Get("datasource").Interface().(string)
+		if !ok || !isHardcodedDatasource(name) {
+			return
+		}
+
+		pluginType := resolveType(name)
+		if pluginType == "" {
+			return
+		}
+
+		variable, ok := variables[pluginType]
+		if !ok {
+			variable = "datasource"
+			if _, taken := findVariable(data, variable); taken || len(variables) > 0 {
+				variable = "datasource_" + pluginType
+			}
+			variables[pluginType] = variable
+			added = append(added, map[string]interface{}{
+				"name":    variable,
+				"type":    "datasource",
+				"query":   pluginType,
+				"label":   "Datasource",
+				"hide":    0,
+				"refresh": 1,
+				"regex":   "",
+				"options": []interface{}{},
+				"current": map[string]interface{}{},
+			})
+		}
+
+		holder.Set("datasource", "$"+variable)
+		replaced[name] = true
+	}
+
+	forEachPanel(data, func(panel *simplejson.Json) {
+		replace(panel)
+		for _, target := range panel.Get("targets").MustArray() {
+			replace(simplejson.NewFromAny(target))
+		}
+	})
+
+	for _, item := range data.GetPath("annotations", "list").MustArray() {
+		annotation := simplejson.NewFromAny(item)
+		if annotation.Get("builtIn").MustInt() != 1 {
+			replace(annotation)
+		}
+	}
+
+	list := data.GetPath("templating", "list").MustArray()
+	for _, item := range list {
+		replace(simplejson.NewFromAny(item))
+	}
+
+	if len(added) > 0 {
+		data.SetPath([]string{"templating", "list"}, append(added, list...))
+	}
+
+	var names []string
+	for name := range replaced {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isHardcodedDatasource returns false for datasource references that don't name a datasource of this instance,
+// like variables or the special built in datasources.
+func isHardcodedDatasource(name string) bool {
+	return name != "" && !strings.HasPrefix(name, "$") && !strings.HasPrefix(name, "-- ")
+}
+
+func findVariable(data *simplejson.Json, name string) (*simplejson.Json, bool) {
+	for _, item := range data.GetPath("templating", "list").MustArray() {
+		variable := simplejson.NewFromAny(item)
+		if variable.Get("name").MustString() == name {
+			return variable, true
+		}
+	}
+	return nil, false
+}
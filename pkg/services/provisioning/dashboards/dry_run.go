@@ -0,0 +1,169 @@
+package dashboards
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+)
+
+// Actions planned by a dry run.
+const (
+	dryRunActionInsert      = "insert"
+	dryRunActionUpdate      = "update"
+	dryRunActionSkip        = "skip"
+	dryRunActionDelete      = "delete"
+	dryRunActionUnprovision = "unprovision"
+)
+
+// Reasons of the actions planned by a dry run.
+const (
+	dryRunReasonNew       = "new"
+	dryRunReasonChanged   = "changed"
+	dryRunReasonUnchanged = "unchanged"
+	dryRunReasonMoved     = "moved"
+	dryRunReasonMissing   = "missing"
+)
+
+// generalFolderTitle is the title of the folder of dashboards without folder.
+const generalFolderTitle = "General"
+
+// dryRunAction is an action a scan would have taken without the dryRun option.
+type dryRunAction struct {
+	Action      string
+	Reason      string
+	Source      string
+	Uid         string
+	Title       string
+	DashboardId int64
+	Folder      string
+}
+
+// dryRunProvisioningService is used instead of the provisioning service with the dryRun option. Provisioning data is
+// read from the wrapped service, but nothing is saved or removed. Folders that would be created get negative ids, so
+// dashboards going into them can still be told apart.
+type dryRunProvisioningService struct {
+	dashboards.DashboardProvisioningService
+
+	mu           sync.Mutex
+	nextFolderId int64
+	folders      map[int64]string
+}
+
+func newDryRunProvisioningService(service dashboards.DashboardProvisioningService) *dryRunProvisioningService {
+	return &dryRunProvisioningService{
+		DashboardProvisioningService: service,
+		folders:                      map[int64]string{},
+	}
+}
+
+func (s *dryRunProvisioningService) SaveProvisionedDashboard(dto *dashboards.SaveDashboardDTO, provisioning *models.DashboardProvisioning) (*models.Dashboard, error) {
+	return dto.Dashboard, nil
+}
+
+func (s *dryRunProvisioningService) SaveFolderForProvisionedDashboards(dto *dashboards.SaveDashboardDTO) (*models.Dashboard, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextFolderId--
+	s.folders[s.nextFolderId] = dto.Dashboard.Title
+	dto.Dashboard.Id = s.nextFolderId
+	return dto.Dashboard, nil
+}
+
+func (s *dryRunProvisioningService) UnprovisionDashboard(dashboardId int64) error {
+	return nil
+}
+
+func (s *dryRunProvisioningService) DeleteProvisionedDashboard(dashboardId int64, orgId int64) error {
+	return nil
+}
+
+// plannedFolder returns the title of a folder the dry run would have created.
+func (s *dryRunProvisioningService) plannedFolder(folderId int64) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	title, ok := s.folders[folderId]
+	return title, ok
+}
+
+// isDryRun returns true if the scans of the provider only log what they would do.
+func (fr *fileReader) isDryRun() bool {
+	_, ok := fr.dashboardProvisioningService.(*dryRunProvisioningService)
+	return ok
+}
+
+// planDryRun logs an action the scan would have taken.
+func (fr *fileReader) planDryRun(action dryRunAction) {
+	fr.log.Info("dry run", "provider", fr.Cfg.Name, "action", action.Action, "reason", action.Reason,
+		"file", action.Source, "uid", action.Uid, "title", action.Title, "dashboardId", action.DashboardId,
+		"folder", action.Folder)
+}
+
+// planDryRunSave logs the action the scan would have taken for a dashboard file. provisionedData is nil for
+// dashboards not provisioned yet.
+func (fr *fileReader) planDryRunSave(jsonFile *dashboardJsonFile, provisionedData *models.DashboardProvisioning, moved bool) {
+	dash := jsonFile.dashboard.Dashboard
+	action := dryRunAction{
+		Action: dryRunActionInsert,
+		Reason: dryRunReasonNew,
+		Source: jsonFile.externalId,
+		Uid:    dash.Uid,
+		Title:  dash.Title,
+		Folder: fr.dryRunFolderTitle(dash.FolderId),
+	}
+	if provisionedData != nil {
+		action.DashboardId = provisionedData.DashboardId
+		switch {
+		case jsonFile.upToDate:
+			action.Action = dryRunActionSkip
+			action.Reason = dryRunReasonUnchanged
+		case moved:
+			action.Action = dryRunActionUpdate
+			action.Reason = dryRunReasonMoved
+		default:
+			action.Action = dryRunActionUpdate
+			action.Reason = dryRunReasonChanged
+		}
+	}
+	fr.planDryRun(action)
+}
+
+// planDryRunRemove logs the removal of dashboards missing on disk the scan would have made.
+func (fr *fileReader) planDryRunRemove(provisioned []*models.DashboardProvisioning) {
+	action := dryRunActionDelete
+	if fr.Cfg.DisableDeletion {
+		action = dryRunActionUnprovision
+	}
+	for _, provisioningData := range provisioned {
+		fr.planDryRun(dryRunAction{
+			Action:      action,
+			Reason:      dryRunReasonMissing,
+			Source:      provisioningData.ExternalId,
+			Uid:         fr.dashboardUid(provisioningData.DashboardId),
+			DashboardId: provisioningData.DashboardId,
+		})
+	}
+}
+
+// dryRunFolderTitle returns the title of the folder with the id, including folders the dry run would have created.
+// Falls back to the id if the folder can't be loaded.
+func (fr *fileReader) dryRunFolderTitle(folderId int64) string {
+	if folderId == 0 {
+		return generalFolderTitle
+	}
+	if service, ok := fr.dashboardProvisioningService.(*dryRunProvisioningService); ok {
+		if title, ok := service.plannedFolder(folderId); ok {
+			return title
+		}
+	}
+
+	query := &models.GetDashboardQuery{Id: folderId, OrgId: fr.Cfg.OrgId}
+	if err := bus.Dispatch(query); err != nil {
+		return strconv.FormatInt(folderId, 10)
+	}
+	return query.Result.Title
+}
@@ -0,0 +1,110 @@
+package dashboards
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// dryRunRecorder records the actions logged by a dry run as "action reason file folder". Removals have no folder.
+type dryRunRecorder struct {
+	log.Logger
+	actions []string
+}
+
+func (l *dryRunRecorder) Info(msg string, ctx ...interface{}) {
+	if msg != "dry run" {
+		return
+	}
+	fields := map[interface{}]interface{}{}
+	for i := 0; i+1 < len(ctx); i += 2 {
+		fields[ctx[i]] = ctx[i+1]
+	}
+	l.actions = append(l.actions, strings.TrimSpace(fmt.Sprintf("%v %v %v %v", fields["action"], fields["reason"], filepath.Base(fmt.Sprint(fields["file"])), fields["folder"])))
+}
+
+func TestDryRun(t *testing.T) {
+	Convey("Provisioning dashboards with dryRun", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		dir, err := ioutil.TempDir("", "provisioning-dry-run")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		writeDashboard := func(name string, title string, modTime time.Time) {
+			path := filepath.Join(dir, name)
+			So(ioutil.WriteFile(path, []byte(fmt.Sprintf(`{"title": %q}`, title)), 0644), ShouldBeNil)
+			So(os.Chtimes(path, modTime, modTime), ShouldBeNil)
+		}
+
+		provisioned := time.Now().Add(-time.Hour)
+		writeDashboard("cpu.json", "CPU", provisioned)
+		writeDashboard("disk.json", "Disk", provisioned)
+		writeDashboard("net.json", "Network", provisioned)
+
+		cfg := &DashboardsAsConfig{
+			Name:    "Default",
+			Type:    "file",
+			OrgId:   1,
+			Options: map[string]interface{}{"path": dir},
+		}
+		reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+		So(err, ShouldBeNil)
+		So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+		So(len(fakeService.inserted), ShouldEqual, 3)
+
+		writeDashboard("cpu.json", "CPU usage", time.Now())
+		writeDashboard("mem.json", "Memory", time.Now())
+		So(os.Remove(filepath.Join(dir, "disk.json")), ShouldBeNil)
+
+		dryRunCfg := *cfg
+		dryRunCfg.Folder = "Planned"
+		dryRunCfg.Options = map[string]interface{}{"path": dir, "dryRun": true}
+		recorder := &dryRunRecorder{Logger: log.New("test-logger")}
+		dryRun, err := NewDashboardFileReader(&dryRunCfg, recorder, ReaderOptions{})
+		So(err, ShouldBeNil)
+		So(dryRun.startWalkingDisk(context.Background()), ShouldBeNil)
+
+		Convey("should log the actions with their reason and folder", func() {
+			So(recorder.actions, ShouldResemble, []string{
+				"delete missing disk.json",
+				"update changed cpu.json Planned",
+				"insert new mem.json Planned",
+				"skip unchanged net.json Planned",
+			})
+		})
+
+		Convey("should not save or remove anything", func() {
+			So(len(fakeService.inserted), ShouldEqual, 3)
+			So(len(fakeService.provisioned["Default"]), ShouldEqual, 3)
+			for _, dto := range fakeService.inserted {
+				So(dto.Dashboard.Title, ShouldNotEqual, "CPU usage")
+				So(dto.Dashboard.FolderId, ShouldEqual, 0)
+			}
+		})
+
+		Convey("should log removals as unprovisioning with disableDeletion", func() {
+			dryRunCfg.DisableDeletion = true
+			recorder.actions = nil
+			So(dryRun.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(recorder.actions[0], ShouldStartWith, "unprovision missing disk.json")
+		})
+	})
+}
@@ -0,0 +1,41 @@
+package dashboards
+
+import (
+	"fmt"
+)
+
+// Values of the onDuplicateUid option choosing what happens to a dashboard with the uid of a dashboard of another
+// file of the provider. Either way only the dashboard of the file first in path order is saved.
+const (
+	// duplicateUidError reports the dashboard like a file failing to save.
+	duplicateUidError = "error"
+	// duplicateUidFirstWins only logs a warning.
+	duplicateUidFirstWins = "firstWins"
+)
+
+// uidConflicts remembers the file each dashboard uid of a scan was first read from, so a dashboard of another file
+// with the same uid is skipped instead of overwriting it depending on the order the files are saved in.
+type uidConflicts struct {
+	paths map[string]string
+}
+
+func newUidConflicts() *uidConflicts {
+	return &uidConflicts{paths: map[string]string{}}
+}
+
+// check returns an error naming both files if the uid was read from another file than path before.
+func (c *uidConflicts) check(uid string, path string) error {
+	if uid == "" {
+		return nil
+	}
+
+	first, ok := c.paths[uid]
+	if !ok {
+		c.paths[uid] = path
+		return nil
+	}
+	if first != path {
+		return fmt.Errorf("dashboard uid %s of %s is already provisioned from %s", uid, path, first)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package dashboards
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	utf8Bom    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LeBom = []byte{0xFF, 0xFE}
+	utf16BeBom = []byte{0xFE, 0xFF}
+)
+
+// decodeDashboardFile strips a byte order mark and transcodes UTF-16 content to UTF-8 so files saved by editors
+// defaulting to those encodings can be parsed. Files without a byte order mark are UTF-8. Returns the content and the
+// name of the encoding it was converted from, or an empty name if the content was left as it is.
+func decodeDashboardFile(content []byte) ([]byte, string) {
+	switch {
+	case bytes.HasPrefix(content, utf8Bom):
+		return content[len(utf8Bom):], "UTF-8 with BOM"
+	case bytes.HasPrefix(content, utf16LeBom):
+		return decodeUtf16(content[len(utf16LeBom):], binary.LittleEndian), "UTF-16LE"
+	case bytes.HasPrefix(content, utf16BeBom):
+		return decodeUtf16(content[len(utf16BeBom):], binary.BigEndian), "UTF-16BE"
+	default:
+		return content, ""
+	}
+}
+
+// decodeUtf16 converts UTF-16 content to UTF-8. A trailing odd byte becomes the replacement character, so the file
+// fails to parse instead of losing it.
+func decodeUtf16(content []byte, order binary.ByteOrder) []byte {
+	units := make([]uint16, len(content)/2)
+	for i := range units {
+		units[i] = order.Uint16(content[i*2:])
+	}
+	decoded := string(utf16.Decode(units))
+	if len(content)%2 != 0 {
+		decoded += string(utf8.RuneError)
+	}
+	return []byte(decoded)
+}
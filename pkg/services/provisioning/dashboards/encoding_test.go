@@ -0,0 +1,36 @@
+package dashboards
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDecodeDashboardFile(t *testing.T) {
+	Convey("Decoding dashboard files", t, func() {
+		Convey("should read files without byte order mark as UTF-8", func() {
+			content := []byte(`{"title": "Überblick"}`)
+			decoded, encoding := decodeDashboardFile(content)
+			So(encoding, ShouldBeEmpty)
+			So(decoded, ShouldResemble, content)
+		})
+
+		Convey("should not guess UTF-16 without byte order mark", func() {
+			content := []byte("{\x00\"\x00")
+			decoded, encoding := decodeDashboardFile(content)
+			So(encoding, ShouldBeEmpty)
+			So(decoded, ShouldResemble, content)
+		})
+
+		Convey("should convert UTF-16 with byte order mark", func() {
+			decoded, encoding := decodeDashboardFile([]byte{0xFF, 0xFE, '{', 0, '}', 0})
+			So(encoding, ShouldEqual, "UTF-16LE")
+			So(string(decoded), ShouldEqual, "{}")
+		})
+
+		Convey("should keep a trailing odd byte of UTF-16 as replacement character", func() {
+			decoded, _ := decodeDashboardFile([]byte{0xFE, 0xFF, 0, '{', 0, '}', 0})
+			So(string(decoded), ShouldEqual, "{}�")
+		})
+	})
+}
@@ -0,0 +1,75 @@
+package dashboards
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// expandEnvVariables replaces $NAME and ${NAME} in the content of a dashboard file with the environment variables
+// for the expandEnv option. Variables that are not set are kept as they are, so Grafana template variables like
+// $datasource survive, unless strict is set, then they fail the file. $$ is replaced by a single $ and can be used to
+// keep template variables with strict.
+func expandEnvVariables(content []byte, strict bool) ([]byte, error) {
+	var result bytes.Buffer
+	for i := 0; i < len(content); i++ {
+		if content[i] != '$' || i+1 == len(content) {
+			result.WriteByte(content[i])
+			continue
+		}
+
+		next := content[i+1]
+		var name string
+		var end int
+		switch {
+		case next == '$':
+			result.WriteByte('$')
+			i++
+			continue
+		case next == '{':
+			closing := bytes.IndexByte(content[i+2:], '}')
+			if closing < 0 || !isEnvVariableName(content[i+2:i+2+closing]) {
+				result.WriteByte('$')
+				continue
+			}
+			name = string(content[i+2 : i+2+closing])
+			end = i + 2 + closing + 1
+		case isEnvVariableStart(next):
+			end = i + 2
+			for end < len(content) && (isEnvVariableStart(content[end]) || (content[end] >= '0' && content[end] <= '9')) {
+				end++
+			}
+			name = string(content[i+1 : end])
+		default:
+			result.WriteByte('$')
+			continue
+		}
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			if strict {
+				return nil, fmt.Errorf("environment variable %s is not set", name)
+			}
+			value = string(content[i:end])
+		}
+		result.WriteString(value)
+		i = end - 1
+	}
+	return result.Bytes(), nil
+}
+
+func isEnvVariableStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isEnvVariableName(name []byte) bool {
+	if len(name) == 0 || !isEnvVariableStart(name[0]) {
+		return false
+	}
+	for _, c := range name[1:] {
+		if !isEnvVariableStart(c) && !(c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package dashboards
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var envDashboards = "testdata/test-dashboards/env"
+
+func TestExpandEnvVariables(t *testing.T) {
+	Convey("Expanding environment variables", t, func() {
+		os.Setenv("ENV_TEST_ENVIRONMENT", "staging")
+		defer os.Unsetenv("ENV_TEST_ENVIRONMENT")
+
+		Convey("should replace both forms", func() {
+			expanded, err := expandEnvVariables([]byte(`"$ENV_TEST_ENVIRONMENT-${ENV_TEST_ENVIRONMENT}x"`), false)
+			So(err, ShouldBeNil)
+			So(string(expanded), ShouldEqual, `"staging-stagingx"`)
+		})
+
+		Convey("should keep unset variables and other dollar signs", func() {
+			content := `{"datasource": "$datasource", "expr": "rate(x[$__interval]) ${var:csv} ^a.*$ $1"}`
+			expanded, err := expandEnvVariables([]byte(content), false)
+			So(err, ShouldBeNil)
+			So(string(expanded), ShouldEqual, content)
+		})
+
+		Convey("should fail on unset variables when strict", func() {
+			_, err := expandEnvVariables([]byte(`"$datasource"`), true)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("should replace escaped dollar signs", func() {
+			expanded, err := expandEnvVariables([]byte(`"$$datasource ${ENV_TEST_ENVIRONMENT}"`), true)
+			So(err, ShouldBeNil)
+			So(string(expanded), ShouldEqual, `"$datasource staging"`)
+		})
+
+		Convey("when provisioning", func() {
+			bus.ClearBusHandlers()
+			origNewDashboardProvisioningService := dashboards.NewProvisioningService
+			fakeService = mockDashboardProvisioningService()
+			bus.AddHandler("test", mockGetDashboardQuery)
+			defer func() {
+				dashboards.NewProvisioningService = origNewDashboardProvisioningService
+			}()
+
+			cfg := &DashboardsAsConfig{
+				Name:    "Default",
+				Type:    "file",
+				OrgId:   1,
+				Options: map[string]interface{}{"path": envDashboards},
+			}
+			provision := func() map[string]interface{} {
+				reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldBeNil)
+				So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+				So(len(fakeService.inserted), ShouldEqual, 1)
+				return fakeService.inserted[0].Dashboard.Data.MustMap()
+			}
+
+			Convey("should leave dashboards untouched without the option", func() {
+				data := provision()
+				So(data["title"], ShouldEqual, "${ENV_TEST_ENVIRONMENT} services")
+			})
+
+			Convey("should expand variables and keep template variables and regexes", func() {
+				cfg.Options["expandEnv"] = true
+				provision()
+
+				dash := fakeService.inserted[0].Dashboard
+				So(dash.Title, ShouldEqual, "staging services")
+				panel := dash.Data.Get("panels").GetIndex(0)
+				So(panel.Get("datasource").MustString(), ShouldEqual, "$datasource")
+				So(panel.Get("targets").GetIndex(0).Get("expr").MustString(), ShouldEqual, `up{job=~"$job", instance=~"^api-.*$"}`)
+			})
+
+			Convey("should skip dashboards with unset variables when strict", func() {
+				cfg.Options["expandEnv"] = true
+				cfg.Options["strictEnv"] = true
+
+				reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldBeNil)
+				So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+				So(len(fakeService.inserted), ShouldEqual, 0)
+			})
+		})
+	})
+}
@@ -0,0 +1,75 @@
+package dashboards
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDashboardProvisionedEvents(t *testing.T) {
+	Convey("Publishing provisioning actions on the bus", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		var published []*events.DashboardProvisioned
+		bus.AddEventListener(func(event *events.DashboardProvisioned) error {
+			published = append(published, event)
+			return nil
+		})
+
+		fakeService.provisioned["Default"] = []*models.DashboardProvisioning{
+			{Name: "Default", ExternalId: "/removed/dashboard.json", DashboardId: 42},
+		}
+		cfg := &DashboardsAsConfig{
+			Name:    "Default",
+			Type:    "file",
+			OrgId:   1,
+			Options: map[string]interface{}{"path": defaultDashboards},
+		}
+
+		Convey("should publish an event per saved and removed dashboard", func() {
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			actions := map[string]int{}
+			for _, event := range published {
+				So(event.OrgId, ShouldEqual, 1)
+				So(event.Provider, ShouldEqual, "Default")
+				actions[event.Action]++
+			}
+			So(actions, ShouldResemble, map[string]int{journalActionCreated: 2, journalActionDeleted: 1})
+
+			for _, event := range published {
+				if event.Action == journalActionDeleted {
+					So(event.DashboardId, ShouldEqual, 42)
+					So(event.Path, ShouldEqual, "/removed/dashboard.json")
+				} else {
+					So(event.DashboardId, ShouldNotEqual, 0)
+					So(event.Path, ShouldStartWith, reader.resolvedPath())
+				}
+			}
+		})
+
+		Convey("should not publish events for unchanged dashboards", func() {
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+			published = nil
+
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+			So(published, ShouldBeEmpty)
+		})
+	})
+}
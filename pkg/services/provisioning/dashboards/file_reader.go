@@ -4,20 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/util"
 
 	"github.com/grafana/grafana/pkg/bus"
-
 	"github.com/grafana/grafana/pkg/components/simplejson"
+
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/rendering"
 )
 
 var (
@@ -28,56 +31,307 @@ type fileReader struct {
 	Cfg                          *DashboardsAsConfig
 	Path                         string
 	log                          log.Logger
+	formats                      []*dashboardFileFormat
 	dashboardProvisioningService dashboards.DashboardProvisioningService
+	// resolveRevision returns the commit the dashboards are provisioned from and revision is its value for the
+	// current scan. Both are only used with the tagWithCommit option and by git providers.
+	resolveRevision func() (string, error)
+	revision        string
+	// allowedRoot is the resolved allowedRoot option. When set no dashboard is read from outside of it.
+	allowedRoot string
+	// insertedDashboardIds are the dashboards inserted by the last scan. They are deleted again when the transaction
+	// group of the provider fails.
+	insertedDashboardIds []int64
+	// updatedDashboards are the dashboards updated by the last atomic scan as they were before the scan. They are
+	// saved again when the transaction group of the provider fails. keepPreviousVersions is set during atomic scans.
+	updatedDashboards    []*previousDashboard
+	keepPreviousVersions bool
+	// logLimiter wraps log when the logRateLimit option is set.
+	logLimiter *rateLimitedLogger
+	// renders checks the saved dashboards for the renderCheck option.
+	renders *renderChecker
+	// journal records the provisioning actions when the journalPath option is set.
+	journal *dashboardJournal
+	// includePatterns and excludePatterns limit the files provisioned when the options of the same name are set.
+	includePatterns []*globPattern
+	excludePatterns []*globPattern
+	// movableDashboards are the dashboards of the current scan missing on disk that have a uid, by uid. A dashboard
+	// file with the same uid takes them over instead of them being removed, so moving a file keeps its dashboard.
+	movableDashboards map[string]*models.DashboardProvisioning
+	// datasourceHealth holds the health check results of the current scan for the requireHealthyDatasources option.
+	datasourceHealth map[string]error
+	// concurrency is the number of workers reading dashboard files during a scan.
+	concurrency int
+	// jpath are the library directories of jsonnet dashboards.
+	jpath []string
+	// paths are all directories of the provider, starting with Path. Their dashboards are provisioned as one provider.
+	paths []string
+	// uidOwners tracks the uids provisioned by all providers of the provisioner to report conflicts between them.
+	uidOwners *uidOwners
+	// folders caches the folders looked up by the current scan.
+	folders *folderCache
+	// orgReaders are the readers of the orgs by id when the provider provisions into several orgs.
+	orgReaders map[int64]*fileReader
+	// source fetches the dashboards into Path for provider types other than file.
+	source dashboardSource
+	// fs is the file system dashboard files are read from.
+	fs fileSystem
+	// scans runs the scans of the provider one at a time, whether they are polled or reloaded.
+	scans *scanCoalescer
+	// stopped is closed by Stop, stopOnce makes sure it is closed once.
+	stopped  chan struct{}
+	stopOnce *sync.Once
+	// status collects the results of scans for Status.
+	status *statusRecorder
+}
+
+// ReaderOptions are the dependencies of dashboard readers provided by the Grafana server rather than the provider
+// config.
+type ReaderOptions struct {
+	// RenderService renders dashboards for the renderCheck option, the check is skipped while it is nil.
+	RenderService rendering.Service
+	// WorkDir is the directory providers that don't read dashboards from a directory on disk, like archives, keep
+	// their local copy of the dashboards in. A directory in the temp dir is used if it is empty.
+	WorkDir string
+}
+
+func NewDashboardFileReader(cfg *DashboardsAsConfig, log log.Logger, opts ReaderOptions) (*fileReader, error) {
+	return newDashboardFileReader(cfg, log, opts, osFileSystem{})
 }
 
-func NewDashboardFileReader(cfg *DashboardsAsConfig, log log.Logger) (*fileReader, error) {
+// newDashboardFileReader creates a reader reading dashboard files from fsys.
+func newDashboardFileReader(cfg *DashboardsAsConfig, log log.Logger, opts ReaderOptions, fsys fileSystem) (*fileReader, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("Failed to load dashboards. %v", err)
+	}
+
+	if len(getProviderPaths(cfg.Options)) == 0 && sourceRequiresPath(cfg.Type) {
+		log.Warn("[Deprecated] The folder property is deprecated. Please use path instead.")
+	}
+	paths, err := providerPaths(cfg, log)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load dashboards. %v", err)
+	}
+
 	var path string
-	path, ok := cfg.Options["path"].(string)
-	if !ok {
-		path, ok = cfg.Options["folder"].(string)
-		if !ok {
-			return nil, fmt.Errorf("Failed to load dashboards. path param is not a string")
+	if len(paths) > 0 {
+		path = paths[0]
+	}
+
+	if cfg.UpdateIntervalSeconds == 0 {
+		cfg.UpdateIntervalSeconds = DefaultUpdateIntervalSeconds
+	}
+	minInterval := getInt64Option(cfg.Options, "minUpdateIntervalSeconds")
+	if minInterval <= 0 {
+		minInterval = defaultMinUpdateIntervalSeconds
+	}
+	if cfg.UpdateIntervalSeconds < minInterval {
+		log.Warn("updateIntervalSeconds is too short, using the minimum instead", "updateIntervalSeconds", cfg.UpdateIntervalSeconds, "minUpdateIntervalSeconds", minInterval)
+		cfg.UpdateIntervalSeconds = minInterval
+	}
+
+	var allowedRoot string
+	if root := getStringOption(cfg.Options, "allowedRoot"); root != "" {
+		var err error
+		if allowedRoot, err = resolveAllowedRoot(root); err != nil {
+			return nil, fmt.Errorf("Failed to load dashboards. Could not resolve allowedRoot. %v", err)
 		}
+	}
 
-		log.Warn("[Deprecated] The folder property is deprecated. Please use path instead.")
+	formatNames := getStringSliceOption(cfg.Options, "formats")
+	if len(formatNames) == 0 {
+		formatNames = defaultDashboardFileFormats
+	}
+	formats, err := getDashboardFileFormats(formatNames)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load dashboards. %v", err)
+	}
+
+	includePatterns, err := compileGlobPatterns("includePatterns", getStringSliceOption(cfg.Options, "includePatterns"))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load dashboards. %v", err)
+	}
+
+	excludePatterns, err := compileGlobPatterns("excludePatterns", getStringSliceOption(cfg.Options, "excludePatterns"))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load dashboards. %v", err)
+	}
+
+	concurrency := int(getInt64Option(cfg.Options, "concurrency"))
+	if concurrency == 0 {
+		concurrency = defaultConcurrency()
+	}
+
+	retries := getInt64Option(cfg.Options, "saveRetries")
+
+	workDir := opts.WorkDir
+	if workDir == "" {
+		workDir = defaultWorkDir()
+	}
+	source, err := newDashboardSource(cfg, path, formats, workDir, log)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load dashboards. %v", err)
+	}
+	if source != nil {
+		path = source.dir()
+		paths = []string{path}
 	}
 
-	return &fileReader{
+	if source == nil {
+		for _, providerPath := range paths {
+			if err := checkProviderPath(fsys, providerPath); err != nil {
+				if !getBoolOption(cfg.Options, "allowMissingPath") {
+					return nil, fmt.Errorf("Failed to load dashboards. %v", err)
+				}
+				log.Warn("provider path is missing", "path", providerPath, "error", err)
+			}
+		}
+	}
+
+	fr := &fileReader{
 		Cfg:                          cfg,
 		Path:                         path,
+		paths:                        paths,
 		log:                          log,
+		formats:                      formats,
+		allowedRoot:                  allowedRoot,
 		dashboardProvisioningService: dashboards.NewProvisioningService(),
-	}, nil
+		includePatterns:              includePatterns,
+		excludePatterns:              excludePatterns,
+		renders:                      newRenderChecker(opts.RenderService, log),
+		concurrency:                  concurrency,
+		jpath:                        resolveJpath(cfg.Jpath, path),
+		source:                       source,
+		fs:                           fsys,
+		scans:                        &scanCoalescer{},
+		stopped:                      make(chan struct{}),
+		stopOnce:                     &sync.Once{},
+		status:                       newStatusRecorder(cfg.Name),
+	}
+	if retries > 0 {
+		backoff := time.Duration(getInt64Option(cfg.Options, "saveRetryBackoffMillis")) * time.Millisecond
+		fr.dashboardProvisioningService = newRetryingProvisioningService(fr.dashboardProvisioningService, int(retries), backoff, log)
+	}
+	if getBoolOption(cfg.Options, "dryRun") {
+		fr.dashboardProvisioningService = newDryRunProvisioningService(fr.dashboardProvisioningService)
+	}
+	fr.resolveRevision = func() (string, error) {
+		return readGitRevision(fr.resolvedPath())
+	}
+
+	if journalPath := getStringOption(cfg.Options, "journalPath"); journalPath != "" {
+		fr.journal = newDashboardJournal(journalPath, getInt64Option(cfg.Options, "journalMaxSizeBytes"))
+	}
+
+	if limit := getInt64Option(cfg.Options, "logRateLimit"); limit > 0 {
+		window := time.Duration(getInt64Option(cfg.Options, "logRateLimitWindowSeconds")) * time.Second
+		fr.logLimiter = newRateLimitedLogger(log, int(limit), window)
+		fr.log = fr.logLimiter
+	}
+
+	return fr, nil
 }
 
-// pollChanges periodically runs startWalkingDisk based on interval specified in the config.
+// pollChanges periodically runs startWalkingDisk based on interval specified in the config. With the watch option it
+// scans on changes on disk instead and only falls back to polling if watching fails.
 func (fr *fileReader) pollChanges(ctx context.Context) {
-	ticker := time.Tick(time.Duration(int64(time.Second) * fr.Cfg.UpdateIntervalSeconds))
+	ctx, cancel := fr.untilStopped(ctx)
+	defer cancel()
+
+	breaker := newScanBreaker(fr.Cfg)
+	scan := func() { fr.scanWithBreaker(ctx, breaker) }
+
+	if getBoolOption(fr.Cfg.Options, "watch") {
+		err := fr.watchChanges(ctx, scan)
+		if err == nil {
+			return
+		}
+		fr.log.Warn("failed to watch for changes, falling back to polling", "error", err)
+	}
+
+	ticker := time.NewTicker(time.Duration(int64(time.Second) * fr.Cfg.UpdateIntervalSeconds))
+	defer ticker.Stop()
 	for {
 		select {
-		case <-ticker:
-			if err := fr.startWalkingDisk(); err != nil {
-				fr.log.Error("failed to search for dashboards", "error", err)
-			}
+		case <-ticker.C:
+			scan()
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
+// scanWithBreaker runs startWalkingDisk unless the breaker is open.
+func (fr *fileReader) scanWithBreaker(ctx context.Context, breaker *scanBreaker) {
+	wasTripped := breaker.tripped()
+	scanned, err := breaker.run(func() error {
+		return fr.Reload(ctx)
+	})
+	if !scanned {
+		fr.log.Debug("skipping scan while provider is cooling down", "until", breaker.openUntil)
+		return
+	}
+
+	// stopping and cancelling are not failures of the provider
+	if err != nil && (err == errProviderStopped || err == ctx.Err()) {
+		return
+	}
+	if err != nil {
+		fr.log.Error("failed to search for dashboards", "error", err)
+		if breaker.tripped() {
+			fr.log.Warn("provider keeps failing, pausing scans", "failures", breaker.failures, "until", breaker.openUntil)
+		}
+	} else if wasTripped {
+		fr.log.Info("provider recovered, resuming scans")
+	}
+}
+
 // startWalkingDisk traverses the file system for defined path, reads dashboard definition files and applies any change
-// to the database.
-func (fr *fileReader) startWalkingDisk() error {
+// to the database. Cancelling ctx stops the scan before the next file with the error of ctx.
+func (fr *fileReader) startWalkingDisk(ctx context.Context) error {
+	return fr.startWalkingDiskWithOptions(ctx, ScanOptions{})
+}
+
+// startWalkingDiskWithOptions does the same as startWalkingDisk with the behaviour of this single scan changed by
+// opts.
+func (fr *fileReader) startWalkingDiskWithOptions(ctx context.Context, opts ScanOptions) (err error) {
 	fr.log.Debug("Start walking disk", "path", fr.Path)
-	resolvedPath := fr.resolvedPath()
-	if _, err := os.Stat(resolvedPath); err != nil {
-		if os.IsNotExist(err) {
-			return err
+	defer fr.flushJournal()
+	start := time.Now()
+	fr.status.begin()
+	defer func() {
+		fr.recordScan(start, err)
+		fr.status.finish(start, err)
+	}()
+
+	fr.syncSource()
+	if provisionsIntoSeveralOrgs(fr.Cfg) {
+		return fr.scanOrgs(ctx, opts)
+	}
+	return fr.scan(ctx, opts)
+}
+
+// scan applies the dashboard files of the provider to the org of its config.
+func (fr *fileReader) scan(ctx context.Context, opts ScanOptions) error {
+	resolvedPaths := fr.resolvedPaths()
+	for _, resolvedPath := range resolvedPaths {
+		if _, err := fr.fs.Stat(resolvedPath); err != nil {
+			if os.IsNotExist(err) {
+				return err
+			}
+		}
+
+		// the path of a source was checked when the provider was created, its local copy is outside of the allowed
+		// root
+		if fr.allowedRoot != "" && fr.source == nil {
+			if err := checkPathWithinRoot(resolvedPath, fr.allowedRoot); err != nil {
+				return err
+			}
 		}
 	}
 
-	folderId, err := getOrCreateFolderId(fr.Cfg, fr.dashboardProvisioningService)
+	fr.folders = newFolderCache(fr.dashboardProvisioningService)
+	folderId, err := fr.folders.getOrCreateFolder(fr.Cfg, fr.Cfg.Folder, fr.Cfg.FolderUid, nil)
 	if err != nil && err != ErrFolderNameMissing {
 		return err
 	}
@@ -87,111 +341,451 @@ func (fr *fileReader) startWalkingDisk() error {
 		return err
 	}
 
-	filesFoundOnDisk := map[string]os.FileInfo{}
-	err = filepath.Walk(resolvedPath, createWalkFn(filesFoundOnDisk))
+	fr.revision = ""
+	if getBoolOption(fr.Cfg.Options, "tagWithCommit") || fr.Cfg.Type == gitSourceType {
+		revision, err := fr.resolveRevision()
+		if err != nil {
+			fr.log.Warn("failed to resolve commit, dashboards will not be tagged with it", "error", err)
+		}
+		fr.revision = revision
+	}
+
+	if fr.uidOwners != nil {
+		fr.uidOwners.release(fr.Cfg.Name)
+	}
+	fr.insertedDashboardIds = nil
+	fr.updatedDashboards = nil
+	fr.keepPreviousVersions = opts.atomic
+	fr.movableDashboards = map[string]*models.DashboardProvisioning{}
+	fr.datasourceHealth = map[string]error{}
+	filesFoundOnDisk, err := fr.findDashboardFiles(ctx)
 	if err != nil {
 		return err
 	}
 
-	fr.handleMissingDashboardFiles(provisionedDashboardRefs, filesFoundOnDisk)
+	if softMax := getInt64Option(fr.Cfg.Options, "softMaxDashboards"); softMax > 0 && int64(len(filesFoundOnDisk)) > softMax {
+		fr.log.Warn("provider exceeds its soft limit of dashboards", "dashboards", len(filesFoundOnDisk), "softMaxDashboards", softMax)
+		metrics.M_Provisioning_Soft_Max_Exceeded.WithLabelValues(fr.Cfg.Name).Inc()
+	}
+
+	if opts.SkipDelete {
+		fr.log.Debug("skipping removal of dashboards missing on disk for this scan")
+	} else {
+		fr.handleMissingDashboardFiles(provisionedDashboardRefs, filesFoundOnDisk)
+	}
 
 	sanityChecker := newProvisioningSanityChecker(fr.Cfg.Name)
 
-	// save dashboards based on json files
-	for path, fileInfo := range filesFoundOnDisk {
-		provisioningMetadata, err := fr.saveDashboard(path, folderId, fileInfo, provisionedDashboardRefs)
-		sanityChecker.track(provisioningMetadata)
+	// save dashboards based on json files, the files are read in parallel but saved one by one in order
+	processed := 0
+	// atomicErr is the error stopping the scan, either of an atomic scan or because the provider was stopped or ctx
+	// was cancelled
+	var atomicErr error
+	// readExternalIds are the external ids of the dashboards in every file that could be read
+	readExternalIds := map[string][]string{}
+	// the same uid in two files of the provider would make their dashboards overwrite each other
+	conflicts := newUidConflicts()
+	folders := newFilesStructureFolders(fr, resolvedPaths, folderId)
+	load := func(path string) *loadedDashboard {
+		folder := dashboardFolder{id: folderId, title: fr.Cfg.Folder}
+		return fr.loadDashboard(path, filesFoundOnDisk[path], folders, folder, provisionedDashboardRefs)
+	}
+	save := func(loaded *loadedDashboard) bool {
+		if fr.isStopped() {
+			atomicErr = errProviderStopped
+			return false
+		}
+		if err := ctx.Err(); err != nil {
+			atomicErr = err
+			return false
+		}
+
+		err := loaded.err
+		if loaded.jsonFiles != nil {
+			readExternalIds[loaded.path] = []string{}
+		}
+		for _, jsonFile := range loaded.jsonFiles {
+			readExternalIds[loaded.path] = append(readExternalIds[loaded.path], jsonFile.externalId)
+			if conflictErr := conflicts.check(jsonFile.dashboard.Dashboard.Uid, loaded.path); conflictErr != nil {
+				if getStringOption(fr.Cfg.Options, "onDuplicateUid") == duplicateUidFirstWins {
+					fr.log.Warn("skipping dashboard with duplicate uid", "error", conflictErr)
+					continue
+				}
+				err = conflictErr
+				if opts.atomic {
+					break
+				}
+				continue
+			}
+			metadata, saveErr := fr.saveDashboard(jsonFile, provisionedDashboardRefs)
+			sanityChecker.track(metadata)
+			if saveErr != nil {
+				err = saveErr
+				if opts.atomic {
+					break
+				}
+				continue
+			}
+			fr.claimUid(metadata.uid, loaded.path)
+		}
+
 		if err != nil {
+			fr.countDashboard(metricActionFailed)
+			fr.status.fail(loaded.path, err)
 			fr.log.Error("failed to save dashboard", "error", err)
+			if opts.atomic {
+				atomicErr = err
+				return false
+			}
 		}
+
+		processed++
+		fr.reportProgress(opts.Progress, ProgressEvent{
+			Provider:  fr.Cfg.Name,
+			Path:      loaded.path,
+			Processed: processed,
+			Total:     len(filesFoundOnDisk),
+			Error:     err,
+		})
+		return true
+	}
+	failedBefore := fr.status.failures()
+	processInOrder(sortedDashboardPaths(resolvedPaths, filesFoundOnDisk), fr.concurrency, load, save)
+	if atomicErr != nil {
+		return atomicErr
+	}
+	if failed := fr.status.failures() - failedBefore; failed > 0 {
+		fr.log.Warn(fmt.Sprintf("%d of %d dashboards failed", failed, len(filesFoundOnDisk)))
 	}
 	sanityChecker.logWarnings(fr.log)
 
+	if !opts.SkipDelete {
+		fr.removeUnmovedDashboards()
+		fr.handleDashboardsRemovedFromFiles(provisionedDashboardRefs, filesFoundOnDisk, readExternalIds)
+	}
+
+	if getBoolOption(fr.Cfg.Options, "installSelfMonitoringDashboard") {
+		if err := fr.installSelfMonitoringDashboard(folderId, provisionedDashboardRefs); err != nil {
+			fr.log.Error("failed to save self monitoring dashboard", "error", err)
+		}
+	}
+
+	if dir := getStringOption(fr.Cfg.Options, "backupDir"); dir != "" {
+		if err := fr.writeBackup(dir, time.Now()); err != nil {
+			fr.log.Error("failed to write dashboard backup", "dir", dir, "error", err)
+		}
+	}
+
+	if fr.logLimiter != nil {
+		fr.logLimiter.flush()
+	}
+
 	return nil
 }
 
 // handleMissingDashboardFiles will unprovision or delete dashboards which are missing on disk.
 func (fr *fileReader) handleMissingDashboardFiles(provisionedDashboardRefs map[string]*models.DashboardProvisioning, filesFoundOnDisk map[string]os.FileInfo) {
 	// find dashboards to delete since json file is missing
-	var dashboardToDelete []int64
-	for path, provisioningData := range provisionedDashboardRefs {
+	var missing []*models.DashboardProvisioning
+	installsSelfMonitoring := getBoolOption(fr.Cfg.Options, "installSelfMonitoringDashboard")
+	for externalId, provisioningData := range provisionedDashboardRefs {
+		if externalId == selfMonitoringExternalId && installsSelfMonitoring {
+			continue
+		}
+
+		path := pathOfExternalId(externalId, filesFoundOnDisk)
 		_, existsOnDisk := filesFoundOnDisk[path]
+		if !existsOnDisk && fr.isNotIncluded(path) {
+			// still on disk, only not provisioned anymore
+			continue
+		}
+
 		if !existsOnDisk {
-			dashboardToDelete = append(dashboardToDelete, provisioningData.DashboardId)
+			missing = append(missing, provisioningData)
 		}
 	}
 
+	// dashboards with a uid are only removed after saving in case their file was moved
+	var removed []*models.DashboardProvisioning
+	for _, provisioningData := range missing {
+		if uid := fr.dashboardUid(provisioningData.DashboardId); uid != "" {
+			fr.movableDashboards[uid] = provisioningData
+			continue
+		}
+		removed = append(removed, provisioningData)
+	}
+
+	fr.removeProvisionedDashboards(removed)
+}
+
+// dashboardUid returns the uid of the dashboard or an empty string if it has none or can't be loaded.
+func (fr *fileReader) dashboardUid(dashboardId int64) string {
+	query := &models.GetDashboardQuery{Id: dashboardId, OrgId: fr.Cfg.OrgId}
+	if err := bus.Dispatch(query); err != nil {
+		return ""
+	}
+	return query.Result.Uid
+}
+
+// removeUnmovedDashboards removes the dashboards missing on disk that no dashboard file of the scan took over.
+func (fr *fileReader) removeUnmovedDashboards() {
+	var removed []*models.DashboardProvisioning
+	for _, provisioningData := range fr.movableDashboards {
+		removed = append(removed, provisioningData)
+	}
+	fr.movableDashboards = map[string]*models.DashboardProvisioning{}
+	fr.removeProvisionedDashboards(removed)
+}
+
+// removeProvisionedDashboards unprovisions the dashboards if deletion is disabled for the provider and deletes them
+// otherwise.
+func (fr *fileReader) removeProvisionedDashboards(provisioned []*models.DashboardProvisioning) {
+	if fr.isDryRun() {
+		fr.planDryRunRemove(provisioned)
+		return
+	}
+
 	if fr.Cfg.DisableDeletion {
 		// If deletion is disabled for the provisioner we just remove provisioning metadata about the dashboard
 		// so afterwards the dashboard is considered unprovisioned.
-		for _, dashboardId := range dashboardToDelete {
+		for _, provisioningData := range provisioned {
+			dashboardId := provisioningData.DashboardId
 			fr.log.Debug("unprovisioning provisioned dashboard. missing on disk", "id", dashboardId)
+			fr.removeInjectedTags(dashboardId, provisioningData.InjectedTags)
 			err := fr.dashboardProvisioningService.UnprovisionDashboard(dashboardId)
 			if err != nil {
 				fr.log.Error("failed to unprovision dashboard", "dashboard_id", dashboardId, "error", err)
+				continue
 			}
+			fr.renders.forget(dashboardId)
+			fr.countDashboard(metricActionUnprovisioned)
+			fr.recordJournal(journalEntry{
+				DashboardId: dashboardId,
+				Action:      journalActionUnprovisioned,
+				Source:      provisioningData.ExternalId,
+				Hash:        provisioningData.CheckSum,
+			})
 		}
 	} else {
-		// delete dashboard that are missing json file
-		for _, dashboardId := range dashboardToDelete {
+		// delete dashboards that are missing their json file from the database, along with their provisioning metadata
+		for _, provisioningData := range provisioned {
+			dashboardId := provisioningData.DashboardId
 			fr.log.Debug("deleting provisioned dashboard. missing on disk", "id", dashboardId)
 			err := fr.dashboardProvisioningService.DeleteProvisionedDashboard(dashboardId, fr.Cfg.OrgId)
 			if err != nil {
 				fr.log.Error("failed to delete dashboard", "id", dashboardId, "error", err)
+				continue
 			}
+			fr.renders.forget(dashboardId)
+			fr.countDashboard(metricActionDeleted)
+			fr.recordJournal(journalEntry{
+				DashboardId: dashboardId,
+				Action:      journalActionDeleted,
+				Source:      provisioningData.ExternalId,
+				Hash:        provisioningData.CheckSum,
+			})
 		}
 	}
 }
 
-// saveDashboard saves or updates the dashboard provisioning file at path.
-func (fr *fileReader) saveDashboard(path string, folderId int64, fileInfo os.FileInfo, provisionedDashboardRefs map[string]*models.DashboardProvisioning) (provisioningMetadata, error) {
-	provisioningMetadata := provisioningMetadata{}
-	resolvedFileInfo, err := resolveSymlink(fileInfo, path)
-	if err != nil {
-		return provisioningMetadata, err
+// removeInjectedTags removes the tags added by the provider from a dashboard leaving provisioning, so it does not
+// keep claiming to be provisioned.
+func (fr *fileReader) removeInjectedTags(dashboardId int64, tags []string) {
+	if len(tags) == 0 {
+		return
 	}
 
-	provisionedData, alreadyProvisioned := provisionedDashboardRefs[path]
-	upToDate := alreadyProvisioned && provisionedData.Updated >= resolvedFileInfo.ModTime().Unix()
+	query := &models.GetDashboardQuery{Id: dashboardId, OrgId: fr.Cfg.OrgId}
+	if err := bus.Dispatch(query); err != nil {
+		fr.log.Error("failed to load dashboard to remove injected tags", "id", dashboardId, "error", err)
+		return
+	}
+
+	dash := query.Result
+	if !removeTags(dash.Data, tags) {
+		return
+	}
+
+	cmd := &models.SaveDashboardCommand{
+		Dashboard: dash.Data,
+		OrgId:     dash.OrgId,
+		FolderId:  dash.FolderId,
+		Overwrite: true,
+		Message:   "removed provisioning tags",
+	}
+	if err := bus.Dispatch(cmd); err != nil {
+		fr.log.Error("failed to remove injected tags", "id", dashboardId, "error", err)
+	}
+}
+
+// loadedDashboard is a dashboard file read by loadDashboard. jsonFiles is nil when the file couldn't be read, err is
+// set when it can't be saved.
+type loadedDashboard struct {
+	path      string
+	fileInfo  os.FileInfo
+	jsonFiles []*dashboardJsonFile
+	err       error
+}
 
-	jsonFile, err := fr.readDashboardFromFile(path, resolvedFileInfo.ModTime(), folderId)
+// loadDashboard resolves the folder of the dashboard file at path and reads it. It runs in the workers of a scan, so
+// it must not change the state of the reader.
+func (fr *fileReader) loadDashboard(path string, fileInfo os.FileInfo, folders *filesStructureFolders, folder dashboardFolder, provisionedDashboardRefs map[string]*models.DashboardProvisioning) *loadedDashboard {
+	loaded := &loadedDashboard{path: path}
+	if getBoolOption(fr.Cfg.Options, "foldersFromFilesStructure") {
+		if folder, loaded.err = folders.folderForFile(path); loaded.err != nil {
+			return loaded
+		}
+	}
+
+	if loaded.fileInfo, loaded.err = resolveSymlink(fr.fs, fileInfo, path); loaded.err != nil {
+		return loaded
+	}
+
+	modTime := loaded.fileInfo.ModTime()
+	format := formatForFile(fr.formats, path)
+	// dashboards can change without the file changing when it is evaluated or rendered with outside data
+	byModTime := (format == nil || !format.imports) && !fr.rendersWithOutsideData()
+	provisionedData, alreadyProvisioned := provisionedDashboardRefs[externalIdOfPath(path)]
+	unmodified := byModTime && alreadyProvisioned && provisionedData.Updated >= modTime.Unix()
+
+	jsonFiles, err := fr.readDashboardFromFile(path, modTime, folder, !unmodified)
+	if err == errMissingTitle {
+		fr.log.Warn("skipping dashboard without title", "file", path)
+		return loaded
+	}
 	if err != nil {
+		fr.countDashboard(metricActionFailed)
+		fr.status.fail(path, err)
 		fr.log.Error("failed to load dashboard from ", "file", path, "error", err)
-		return provisioningMetadata, nil
+		return loaded
 	}
 
-	if provisionedData != nil && jsonFile.checkSum == provisionedData.CheckSum {
-		upToDate = true
+	for _, jsonFile := range jsonFiles {
+		provisionedData, alreadyProvisioned := provisionedDashboardRefs[jsonFile.externalId]
+		// a file that was touched without changing its content is not saved again, unless the tags injected by the
+		// provider changed so tags removed from the provider are removed from the dashboard too, or the dashboard is
+		// read from another file, like its compressed version. Records saved before the source path was stored get it
+		// with the next change of the dashboard.
+		jsonFile.upToDate = alreadyProvisioned &&
+			((byModTime && provisionedData.Updated >= modTime.Unix()) || jsonFile.checkSum == provisionedData.CheckSum) &&
+			sameStrings(provisionedData.InjectedTags, jsonFile.injectedTags) &&
+			(provisionedData.SourcePath == "" || provisionedData.SourcePath == jsonFile.sourcePath)
+		// with preventUpdate dashboards are only saved once, afterwards they belong to the users
+		if alreadyProvisioned && getBoolOption(fr.Cfg.Options, "preventUpdate") {
+			jsonFile.upToDate = true
+		}
 	}
 
+	loaded.jsonFiles = jsonFiles
+	return loaded
+}
+
+// saveDashboard saves or updates a dashboard of a loaded provisioning file.
+func (fr *fileReader) saveDashboard(jsonFile *dashboardJsonFile, provisionedDashboardRefs map[string]*models.DashboardProvisioning) (provisioningMetadata, error) {
+	provisioningMetadata := provisioningMetadata{}
+	path := jsonFile.externalId
+	provisionedData, alreadyProvisioned := provisionedDashboardRefs[path]
+
 	// keeps track of what uid's and title's we have already provisioned
 	dash := jsonFile.dashboard
 	provisioningMetadata.uid = dash.Dashboard.Uid
 	provisioningMetadata.title = dash.Dashboard.Title
 
-	if upToDate {
+	if jsonFile.upToDate {
+		if fr.isDryRun() {
+			fr.planDryRunSave(jsonFile, provisionedData, false)
+		} else {
+			fr.countDashboard(metricActionUnchanged)
+		}
 		return provisioningMetadata, nil
 	}
 
+	if getBoolOption(fr.Cfg.Options, "requireHealthyDatasources") {
+		if name, err := fr.findUnhealthyDatasource(dash.Dashboard.Data); err != nil {
+			fr.log.Warn("deferring dashboard until its datasource is healthy", "file", path, "datasource", name, "error", err)
+			return provisioningMetadata, nil
+		}
+	}
+
 	if dash.Dashboard.Id != 0 {
 		dash.Dashboard.Data.Set("id", nil)
 		dash.Dashboard.Id = 0
 	}
 
+	// a new file with the uid of a dashboard missing on disk is the file of that dashboard moved
+	moved := false
+	if !alreadyProvisioned && dash.Dashboard.Uid != "" {
+		if provisionedData, moved = fr.movableDashboards[dash.Dashboard.Uid]; moved {
+			fr.log.Debug("moving dashboard to new file", "from", provisionedData.ExternalId, "fromSource", provisionedData.SourcePath, "to", path)
+			delete(fr.movableDashboards, dash.Dashboard.Uid)
+			alreadyProvisioned = true
+		}
+	}
+
 	if alreadyProvisioned {
 		dash.Dashboard.SetId(provisionedData.DashboardId)
+		if getBoolOption(fr.Cfg.Options, "allowUiUpdates") {
+			fr.warnAboutUiChanges(path, provisionedData.DashboardId)
+		}
+	}
+
+	if fr.isDryRun() {
+		fr.planDryRunSave(jsonFile, provisionedData, moved)
+		return provisioningMetadata, nil
 	}
 
 	fr.log.Debug("saving new dashboard", "provisioner", fr.Cfg.Name, "file", path, "folderId", dash.Dashboard.FolderId)
 	dp := &models.DashboardProvisioning{
-		ExternalId: path,
-		Name:       fr.Cfg.Name,
-		Updated:    resolvedFileInfo.ModTime().Unix(),
-		CheckSum:   jsonFile.checkSum,
+		ExternalId:     path,
+		Name:           fr.Cfg.Name,
+		Updated:        jsonFile.lastModified.Unix(),
+		CheckSum:       jsonFile.checkSum,
+		LockMessage:    getStringOption(fr.Cfg.Options, "lockMessage"),
+		InjectedTags:   jsonFile.injectedTags,
+		Revision:       fr.revision,
+		AllowUiUpdates: fr.allowsUiUpdates(),
+		SourcePath:     jsonFile.sourcePath,
 	}
 
-	_, err = fr.dashboardProvisioningService.SaveProvisionedDashboard(dash, dp)
-	return provisioningMetadata, err
+	var previous *previousDashboard
+	if alreadyProvisioned && fr.keepPreviousVersions {
+		var err error
+		if previous, err = fr.getPreviousDashboard(provisionedData); err != nil {
+			return provisioningMetadata, err
+		}
+	}
+
+	saved, err := fr.dashboardProvisioningService.SaveProvisionedDashboard(dash, dp)
+	if err != nil {
+		return provisioningMetadata, err
+	}
+	if previous != nil {
+		fr.updatedDashboards = append(fr.updatedDashboards, previous)
+	}
+
+	action := journalActionUpdated
+	if moved {
+		action = journalActionMoved
+	} else if !alreadyProvisioned {
+		fr.insertedDashboardIds = append(fr.insertedDashboardIds, saved.Id)
+		action = journalActionCreated
+	}
+	if alreadyProvisioned {
+		fr.countDashboard(metricActionUpdated)
+	} else {
+		fr.countDashboard(metricActionInserted)
+	}
+	fr.recordJournal(journalEntry{
+		DashboardId: saved.Id,
+		Uid:         saved.Uid,
+		Action:      action,
+		Source:      path,
+		Hash:        jsonFile.checkSum,
+	})
+	if getBoolOption(fr.Cfg.Options, "renderCheck") {
+		fr.renders.check(path, saved)
+	}
+	return provisioningMetadata, nil
 }
 
 func getProvisionedDashboardByPath(service dashboards.DashboardProvisioningService, name string) (map[string]*models.DashboardProvisioning, error) {
@@ -209,11 +803,25 @@ func getProvisionedDashboardByPath(service dashboards.DashboardProvisioningServi
 }
 
 func getOrCreateFolderId(cfg *DashboardsAsConfig, service dashboards.DashboardProvisioningService) (int64, error) {
-	if cfg.Folder == "" {
+	return getOrCreateFolder(cfg, service, cfg.Folder, cfg.FolderUid, nil)
+}
+
+// getOrCreateFolder returns the id of the folder in the org of the provider, creating it if it doesn't exist yet.
+// With a uid the folder is looked up by uid, so renaming it doesn't create another one, and the title is only used
+// when creating it, falling back to the uid if it is empty. Without a uid the folder is looked up by title. The tags
+// are only set when creating the folder.
+func getOrCreateFolder(cfg *DashboardsAsConfig, service dashboards.DashboardProvisioningService, title string, uid string, tags []string) (int64, error) {
+	if title == "" && uid == "" {
 		return 0, ErrFolderNameMissing
 	}
 
-	cmd := &models.GetDashboardQuery{Slug: models.SlugifyTitle(cfg.Folder), OrgId: cfg.OrgId}
+	cmd := &models.GetDashboardQuery{Slug: models.SlugifyTitle(title), OrgId: cfg.OrgId}
+	if uid != "" {
+		cmd = &models.GetDashboardQuery{Uid: uid, OrgId: cfg.OrgId}
+		if title == "" {
+			title = uid
+		}
+	}
 	err := bus.Dispatch(cmd)
 
 	if err != nil && err != models.ErrDashboardNotFound {
@@ -223,12 +831,19 @@ func getOrCreateFolderId(cfg *DashboardsAsConfig, service dashboards.DashboardPr
 	// dashboard folder not found. create one.
 	if err == models.ErrDashboardNotFound {
 		dash := &dashboards.SaveDashboardDTO{}
-		dash.Dashboard = models.NewDashboardFolder(cfg.Folder)
+		dash.Dashboard = models.NewDashboardFolder(title)
 		dash.Dashboard.IsFolder = true
 		dash.Overwrite = true
 		dash.OrgId = cfg.OrgId
 		// set dashboard folderUid if given
-		dash.Dashboard.SetUid(cfg.FolderUid)
+		dash.Dashboard.SetUid(uid)
+		if len(tags) > 0 {
+			folderTags := make([]interface{}, 0, len(tags))
+			for _, tag := range tags {
+				folderTags = append(folderTags, tag)
+			}
+			dash.Dashboard.Data.Set("tags", folderTags)
+		}
 		dbDash, err := service.SaveFolderForProvisionedDashboards(dash)
 		if err != nil {
 			return 0, err
@@ -244,10 +859,10 @@ func getOrCreateFolderId(cfg *DashboardsAsConfig, service dashboards.DashboardPr
 	return cmd.Result.Id, nil
 }
 
-func resolveSymlink(fileinfo os.FileInfo, path string) (os.FileInfo, error) {
-	checkFilepath, err := filepath.EvalSymlinks(path)
+func resolveSymlink(fsys fileSystem, fileinfo os.FileInfo, path string) (os.FileInfo, error) {
+	checkFilepath, err := fsys.EvalSymlinks(path)
 	if path != checkFilepath {
-		fi, err := os.Lstat(checkFilepath)
+		fi, err := fsys.Lstat(checkFilepath)
 		if err != nil {
 			return nil, err
 		}
@@ -258,31 +873,214 @@ func resolveSymlink(fileinfo os.FileInfo, path string) (os.FileInfo, error) {
 	return fileinfo, err
 }
 
-func createWalkFn(filesOnDisk map[string]os.FileInfo) filepath.WalkFunc {
-	return func(path string, fileInfo os.FileInfo, err error) error {
+// sortedDashboardPaths returns the paths of the files found on disk sorted by the provider path they are in, in the
+// order of roots, and then by their path relative to it, using forward slashes, so dashboards are saved in the same
+// order on every scan and platform.
+func sortedDashboardPaths(roots []string, filesFoundOnDisk map[string]os.FileInfo) []string {
+	rootIndex := func(path string) int {
+		for i, root := range roots {
+			if isPathWithinRoot(path, root) {
+				return i
+			}
+		}
+		return len(roots)
+	}
+	relativePath := func(path string) string {
+		if rel, err := filepath.Rel(rootOf(roots, path), path); err == nil {
+			return filepath.ToSlash(rel)
+		}
+		return filepath.ToSlash(path)
+	}
+
+	paths := make([]string, 0, len(filesFoundOnDisk))
+	for path := range filesFoundOnDisk {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if ri, rj := rootIndex(paths[i]), rootIndex(paths[j]); ri != rj {
+			return ri < rj
+		}
+		return relativePath(paths[i]) < relativePath(paths[j])
+	})
+	return paths
+}
+
+// createWalkFn returns the function collecting the dashboard files of a single walk. With followSymlinks it also
+// walks into symlinked directories, reporting their files under the path of the symlink. Directories are only walked
+// once by their real path, with symlinks and on Windows junctions resolved, so symlinks pointing back to an ancestor
+// don't make the walk loop forever. Skipped directories are logged.
+func createWalkFn(filesOnDisk map[string]os.FileInfo, opts walkOptions) walkDirFunc {
+	visited := map[string]bool{}
+
+	var walkFn walkDirFunc
+	walkFn = func(path string, entry dirEntry, err error) error {
 		if err != nil {
 			return err
 		}
+		if opts.ctx != nil {
+			if err := opts.ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		if entry.IsDir() && opts.maxDepth > 0 && directoryDepth(opts.root, path) > opts.maxDepth {
+			opts.log.Debug("skipping directory deeper than max depth", "path", path, "maxDepth", opts.maxDepth)
+			return filepath.SkipDir
+		}
+
+		if entry.IsDir() && path != opts.root && matchesAnyPattern(opts.exclude, opts.root, path, true) {
+			opts.log.Debug("skipping excluded directory", "path", path)
+			return filepath.SkipDir
+		}
+
+		if opts.followSymlinks {
+			if entry.Type()&os.ModeSymlink != 0 {
+				target, err := opts.fs.Stat(path)
+				if err != nil {
+					return err
+				}
+				if target.IsDir() {
+					return walkSymlinkedDir(opts.fs, path, walkFn)
+				}
+			}
+
+			if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+				realPath, err := opts.fs.EvalSymlinks(path)
+				if err != nil {
+					return err
+				}
+				if visited[realPath] {
+					opts.log.Warn("skipping directory already walked, the path likely contains a symlink loop", "path", path, "realPath", realPath)
+					return filepath.SkipDir
+				}
+				visited[realPath] = true
+			}
+		}
 
-		isValid, err := validateWalkablePath(fileInfo)
+		isValid, err := validateWalkablePath(entry, opts.formats)
 		if !isValid {
 			return err
 		}
 
+		if len(opts.include) > 0 && !matchesAnyPattern(opts.include, opts.root, path, false) {
+			return nil
+		}
+
+		if matchesAnyPattern(opts.exclude, opts.root, path, false) {
+			return nil
+		}
+
+		fileInfo, err := entry.Info()
+		if os.IsNotExist(err) {
+			// removed while walking
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 		filesOnDisk[path] = fileInfo
 		return nil
 	}
+	return walkFn
 }
 
-func validateWalkablePath(fileInfo os.FileInfo) (bool, error) {
-	if fileInfo.IsDir() {
-		if strings.HasPrefix(fileInfo.Name(), ".") {
+// walkOptions control which files createWalkFn collects.
+type walkOptions struct {
+	fs fileSystem
+	// root is the path the walk starts at.
+	root    string
+	formats []*dashboardFileFormat
+	// followSymlinks walks into symlinked directories.
+	followSymlinks bool
+	// maxDepth skips directories more than this many levels below root, 0 means no limit.
+	maxDepth int64
+	// include limits the files to those matching one of the patterns if set, exclude skips the files and directories
+	// matching one of its patterns after that.
+	include []*globPattern
+	exclude []*globPattern
+	log     log.Logger
+	// ctx stops the walk with its error once cancelled. Walks without ctx can't be cancelled.
+	ctx context.Context
+}
+
+// isNotIncluded returns true if the file at path exists but doesn't match the includePatterns option. Excluded
+// files are treated like deleted ones instead, so adding an exclude pattern removes their dashboards.
+func (fr *fileReader) isNotIncluded(path string) bool {
+	if len(fr.includePatterns) == 0 {
+		return false
+	}
+
+	if _, err := fr.fs.Stat(path); err != nil {
+		return false
+	}
+
+	root := rootOf(fr.resolvedPaths(), path)
+	return !matchesAnyPattern(fr.includePatterns, root, path, false) && !fr.isExcluded(root, path)
+}
+
+// isExcluded returns true if the file at path or one of its directories below root matches the excludePatterns
+// option.
+func (fr *fileReader) isExcluded(root string, path string) bool {
+	if matchesAnyPattern(fr.excludePatterns, root, path, false) {
+		return true
+	}
+
+	for dir := filepath.Dir(path); isPathWithinRoot(dir, root) && dir != root; dir = filepath.Dir(dir) {
+		if matchesAnyPattern(fr.excludePatterns, root, dir, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// directoryDepth returns how many levels path is below root, root itself being 0.
+func directoryDepth(root string, path string) int64 {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return int64(len(strings.Split(filepath.ToSlash(rel), "/")))
+}
+
+// walkSymlinkedDir walks the directory link points to, calling walkFn with paths below link instead of the target.
+func walkSymlinkedDir(fsys fileSystem, link string, walkFn walkDirFunc) error {
+	target, err := fsys.EvalSymlinks(link)
+	if err != nil {
+		return err
+	}
+
+	return walkDir(fsys, target, func(path string, entry dirEntry, err error) error {
+		rel, relErr := filepath.Rel(target, path)
+		if relErr != nil {
+			return relErr
+		}
+		if rel == "." && entry != nil {
+			// report the target under the name of the link so hidden links are skipped like hidden directories
+			entry = renamedDirEntry{dirEntry: entry, name: filepath.Base(link)}
+		}
+		return walkFn(filepath.Join(link, rel), entry, err)
+	})
+}
+
+// renamedDirEntry is a dirEntry with another name.
+type renamedDirEntry struct {
+	dirEntry
+	name string
+}
+
+func (e renamedDirEntry) Name() string {
+	return e.name
+}
+
+func validateWalkablePath(entry dirEntry, formats []*dashboardFileFormat) (bool, error) {
+	if entry.IsDir() {
+		if strings.HasPrefix(entry.Name(), ".") {
 			return false, filepath.SkipDir
 		}
 		return false, nil
 	}
 
-	if !strings.HasSuffix(fileInfo.Name(), ".json") {
+	if isDashboardSidecar(entry.Name()) || isFolderManifest(entry.Name()) || formatForFile(formats, entry.Name()) == nil {
 		return false, nil
 	}
 
@@ -290,62 +1088,172 @@ func validateWalkablePath(fileInfo os.FileInfo) (bool, error) {
 }
 
 type dashboardJsonFile struct {
-	dashboard    *dashboards.SaveDashboardDTO
+	dashboard *dashboards.SaveDashboardDTO
+	// externalId identifies the dashboard in the provisioning data, it is the path of the file or, for files holding
+	// an array of dashboards, the path and the uid of the dashboard.
+	externalId string
+	// sourcePath is the absolute path of the file the dashboard was read from.
+	sourcePath   string
 	checkSum     string
 	lastModified time.Time
+	injectedTags []string
+	// upToDate is set when the saved dashboard doesn't need to be updated.
+	upToDate bool
 }
 
-func (fr *fileReader) readDashboardFromFile(path string, lastModified time.Time, folderId int64) (*dashboardJsonFile, error) {
-	reader, err := os.Open(path)
+// readDashboardFromFile reads and parses the dashboard file at path. A file holds a single dashboard or an array of
+// dashboards. The sha256 checksum of a single dashboard file is only computed when checkSum is set, files that are not
+// modified since they were saved don't need it.
+func (fr *fileReader) readDashboardFromFile(path string, lastModified time.Time, folder dashboardFolder, checkSum bool) ([]*dashboardJsonFile, error) {
+	format := formatForFile(fr.formats, path)
+	if format == nil {
+		return nil, fmt.Errorf("unsupported dashboard file format")
+	}
+
+	var all []byte
+	var err error
+	if format.name == jsonnetFormat {
+		// the checksum is taken of the evaluated json so changes of imported libraries are noticed
+		all, err = fr.evaluateJsonnet(path)
+	} else if all, err = fr.fs.ReadFile(path); err == nil && isGzipFile(path) {
+		all, err = gunzip(all)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	content, encoding := decodeDashboardFile(all)
+	if encoding != "" {
+		fr.log.Debug("converted dashboard file to UTF-8", "file", path, "encoding", encoding)
+	}
+
+	if getBoolOption(fr.Cfg.Options, "expandEnv") {
+		if content, err = expandEnvVariables(content, getBoolOption(fr.Cfg.Options, "strictEnv")); err != nil {
+			return nil, err
+		}
+	}
+
+	if getBoolOption(fr.Cfg.Options, "templating") {
+		if content, err = fr.renderDashboardTemplate(path, content); err != nil {
+			return nil, err
+		}
+	}
+
+	if fr.rendersWithOutsideData() {
+		// the checksum is taken of the rendered dashboard so changes of the environment or template data are noticed
+		all = content
+	}
+
+	var sum string
+	if checkSum {
+		if sum, err = util.Sha256SumString(string(all)); err != nil {
+			return nil, err
+		}
+	}
+
+	if format.name == "json" && getBoolOption(fr.Cfg.Options, "allowComments") {
+		content = stripJsonComments(content)
+	}
+
+	data, err := format.parse(content)
 	if err != nil {
 		return nil, err
 	}
-	defer reader.Close()
 
-	all, err := ioutil.ReadAll(reader)
+	if elements, ok := data.Interface().([]interface{}); ok {
+		return fr.readDashboardArray(path, elements, lastModified, folder)
+	}
+
+	if getBoolOption(fr.Cfg.Options, "generateUidFromPath") && data.Get("uid").MustString() == "" {
+		data.Set("uid", fr.generatedUid(path))
+	}
+
+	jsonFile, err := fr.readDashboardJson(path, data, lastModified, folder)
 	if err != nil {
 		return nil, err
 	}
+	jsonFile.externalId = externalIdOfPath(path)
+	jsonFile.checkSum = sum
+	return []*dashboardJsonFile{jsonFile}, nil
+}
 
-	checkSum, err := util.Md5SumString(string(all))
+// readDashboardJson transforms, validates and prepares the parsed dashboard for saving.
+func (fr *fileReader) readDashboardJson(path string, data *simplejson.Json, lastModified time.Time, folder dashboardFolder) (*dashboardJsonFile, error) {
+	data, err := fr.resolveDashboardInputs(data)
 	if err != nil {
 		return nil, err
 	}
 
-	data, err := simplejson.NewJson(all)
+	if !hasTitle(data) {
+		if getStringOption(fr.Cfg.Options, "onMissingTitle") != missingTitleFilename {
+			return nil, errMissingTitle
+		}
+		data.Set("title", titleFromFilename(path))
+	}
+
+	injectedTags := fr.transformDashboard(path, folder.title, data)
+
+	if getBoolOption(fr.Cfg.Options, "validateSchema") {
+		if err := validateDashboardSchema(data); err != nil {
+			return nil, err
+		}
+	}
+
+	dash, err := createDashboardJson(data, lastModified, fr.Cfg, folder.id)
 	if err != nil {
 		return nil, err
 	}
 
-	dash, err := createDashboardJson(data, lastModified, fr.Cfg, folderId)
+	if err := fr.validateDashboard(path, dash.Dashboard); err != nil {
+		return nil, err
+	}
+
+	sourcePath, err := filepath.Abs(path)
 	if err != nil {
 		return nil, err
 	}
 
 	return &dashboardJsonFile{
 		dashboard:    dash,
-		checkSum:     checkSum,
+		sourcePath:   sourcePath,
 		lastModified: lastModified,
+		injectedTags: injectedTags,
 	}, nil
 }
 
+// rendersWithOutsideData returns true if dashboard files are rendered with data from outside the file before parsing.
+func (fr *fileReader) rendersWithOutsideData() bool {
+	return getBoolOption(fr.Cfg.Options, "expandEnv") || getBoolOption(fr.Cfg.Options, "templating")
+}
+
 func (fr *fileReader) resolvedPath() string {
-	if _, err := os.Stat(fr.Path); os.IsNotExist(err) {
+	return fr.resolvePath(fr.Path)
+}
+
+// resolvePath returns the absolute path of the provider path with a leading ~ expanded and symlinks resolved.
+func (fr *fileReader) resolvePath(providerPath string) string {
+	if expanded, err := expandHome(providerPath); err != nil {
+		fr.log.Error("Could not expand home directory", "path", providerPath, "error", err)
+	} else {
+		providerPath = expanded
+	}
+
+	if _, err := fr.fs.Stat(providerPath); os.IsNotExist(err) {
 		fr.log.Error("Cannot read directory", "error", err)
 	}
 
-	path, err := filepath.Abs(fr.Path)
+	path, err := filepath.Abs(providerPath)
 	if err != nil {
-		fr.log.Error("Could not create absolute path", "path", fr.Path, "error", err)
+		fr.log.Error("Could not create absolute path", "path", providerPath, "error", err)
 	}
 
-	path, err = filepath.EvalSymlinks(path)
+	path, err = fr.fs.EvalSymlinks(path)
 	if err != nil {
-		fr.log.Error("Failed to read content of symlinked path", "path", fr.Path, "error", err)
+		fr.log.Error("Failed to read content of symlinked path", "path", providerPath, "error", err)
 	}
 
 	if path == "" {
-		path = fr.Path
+		path = providerPath
 		fr.log.Info("falling back to original path due to EvalSymlink/Abs failure")
 	}
 	return path
@@ -3,14 +3,24 @@
 package dashboards
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
 )
 
 var (
 	symlinkedFolder = "testdata/test-dashboards/symlink"
+	jsonnetFolder   = "testdata/test-dashboards/jsonnet"
+	fakeJsonnet     = "testdata/jsonnet/fake-jsonnet.sh"
 )
 
 func TestProvsionedSymlinkedFolder(t *testing.T) {
@@ -22,7 +32,7 @@ func TestProvsionedSymlinkedFolder(t *testing.T) {
 		Options: map[string]interface{}{"path": symlinkedFolder},
 	}
 
-	reader, err := NewDashboardFileReader(cfg, log.New("test-logger"))
+	reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
 	if err != nil {
 		t.Error("expected err to be nil")
 	}
@@ -38,3 +48,204 @@ func TestProvsionedSymlinkedFolder(t *testing.T) {
 		t.Errorf("got %s want %s", resolvedPath, want)
 	}
 }
+
+func TestAllowedRoot(t *testing.T) {
+	Convey("Provider with allowed root", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+
+		tmp, err := ioutil.TempDir("", "provisioning-root")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(tmp)
+
+		root := filepath.Join(tmp, "root")
+		outside := filepath.Join(tmp, "outside")
+		So(os.MkdirAll(filepath.Join(root, "dashboards"), 0750), ShouldBeNil)
+		So(os.MkdirAll(outside, 0750), ShouldBeNil)
+
+		dashboard := `{"title": "%s"}`
+		So(ioutil.WriteFile(filepath.Join(root, "dashboards", "inside.json"), []byte(fmt.Sprintf(dashboard, "Inside")), 0640), ShouldBeNil)
+		So(ioutil.WriteFile(filepath.Join(outside, "secret.json"), []byte(fmt.Sprintf(dashboard, "Secret")), 0640), ShouldBeNil)
+		So(os.Symlink(filepath.Join(outside, "secret.json"), filepath.Join(root, "dashboards", "escape.json")), ShouldBeNil)
+
+		cfg := &DashboardsAsConfig{
+			Name:    "Default",
+			Type:    "file",
+			OrgId:   1,
+			Options: map[string]interface{}{"allowedRoot": root},
+		}
+
+		Convey("should reject path escaping the root", func() {
+			cfg.Options["path"] = filepath.Join(root, "..", "outside")
+			_, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("should skip symlinked file escaping the root", func() {
+			cfg.Options["path"] = filepath.Join(root, "dashboards")
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			err = reader.startWalkingDisk(context.Background())
+			So(err, ShouldBeNil)
+
+			So(len(fakeService.inserted), ShouldEqual, 1)
+			So(fakeService.inserted[0].Dashboard.Title, ShouldEqual, "Inside")
+		})
+
+		Reset(func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		})
+	})
+}
+
+func TestFollowSymlinks(t *testing.T) {
+	Convey("Provider following symlinks", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+
+		tmp, err := ioutil.TempDir("", "provisioning-symlinks")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(tmp)
+
+		dashboardsDir := filepath.Join(tmp, "dashboards")
+		shared := filepath.Join(tmp, "shared", "team-a")
+		So(os.MkdirAll(dashboardsDir, 0750), ShouldBeNil)
+		So(os.MkdirAll(shared, 0750), ShouldBeNil)
+
+		dashboard := `{"title": "%s"}`
+		So(ioutil.WriteFile(filepath.Join(dashboardsDir, "local.json"), []byte(fmt.Sprintf(dashboard, "Local")), 0640), ShouldBeNil)
+		So(ioutil.WriteFile(filepath.Join(shared, "team.json"), []byte(fmt.Sprintf(dashboard, "Team A")), 0640), ShouldBeNil)
+		So(os.Symlink(shared, filepath.Join(dashboardsDir, "team-a")), ShouldBeNil)
+		So(os.Symlink(filepath.Join(shared, "team.json"), filepath.Join(dashboardsDir, "linked.json")), ShouldBeNil)
+		So(os.Symlink(dashboardsDir, filepath.Join(shared, "back")), ShouldBeNil)
+
+		cfg := &DashboardsAsConfig{
+			Name:    "Default",
+			Type:    "file",
+			OrgId:   1,
+			Options: map[string]interface{}{"path": dashboardsDir},
+		}
+
+		provisionedPaths := func() []string {
+			var paths []string
+			for _, provisioning := range fakeService.provisioned["Default"] {
+				rel, err := filepath.Rel(dashboardsDir, provisioning.ExternalId)
+				So(err, ShouldBeNil)
+				paths = append(paths, rel)
+			}
+			return paths
+		}
+
+		Convey("should not walk into symlinked directories by default", func() {
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			err = reader.startWalkingDisk(context.Background())
+			So(err, ShouldBeNil)
+
+			So(provisionedPaths(), ShouldResemble, []string{"linked.json", "local.json"})
+		})
+
+		Convey("should walk into symlinked directories without looping", func() {
+			cfg.Options["followSymlinks"] = true
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			err = reader.startWalkingDisk(context.Background())
+			So(err, ShouldBeNil)
+
+			So(provisionedPaths(), ShouldResemble, []string{"linked.json", "local.json", "team-a/team.json"})
+		})
+
+		Reset(func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		})
+	})
+}
+
+func TestSymlinkLoop(t *testing.T) {
+	Convey("Walking a directory with a symlink to itself", t, func() {
+		dir, err := ioutil.TempDir("", "provisioning-symlink-loop")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		So(ioutil.WriteFile(filepath.Join(dir, "dashboard.json"), []byte(`{"title": "Loop"}`), 0640), ShouldBeNil)
+		So(os.Symlink(dir, filepath.Join(dir, "self")), ShouldBeNil)
+
+		recorder := &recordingLogger{Logger: log.New("test-logger")}
+		filesOnDisk := map[string]os.FileInfo{}
+		err = walkDir(osFileSystem{}, dir, createWalkFn(filesOnDisk, walkOptions{fs: osFileSystem{}, root: dir, formats: dashboardFileFormats, followSymlinks: true, log: recorder}))
+		So(err, ShouldBeNil)
+
+		Convey("should complete and report the skipped link", func() {
+			So(filesOnDisk, ShouldContainKey, filepath.Join(dir, "dashboard.json"))
+			So(len(filesOnDisk), ShouldEqual, 1)
+			So(recorder.messages, ShouldResemble, []string{"skipping directory already walked, the path likely contains a symlink loop"})
+		})
+	})
+}
+
+func TestJsonnetDashboards(t *testing.T) {
+	Convey("Provisioning jsonnet dashboards", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		binary, err := filepath.Abs(fakeJsonnet)
+		So(err, ShouldBeNil)
+
+		cfg := &DashboardsAsConfig{
+			Name:  "Default",
+			Type:  "file",
+			OrgId: 1,
+			Jpath: []string{"/usr/share/grafonnet", "vendor"},
+			Options: map[string]interface{}{
+				"path":          jsonnetFolder,
+				"formats":       []interface{}{"json", "jsonnet"},
+				"jsonnetBinary": binary,
+			},
+		}
+		reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+		So(err, ShouldBeNil)
+
+		err = reader.startWalkingDisk(context.Background())
+		So(err, ShouldBeNil)
+
+		Convey("should save evaluated dashboards and skip failing files", func() {
+			So(len(fakeService.inserted), ShouldEqual, 1)
+			dash := fakeService.inserted[0].Dashboard
+			So(dash.Title, ShouldEqual, "nodes")
+			So(dash.Data.Get("description").MustString(), ShouldEqual,
+				"/usr/share/grafonnet:"+filepath.Join(jsonnetFolder, "vendor")+":")
+		})
+
+		Convey("should compare evaluated json instead of modification time", func() {
+			provisioned := fakeService.provisioned["Default"][0]
+			provisioned.Updated = time.Now().Add(time.Hour).Unix()
+
+			err = reader.startWalkingDisk(context.Background())
+			So(err, ShouldBeNil)
+			So(len(fakeService.inserted), ShouldEqual, 1)
+
+			provisioned.CheckSum = "library changed"
+			err = reader.startWalkingDisk(context.Background())
+			So(err, ShouldBeNil)
+			So(fakeService.provisioned["Default"][0].CheckSum, ShouldNotEqual, "library changed")
+		})
+
+		Convey("should fail on files of a missing binary", func() {
+			cfg.Options["jsonnetBinary"] = filepath.Join(os.TempDir(), "no-such-jsonnet")
+			_, err := reader.evaluateJsonnet(filepath.Join(jsonnetFolder, "nodes.jsonnet"))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -1,19 +1,28 @@
 package dashboards
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/grafana/grafana/pkg/util"
 	"math/rand"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/rendering"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	dto "github.com/prometheus/client_model/go"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -23,6 +32,20 @@ var (
 	oneDashboard      = "testdata/test-dashboards/one-dashboard"
 	containingId      = "testdata/test-dashboards/containing-id"
 	unprovision       = "testdata/test-dashboards/unprovision"
+	ownerTags         = "testdata/test-dashboards/owner-tags"
+	multiFormat       = "testdata/test-dashboards/multi-format"
+	revisionTag       = "testdata/test-dashboards/revision-tag"
+	encodings         = "testdata/test-dashboards/encodings"
+	orgSpecific       = "testdata/test-dashboards/org-specific"
+	datasourceDep     = "testdata/test-dashboards/datasource-dependent"
+	foldersFromFiles  = "testdata/test-dashboards/folders-from-files"
+	foldersManifest   = "testdata/test-dashboards/folders-with-manifest"
+	nestedDashboards  = "testdata/test-dashboards/nested"
+	patternDashboards = "testdata/test-dashboards/patterns"
+	commented         = "testdata/test-dashboards/commented"
+	duplicateUids     = "testdata/test-dashboards/duplicate-uids"
+	multiplePathsA    = "testdata/test-dashboards/multiple-paths/team-a"
+	multiplePathsB    = "testdata/test-dashboards/multiple-paths/team-b"
 
 	fakeService *fakeDashboardProvisioningService
 )
@@ -39,14 +62,14 @@ func TestCreatingNewDashboardFileReader(t *testing.T) {
 
 		Convey("using path parameter", func() {
 			cfg.Options["path"] = defaultDashboards
-			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"))
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
 			So(err, ShouldBeNil)
 			So(reader.Path, ShouldNotEqual, "")
 		})
 
 		Convey("using folder as options", func() {
 			cfg.Options["folder"] = defaultDashboards
-			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"))
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
 			So(err, ShouldBeNil)
 			So(reader.Path, ShouldNotEqual, "")
 		})
@@ -58,16 +81,75 @@ func TestCreatingNewDashboardFileReader(t *testing.T) {
 			}
 
 			cfg.Options["folder"] = fullPath
-			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"))
+			cfg.Options["allowMissingPath"] = true
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
 			So(err, ShouldBeNil)
 
 			So(reader.Path, ShouldEqual, fullPath)
 			So(filepath.IsAbs(reader.Path), ShouldBeTrue)
 		})
 
+		Convey("should reject a missing path", func() {
+			cfg.Options["path"] = "testdata/test-dashboards/missing"
+			_, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("should reject a path that is a file", func() {
+			cfg.Options["path"] = filepath.Join(oneDashboard, "dashboard1.json")
+			_, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "is not a directory")
+		})
+
+		Convey("should only warn about a missing path with allowMissingPath", func() {
+			cfg.Options["path"] = "testdata/test-dashboards/missing"
+			cfg.Options["allowMissingPath"] = true
+			_, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("with update interval", func() {
+			cfg.Options["path"] = defaultDashboards
+
+			Convey("should use default interval when not set", func() {
+				reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldBeNil)
+				So(reader.Cfg.UpdateIntervalSeconds, ShouldEqual, DefaultUpdateIntervalSeconds)
+			})
+
+			Convey("should reject negative interval", func() {
+				cfg.UpdateIntervalSeconds = -1
+				_, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("should raise interval below the minimum", func() {
+				cfg.UpdateIntervalSeconds = 1
+				reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldBeNil)
+				So(reader.Cfg.UpdateIntervalSeconds, ShouldEqual, defaultMinUpdateIntervalSeconds)
+			})
+
+			Convey("should use configured minimum", func() {
+				cfg.UpdateIntervalSeconds = 20
+				cfg.Options["minUpdateIntervalSeconds"] = 30
+				reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldBeNil)
+				So(reader.Cfg.UpdateIntervalSeconds, ShouldEqual, 30)
+			})
+
+			Convey("should keep interval above the minimum", func() {
+				cfg.UpdateIntervalSeconds = 15
+				reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldBeNil)
+				So(reader.Cfg.UpdateIntervalSeconds, ShouldEqual, 15)
+			})
+		})
+
 		Convey("using relative path", func() {
 			cfg.Options["folder"] = defaultDashboards
-			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"))
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
 			So(err, ShouldBeNil)
 
 			resolvedPath := reader.resolvedPath()
@@ -99,10 +181,10 @@ func TestDashboardFileReader(t *testing.T) {
 				cfg.Options["path"] = defaultDashboards
 				cfg.Folder = "Team A"
 
-				reader, err := NewDashboardFileReader(cfg, logger)
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
 				So(err, ShouldBeNil)
 
-				err = reader.startWalkingDisk()
+				err = reader.startWalkingDisk(context.Background())
 				So(err, ShouldBeNil)
 
 				folders := 0
@@ -130,25 +212,899 @@ func TestDashboardFileReader(t *testing.T) {
 					Slug:    "grafana",
 				})
 
-				reader, err := NewDashboardFileReader(cfg, logger)
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
 				So(err, ShouldBeNil)
 
-				err = reader.startWalkingDisk()
+				err = reader.startWalkingDisk(context.Background())
 				So(err, ShouldBeNil)
 
 				So(len(fakeService.inserted), ShouldEqual, 1)
 			})
 
+			Convey("Should not save touched dashboard when its content is unchanged", func() {
+				cfg.Options["path"] = oneDashboard
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+				So(len(fakeService.inserted), ShouldEqual, 1)
+
+				provisioned := fakeService.provisioned["Default"][0]
+				So(len(provisioned.CheckSum), ShouldEqual, 64)
+
+				// pretend the file was modified after it was saved
+				provisioned.Updated--
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+				So(len(fakeService.inserted), ShouldEqual, 1)
+
+				Convey("but save it when the content changed", func() {
+					provisioned.Updated--
+					provisioned.CheckSum = "changed"
+
+					err = reader.startWalkingDisk(context.Background())
+					So(err, ShouldBeNil)
+					So(len(fakeService.inserted), ShouldEqual, 1)
+					So(fakeService.provisioned["Default"][0].CheckSum, ShouldNotEqual, "changed")
+				})
+			})
+
 			Convey("Overrides id from dashboard.json files", func() {
 				cfg.Options["path"] = containingId
 
-				reader, err := NewDashboardFileReader(cfg, logger)
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+
+				So(len(fakeService.inserted), ShouldEqual, 1)
+			})
+
+			Convey("Should skip dashboards without required tag prefix in strict mode", func() {
+				cfg.Options["path"] = ownerTags
+				cfg.Options["requireTagPrefix"] = "owner:"
+				cfg.Options["validate"] = "strict"
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+
+				So(len(fakeService.inserted), ShouldEqual, 1)
+				So(fakeService.inserted[0].Dashboard.Title, ShouldEqual, "With owner")
+			})
+
+			Convey("Should report progress for each file in order", func() {
+				cfg.Options["path"] = defaultDashboards
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				progress := make(chan ProgressEvent, 10)
+				err = reader.startWalkingDiskWithOptions(context.Background(), ScanOptions{Progress: progress})
+				So(err, ShouldBeNil)
+				close(progress)
+
+				var events []ProgressEvent
+				for event := range progress {
+					events = append(events, event)
+				}
+
+				So(len(events), ShouldEqual, 2)
+				for i, event := range events {
+					So(event.Provider, ShouldEqual, "Default")
+					So(event.Processed, ShouldEqual, i+1)
+					So(event.Total, ShouldEqual, 2)
+					So(event.Error, ShouldBeNil)
+				}
+			})
+
+			Convey("With two files using the same uid", func() {
+				cfg.Options["path"] = duplicateUids
+
+				scan := func() []ProgressEvent {
+					reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+					So(err, ShouldBeNil)
+
+					progress := make(chan ProgressEvent, 10)
+					err = reader.startWalkingDiskWithOptions(context.Background(), ScanOptions{Progress: progress})
+					So(err, ShouldBeNil)
+					close(progress)
+
+					var events []ProgressEvent
+					for event := range progress {
+						events = append(events, event)
+					}
+					return events
+				}
+
+				Convey("should save the first and report the second naming both files", func() {
+					events := scan()
+
+					So(len(fakeService.inserted), ShouldEqual, 1)
+					So(fakeService.inserted[0].Dashboard.Title, ShouldEqual, "First")
+					So(len(events), ShouldEqual, 2)
+					So(events[0].Error, ShouldBeNil)
+					So(events[1].Error, ShouldNotBeNil)
+					So(events[1].Error.Error(), ShouldContainSubstring, "a.json")
+					So(events[1].Error.Error(), ShouldContainSubstring, "b.json")
+				})
+
+				Convey("should only skip the second when the first wins", func() {
+					cfg.Options["onDuplicateUid"] = "firstWins"
+					events := scan()
+
+					So(len(fakeService.inserted), ShouldEqual, 1)
+					So(fakeService.inserted[0].Dashboard.Title, ShouldEqual, "First")
+					So(events[1].Error, ShouldBeNil)
+				})
+
+				Convey("should reject unknown policies", func() {
+					cfg.Options["onDuplicateUid"] = "lastWins"
+					_, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+					So(err, ShouldNotBeNil)
+				})
+			})
+
+			Convey("Should save dashboards in sorted path order on every scan", func() {
+				cfg.Options["path"] = multiFormat
+				cfg.Options["formats"] = []interface{}{"json", "yaml"}
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				for i := 0; i < 5; i++ {
+					progress := make(chan ProgressEvent, 10)
+					err = reader.startWalkingDiskWithOptions(context.Background(), ScanOptions{Progress: progress})
+					So(err, ShouldBeNil)
+					close(progress)
+
+					var names []string
+					for event := range progress {
+						names = append(names, filepath.Base(event.Path))
+					}
+					So(names, ShouldResemble, []string{"broken.yaml", "json-dashboard.json", "yaml-dashboard.yaml", "yml-dashboard.yml"})
+				}
+			})
+
+			Convey("Slow progress consumer should not block the scan", func() {
+				cfg.Options["path"] = defaultDashboards
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				progress := make(chan ProgressEvent)
+				err = reader.startWalkingDiskWithOptions(context.Background(), ScanOptions{Progress: progress})
+				So(err, ShouldBeNil)
+				So(len(fakeService.inserted), ShouldEqual, 2)
+			})
+
+			Convey("Should record lock message on provisioning data", func() {
+				cfg.Options["path"] = oneDashboard
+				cfg.Options["lockMessage"] = "Managed by team-a, edit via the dashboards repo"
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+
+				So(len(fakeService.provisioned["Default"]), ShouldEqual, 1)
+				So(fakeService.provisioned["Default"][0].LockMessage, ShouldEqual, "Managed by team-a, edit via the dashboards repo")
+			})
+
+			Convey("Can read dashboards in all configured formats", func() {
+				cfg.Options["path"] = multiFormat
+				cfg.Options["formats"] = []interface{}{"json", "yaml"}
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+
+				var uids []string
+				for _, i := range fakeService.inserted {
+					uids = append(uids, i.Dashboard.Uid)
+				}
+				So(len(uids), ShouldEqual, 3)
+				So(uids, ShouldContain, "json-dashboard")
+				So(uids, ShouldContain, "yaml-dashboard")
+				So(uids, ShouldContain, "yml-dashboard")
+			})
+
+			Convey("Should read json and yaml dashboards by default", func() {
+				cfg.Options["path"] = multiFormat
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+
+				var uids []string
+				for _, i := range fakeService.inserted {
+					uids = append(uids, i.Dashboard.Uid)
+				}
+				So(uids, ShouldResemble, []string{"json-dashboard", "yaml-dashboard", "yml-dashboard"})
+			})
+
+			Convey("Should only read json dashboards when restricted to json", func() {
+				cfg.Options["path"] = multiFormat
+				cfg.Options["formats"] = []interface{}{"json"}
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+
+				So(len(fakeService.inserted), ShouldEqual, 1)
+				So(fakeService.inserted[0].Dashboard.Uid, ShouldEqual, "json-dashboard")
+			})
+
+			Convey("Should read json dashboards with comments when allowed", func() {
+				cfg.Options["path"] = commented
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+				So(len(fakeService.inserted), ShouldEqual, 0)
+
+				cfg.Options["allowComments"] = true
+				reader, err = NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+				So(len(fakeService.inserted), ShouldEqual, 1)
+
+				dash := fakeService.inserted[0].Dashboard
+				So(dash.Title, ShouldEqual, "Commented")
+				So(dash.Data.Get("links").GetIndex(0).Get("url").MustString(), ShouldEqual, "https://grafana.com/docs/*")
+			})
+
+			Convey("Unknown format should return error", func() {
+				cfg.Options["path"] = multiFormat
+				cfg.Options["formats"] = []interface{}{"json", "xml"}
+
+				_, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("Should record tags added by the provider", func() {
+				cfg.Options["path"] = ownerTags
+				cfg.Options["addTags"] = []interface{}{"provisioned", "network"}
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+
+				So(len(fakeService.provisioned["Default"]), ShouldEqual, 2)
+				for _, provisioned := range fakeService.provisioned["Default"] {
+					So(provisioned.InjectedTags, ShouldResemble, []string{"provisioned"})
+				}
+				for _, dto := range fakeService.inserted {
+					So(dto.Dashboard.GetTags(), ShouldContain, "provisioned")
+				}
+			})
+
+			Convey("Should remove tags removed from the provider on the next scan", func() {
+				cfg.Options["path"] = ownerTags
+				cfg.Tags = []string{"provisioned", "team:a"}
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+				So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+				for _, dto := range fakeService.inserted {
+					So(dto.Dashboard.GetTags(), ShouldContain, "team:a")
+				}
+
+				cfg.Tags = []string{"provisioned"}
+				fakeService.inserted = nil
+				So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+				So(len(fakeService.inserted), ShouldEqual, 2)
+				for _, dto := range fakeService.inserted {
+					So(dto.Dashboard.GetTags(), ShouldContain, "provisioned")
+					So(dto.Dashboard.GetTags(), ShouldNotContain, "team:a")
+				}
+				for _, provisioned := range fakeService.provisioned["Default"] {
+					So(provisioned.InjectedTags, ShouldResemble, []string{"provisioned"})
+				}
+
+				fakeService.inserted = nil
+				So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+				So(fakeService.inserted, ShouldBeEmpty)
+			})
+
+			Convey("Should install self monitoring dashboard once", func() {
+				cfg.Options["path"] = oneDashboard
+				cfg.Options["installSelfMonitoringDashboard"] = true
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+				err = reader.startWalkingDisk(context.Background())
 				So(err, ShouldBeNil)
 
-				err = reader.startWalkingDisk()
+				var uids []string
+				for _, dto := range fakeService.inserted {
+					uids = append(uids, dto.Dashboard.Uid)
+				}
+				So(uids, ShouldContain, selfMonitoringDashboardUid)
+				So(len(fakeService.inserted), ShouldEqual, 2)
+				So(len(fakeService.provisioned["Default"]), ShouldEqual, 2)
+			})
+
+			Convey("Should only provision dashboards restricted by sidecar into the listed orgs", func() {
+				tenantPath, err := filepath.Abs(filepath.Join(orgSpecific, "tenant.json"))
 				So(err, ShouldBeNil)
+				fakeService.provisioned["org-1"] = []*models.DashboardProvisioning{
+					{Name: "org-1", ExternalId: tenantPath, DashboardId: 42},
+				}
+
+				for _, orgId := range []int64{1, 2, 3} {
+					orgCfg := &DashboardsAsConfig{
+						Name:    fmt.Sprintf("org-%d", orgId),
+						Type:    "file",
+						OrgId:   orgId,
+						Options: map[string]interface{}{"path": orgSpecific},
+					}
+					reader, err := NewDashboardFileReader(orgCfg, logger, ReaderOptions{})
+					So(err, ShouldBeNil)
+
+					err = reader.startWalkingDisk(context.Background())
+					So(err, ShouldBeNil)
+				}
+
+				titlesByOrg := map[int64][]string{}
+				for _, dto := range fakeService.inserted {
+					titlesByOrg[dto.OrgId] = append(titlesByOrg[dto.OrgId], dto.Dashboard.Title)
+				}
+				So(titlesByOrg, ShouldResemble, map[int64][]string{
+					1: {"Shared"},
+					2: {"Shared"},
+					3: {"Shared", "Tenant"},
+				})
+
+				for _, provisioned := range fakeService.provisioned["org-1"] {
+					So(provisioned.ExternalId, ShouldNotEqual, tenantPath)
+				}
+			})
+
+			Convey("With render check", func() {
+				cfg.Options["path"] = defaultDashboards
+				cfg.Options["renderCheck"] = true
+				renderer := &fakeRenderer{failTitles: map[string]bool{"Grafana1": true}}
+
+				Convey("should report dashboard failing to render in the status", func() {
+					reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{RenderService: renderer})
+					So(err, ShouldBeNil)
+
+					err = reader.startWalkingDisk(context.Background())
+					So(err, ShouldBeNil)
+					reader.renders.wait()
+
+					So(len(fakeService.inserted), ShouldEqual, 2)
+					So(len(renderer.rendered), ShouldEqual, 2)
+					unhealthy := reader.Status().UnhealthyDashboards
+					So(len(unhealthy), ShouldEqual, 1)
+					So(filepath.Base(unhealthy[0].Path), ShouldEqual, "dashboard1.json")
+					So(unhealthy[0].Error, ShouldEqual, "panel plugin not found")
+
+					Convey("and drop it once it is saved again and renders", func() {
+						renderer.failTitles = nil
+						for _, dto := range fakeService.inserted {
+							reader.renders.check(unhealthy[0].Path, dto.Dashboard)
+						}
+						reader.renders.wait()
+
+						So(reader.Status().UnhealthyDashboards, ShouldBeEmpty)
+					})
+
+					Convey("and drop it once its file is deleted", func() {
+						reader.removeProvisionedDashboards(fakeService.provisioned["Default"])
+
+						So(reader.Status().UnhealthyDashboards, ShouldBeEmpty)
+					})
+				})
+
+				Convey("should render at most the concurrent limit at once", func() {
+					renderer.release = make(chan struct{})
+					checker := newRenderChecker(renderer, logger)
+					for id := int64(1); id <= 50; id++ {
+						checker.check("dashboard.json", &models.Dashboard{Id: id, Uid: "uid", Slug: "slug", OrgId: 1})
+					}
+
+					checker.mu.Lock()
+					So(checker.workers, ShouldEqual, renderCheckConcurrentLimit)
+					So(len(checker.queue), ShouldBeGreaterThanOrEqualTo, 50-renderCheckConcurrentLimit)
+					checker.mu.Unlock()
+
+					close(renderer.release)
+					checker.wait()
 
+					So(len(renderer.rendered), ShouldEqual, 50)
+					So(checker.workers, ShouldEqual, 0)
+				})
+
+				Convey("should skip check without renderer", func() {
+					reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{RenderService: &fakeRenderer{err: rendering.ErrNoRenderer}})
+					So(err, ShouldBeNil)
+
+					err = reader.startWalkingDisk(context.Background())
+					So(err, ShouldBeNil)
+					reader.renders.wait()
+
+					So(len(fakeService.inserted), ShouldEqual, 2)
+					So(reader.Status().UnhealthyDashboards, ShouldBeEmpty)
+				})
+			})
+
+			Convey("Should defer dashboard while its datasource is unhealthy", func() {
+				cfg.Options["path"] = datasourceDep
+				cfg.Options["requireHealthyDatasources"] = true
+				bus.AddHandler("test", func(query *models.GetDataSourceByNameQuery) error {
+					query.Result = &models.DataSource{Name: query.Name, OrgId: query.OrgId, Type: "prometheus"}
+					return nil
+				})
+
+				var checked []string
+				healthErr := errors.New("connection refused")
+				origCheckDatasourceHealth := checkDatasourceHealth
+				checkDatasourceHealth = func(ds *models.DataSource) error {
+					checked = append(checked, ds.Name)
+					return healthErr
+				}
+				defer func() { checkDatasourceHealth = origCheckDatasourceHealth }()
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+				So(checked, ShouldResemble, []string{"Prometheus"})
+				So(len(fakeService.inserted), ShouldEqual, 0)
+
+				healthErr = nil
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
 				So(len(fakeService.inserted), ShouldEqual, 1)
+				So(fakeService.inserted[0].Dashboard.Title, ShouldEqual, "Datasource dependent")
+			})
+
+			Convey("Should provision dashboards into folders named after their directories", func() {
+				cfg.Folder = "Provisioned"
+				cfg.Options["path"] = foldersFromFiles
+				cfg.Options["foldersFromFilesStructure"] = true
+
+				scan := func() {
+					reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+					So(err, ShouldBeNil)
+
+					err = reader.startWalkingDisk(context.Background())
+					So(err, ShouldBeNil)
+				}
+				scan()
+
+				folderIds := map[string]int64{}
+				folderByDashboard := map[string]int64{}
+				for _, dto := range fakeService.inserted {
+					if dto.Dashboard.IsFolder {
+						folderIds[dto.Dashboard.Title] = dto.Dashboard.Id
+					} else {
+						folderByDashboard[dto.Dashboard.Title] = dto.Dashboard.FolderId
+					}
+				}
+
+				So(len(folderIds), ShouldEqual, 3)
+				So(folderByDashboard["Overview"], ShouldEqual, folderIds["Provisioned"])
+				So(folderByDashboard["Storage"], ShouldEqual, folderIds["infra"])
+				So(folderByDashboard["Switch"], ShouldEqual, folderIds["infra/network"])
+
+				Convey("and reuse existing folders", func() {
+					inserted := len(fakeService.inserted)
+					scan()
+					So(len(fakeService.inserted), ShouldEqual, inserted)
+				})
+			})
+
+			Convey("Should set the timezone of each folder of the files structure", func() {
+				cfg.Folder = "Provisioned"
+				cfg.Options["path"] = foldersManifest
+				cfg.Options["foldersFromFilesStructure"] = true
+				cfg.Options["folderTimezones"] = map[interface{}]interface{}{
+					"Provisioned":     "America/New_York",
+					"Overview folder": "utc",
+					"Network":         "Europe/Berlin",
+				}
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+
+				timezones := map[string]string{}
+				for _, dto := range fakeService.inserted {
+					if !dto.Dashboard.IsFolder {
+						timezones[dto.Dashboard.Title] = dto.Dashboard.Data.Get("timezone").MustString()
+					}
+				}
+				So(timezones, ShouldResemble, map[string]string{
+					"Overview": "utc",
+					"Switch":   "Europe/Berlin",
+					"Storage":  "",
+				})
+			})
+
+			Convey("Should provision the dashboards of all paths as one provider", func() {
+				cfg.Folder = "Teams"
+				cfg.Options["path"] = []interface{}{multiplePathsA}
+				cfg.Options["paths"] = []interface{}{multiplePathsB}
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+				So(reader.paths, ShouldResemble, []string{multiplePathsA, multiplePathsB})
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+
+				var titles []string
+				for _, dto := range fakeService.inserted {
+					if !dto.Dashboard.IsFolder {
+						titles = append(titles, dto.Dashboard.Title)
+					}
+				}
+
+				Convey("should report a uid used in two paths instead of overwriting the dashboard", func() {
+					So(titles, ShouldResemble, []string{"CPU", "Memory"})
+					So(len(fakeService.provisioned["Default"]), ShouldEqual, 2)
+				})
+
+				Convey("should remove the dashboards of a path removed from the provider", func() {
+					cfg.Options["path"] = multiplePathsA
+					delete(cfg.Options, "paths")
+					reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+					So(err, ShouldBeNil)
+
+					err = reader.startWalkingDisk(context.Background())
+					So(err, ShouldBeNil)
+					So(len(fakeService.provisioned["Default"]), ShouldEqual, 1)
+					So(fakeService.provisioned["Default"][0].ExternalId, ShouldEndWith, "cpu.json")
+				})
+			})
+
+			Convey("Should reject more than one path for other provider types", func() {
+				cfg.Type = archiveSourceType
+				cfg.Options["path"] = []interface{}{"a.zip", "b.zip"}
+
+				_, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("Should provision dashboards into every listed org", func() {
+				cfg.Folder = "Shared"
+				cfg.OrgIds = []int64{1, 2}
+				cfg.Options["path"] = oneDashboard
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+
+				foldersByOrg := map[int64]int{}
+				dashboardsByOrg := map[int64]int{}
+				for _, dto := range fakeService.inserted {
+					if dto.Dashboard.IsFolder {
+						foldersByOrg[dto.OrgId]++
+					} else {
+						dashboardsByOrg[dto.OrgId]++
+					}
+				}
+				So(foldersByOrg, ShouldResemble, map[int64]int{1: 1, 2: 1})
+				So(dashboardsByOrg, ShouldResemble, map[int64]int{1: 1, 2: 1})
+
+				So(len(fakeService.provisioned["Default@1"]), ShouldEqual, 1)
+				So(len(fakeService.provisioned["Default@2"]), ShouldEqual, 1)
+				So(fakeService.provisioned["Default"], ShouldBeEmpty)
+			})
+
+			Convey("Should provision dashboards into orgs created later with orgId *", func() {
+				orgs := []*models.OrgDTO{{Id: 1}}
+				bus.AddHandler("test", func(query *models.SearchOrgsQuery) error {
+					query.Result = orgs
+					return nil
+				})
+				cfg.AllOrgs = true
+				cfg.Options["path"] = oneDashboard
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+				So(len(fakeService.inserted), ShouldEqual, 1)
+
+				orgs = append(orgs, &models.OrgDTO{Id: 2})
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+				So(len(fakeService.inserted), ShouldEqual, 2)
+				So(fakeService.inserted[1].OrgId, ShouldEqual, 2)
+				So(reader.provisionsAs("Default@2"), ShouldBeTrue)
+				So(reader.provisionsAs("Default"), ShouldBeFalse)
+			})
+
+			Convey("Should provision dashboards into the folders of their folder manifests", func() {
+				cfg.Folder = "Provisioned"
+				cfg.Options["path"] = foldersManifest
+				cfg.Options["foldersFromFilesStructure"] = true
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+
+				folders := map[string]*models.Dashboard{}
+				folderByDashboard := map[string]int64{}
+				for _, dto := range fakeService.inserted {
+					if dto.Dashboard.IsFolder {
+						folders[dto.Dashboard.Title] = dto.Dashboard
+					} else {
+						folderByDashboard[dto.Dashboard.Title] = dto.Dashboard.FolderId
+					}
+				}
+
+				So(len(folderByDashboard), ShouldEqual, 3)
+				So(folderByDashboard["Overview"], ShouldEqual, folders["Overview folder"].Id)
+				So(folderByDashboard["Switch"], ShouldEqual, folders["Network"].Id)
+				So(folderByDashboard["Storage"], ShouldEqual, folders["storage"].Id)
+
+				So(folders["Network"].Uid, ShouldEqual, "network")
+				So(folders["Network"].Data.Get("tags").MustStringArray(), ShouldResemble, []string{"infra"})
+			})
+
+			Convey("Should provision the same dashboards with and without concurrency", func() {
+				cfg.Folder = "Provisioned"
+				cfg.Options["path"] = foldersFromFiles
+				cfg.Options["foldersFromFilesStructure"] = true
+
+				// provision returns every saved dashboard and folder as title and folder title in the order saved
+				provision := func(concurrency int) []string {
+					fakeService = mockDashboardProvisioningService()
+					cfg.Options["concurrency"] = concurrency
+
+					reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+					So(err, ShouldBeNil)
+					So(reader.concurrency, ShouldEqual, concurrency)
+
+					err = reader.startWalkingDisk(context.Background())
+					So(err, ShouldBeNil)
+
+					folderTitles := map[int64]string{}
+					for _, dto := range fakeService.inserted {
+						if dto.Dashboard.IsFolder {
+							folderTitles[dto.Dashboard.Id] = dto.Dashboard.Title
+						}
+					}
+
+					var saved []string
+					for _, dto := range fakeService.inserted {
+						if !dto.Dashboard.IsFolder {
+							saved = append(saved, folderTitles[dto.Dashboard.FolderId]+"/"+dto.Dashboard.Title)
+						}
+					}
+					return saved
+				}
+
+				serial := provision(1)
+				So(len(serial), ShouldEqual, 3)
+				for i := 0; i < 5; i++ {
+					So(provision(8), ShouldResemble, serial)
+					So(len(fakeService.inserted), ShouldEqual, 6)
+				}
+			})
+
+			Convey("Should reject negative concurrency", func() {
+				cfg.Options["path"] = defaultDashboards
+				cfg.Options["concurrency"] = -1
+
+				_, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("With max depth", func() {
+				cfg.Options["path"] = nestedDashboards
+
+				titles := func() []string {
+					reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+					So(err, ShouldBeNil)
+
+					err = reader.startWalkingDisk(context.Background())
+					So(err, ShouldBeNil)
+
+					var titles []string
+					for _, dto := range fakeService.inserted {
+						titles = append(titles, dto.Dashboard.Title)
+					}
+					return titles
+				}
+
+				Convey("should skip dashboards deeper than the limit", func() {
+					cfg.Options["maxDepth"] = 2
+					So(titles(), ShouldResemble, []string{"Shallow"})
+				})
+
+				Convey("should walk all levels without limit", func() {
+					So(titles(), ShouldResemble, []string{"Shallow", "Deep"})
+				})
+			})
+
+			Convey("With include patterns", func() {
+				cfg.Options["path"] = patternDashboards
+				cfg.Options["includePatterns"] = []interface{}{"prod-*.json", "shared/**.json"}
+
+				absPath := func(rel string) string {
+					path, err := filepath.Abs(filepath.Join(patternDashboards, rel))
+					So(err, ShouldBeNil)
+					return path
+				}
+				fakeService.provisioned["Default"] = []*models.DashboardProvisioning{
+					{Name: "Default", ExternalId: absPath("dev-api.json"), DashboardId: 1},
+					{Name: "Default", ExternalId: absPath("removed.json"), DashboardId: 2},
+				}
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+
+				Convey("should only provision matching files", func() {
+					var titles []string
+					for _, dto := range fakeService.inserted {
+						titles = append(titles, dto.Dashboard.Title)
+					}
+					So(titles, ShouldResemble, []string{"Prod API", "Disk", "Network"})
+				})
+
+				Convey("should not remove dashboards of files skipped by the patterns", func() {
+					var paths []string
+					for _, provisioned := range fakeService.provisioned["Default"] {
+						paths = append(paths, provisioned.ExternalId)
+					}
+					So(paths, ShouldContain, absPath("dev-api.json"))
+					So(paths, ShouldNotContain, absPath("removed.json"))
+				})
+			})
+
+			Convey("With exclude patterns", func() {
+				cfg.Options["path"] = patternDashboards
+				cfg.DisableDeletion = true
+
+				absPath := func(rel string) string {
+					path, err := filepath.Abs(filepath.Join(patternDashboards, rel))
+					So(err, ShouldBeNil)
+					return path
+				}
+				fakeService.provisioned["Default"] = []*models.DashboardProvisioning{
+					{Name: "Default", ExternalId: absPath("dev-api.json"), DashboardId: 1},
+					{Name: "Default", ExternalId: absPath("drafts/wip.json"), DashboardId: 2},
+					{Name: "Default", ExternalId: absPath("shared/nested/disk.json"), DashboardId: 3},
+				}
+
+				scan := func() ([]string, []string) {
+					reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+					So(err, ShouldBeNil)
+
+					err = reader.startWalkingDisk(context.Background())
+					So(err, ShouldBeNil)
+
+					var titles []string
+					for _, dto := range fakeService.inserted {
+						titles = append(titles, dto.Dashboard.Title)
+					}
+					var paths []string
+					for _, provisioned := range fakeService.provisioned["Default"] {
+						paths = append(paths, provisioned.ExternalId)
+					}
+					return titles, paths
+				}
+
+				Convey("should skip excluded files and directories and unprovision them", func() {
+					cfg.Options["excludePatterns"] = []interface{}{"drafts/", "*.tmpl.json"}
+
+					titles, paths := scan()
+					So(titles, ShouldResemble, []string{"Dev API", "Prod API", "Disk", "Network"})
+					So(paths, ShouldNotContain, absPath("drafts/wip.json"))
+				})
+
+				Convey("should evaluate excludes after includes", func() {
+					cfg.Options["includePatterns"] = []interface{}{"shared/**.json"}
+					cfg.Options["excludePatterns"] = []interface{}{"shared/nested/"}
+
+					titles, paths := scan()
+					So(titles, ShouldResemble, []string{"Network"})
+					So(paths, ShouldContain, absPath("dev-api.json"))
+					So(paths, ShouldNotContain, absPath("shared/nested/disk.json"))
+				})
+			})
+
+			Convey("Should reject invalid include pattern", func() {
+				cfg.Options["path"] = patternDashboards
+				cfg.Options["includePatterns"] = []interface{}{"[a-"}
+
+				_, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldNotBeNil)
+			})
+
+			Convey("Can read dashboards with BOM and in UTF-16", func() {
+				cfg.Options["path"] = encodings
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+
+				var titles []string
+				for _, dto := range fakeService.inserted {
+					titles = append(titles, dto.Dashboard.Title)
+				}
+				So(titles, ShouldResemble, []string{"UTF-16BE with BOM", "UTF-16LE with BOM", "UTF-8 with BOM"})
+			})
+
+			Convey("Should warn but still provision when exceeding soft max dashboards", func() {
+				cfg.Options["path"] = defaultDashboards
+				cfg.Options["softMaxDashboards"] = 1
+				recorder := &recordingLogger{Logger: logger}
+				exceeded := func() float64 {
+					metric := &dto.Metric{}
+					err := metrics.M_Provisioning_Soft_Max_Exceeded.WithLabelValues("Default").Write(metric)
+					So(err, ShouldBeNil)
+					return metric.GetCounter().GetValue()
+				}
+				before := exceeded()
+
+				reader, err := NewDashboardFileReader(cfg, recorder, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+
+				So(len(fakeService.inserted), ShouldEqual, 2)
+				So(recorder.messages, ShouldContain, "provider exceeds its soft limit of dashboards")
+				So(exceeded(), ShouldEqual, before+1)
+			})
+
+			Convey("Should tag dashboards with the current commit", func() {
+				cfg.Options["path"] = revisionTag
+				cfg.Options["tagWithCommit"] = true
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+				reader.resolveRevision = func() (string, error) {
+					return "3f2a9c1d8e7b6a5f4e3d2c1b0a9f8e7d6c5b4a39", nil
+				}
+
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+
+				So(len(fakeService.inserted), ShouldEqual, 1)
+				So(fakeService.inserted[0].Dashboard.GetTags(), ShouldResemble, []string{"network", "rev:3f2a9c1"})
 			})
 
 			Convey("Invalid configuration should return error", func() {
@@ -159,14 +1115,14 @@ func TestDashboardFileReader(t *testing.T) {
 					Folder: "",
 				}
 
-				_, err := NewDashboardFileReader(cfg, logger)
+				_, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
 				So(err, ShouldNotBeNil)
 			})
 
 			Convey("Broken dashboards should not cause error", func() {
 				cfg.Options["path"] = brokenDashboards
 
-				_, err := NewDashboardFileReader(cfg, logger)
+				_, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
 				So(err, ShouldBeNil)
 			})
 
@@ -174,16 +1130,16 @@ func TestDashboardFileReader(t *testing.T) {
 				cfg1 := &DashboardsAsConfig{Name: "1", Type: "file", OrgId: 1, Folder: "f1", Options: map[string]interface{}{"path": containingId}}
 				cfg2 := &DashboardsAsConfig{Name: "2", Type: "file", OrgId: 1, Folder: "f2", Options: map[string]interface{}{"path": containingId}}
 
-				reader1, err := NewDashboardFileReader(cfg1, logger)
+				reader1, err := NewDashboardFileReader(cfg1, logger, ReaderOptions{})
 				So(err, ShouldBeNil)
 
-				err = reader1.startWalkingDisk()
+				err = reader1.startWalkingDisk(context.Background())
 				So(err, ShouldBeNil)
 
-				reader2, err := NewDashboardFileReader(cfg2, logger)
+				reader2, err := NewDashboardFileReader(cfg2, logger, ReaderOptions{})
 				So(err, ShouldBeNil)
 
-				err = reader2.startWalkingDisk()
+				err = reader2.startWalkingDisk(context.Background())
 				So(err, ShouldBeNil)
 
 				var folderCount int
@@ -239,16 +1195,53 @@ func TestDashboardFileReader(t *testing.T) {
 			So(inserted, ShouldBeTrue)
 		})
 
+		Convey("can get dashboard folder by uid after it was renamed", func() {
+			fakeService.getDashboard = append(fakeService.getDashboard, &models.Dashboard{
+				Id:       7,
+				Uid:      "team-a",
+				Title:    "Team A (old)",
+				Slug:     "team-a-old",
+				IsFolder: true,
+			})
+			cfg := &DashboardsAsConfig{
+				Name:      "Default",
+				Type:      "file",
+				OrgId:     1,
+				Folder:    "Team A",
+				FolderUid: "team-a",
+			}
+
+			folderId, err := getOrCreateFolderId(cfg, fakeService)
+			So(err, ShouldBeNil)
+			So(folderId, ShouldEqual, 7)
+			So(fakeService.inserted, ShouldBeEmpty)
+		})
+
+		Convey("can create dashboard folder with uid", func() {
+			cfg := &DashboardsAsConfig{
+				Name:      "Default",
+				Type:      "file",
+				OrgId:     1,
+				FolderUid: "team-b",
+			}
+
+			_, err := getOrCreateFolderId(cfg, fakeService)
+			So(err, ShouldBeNil)
+			So(len(fakeService.inserted), ShouldEqual, 1)
+			So(fakeService.inserted[0].Dashboard.Uid, ShouldEqual, "team-b")
+			So(fakeService.inserted[0].Dashboard.Title, ShouldEqual, "team-b")
+		})
+
 		Convey("Walking the folder with dashboards", func() {
 			noFiles := map[string]os.FileInfo{}
 
 			Convey("should skip dirs that starts with .", func() {
-				shouldSkip := createWalkFn(noFiles)("path", &FakeFileInfo{isDirectory: true, name: ".folder"}, nil)
+				shouldSkip := createWalkFn(noFiles, walkOptions{formats: dashboardFileFormats, log: logger})("path", fileInfoEntry{FileInfo: &FakeFileInfo{isDirectory: true, name: ".folder"}}, nil)
 				So(shouldSkip, ShouldEqual, filepath.SkipDir)
 			})
 
 			Convey("should keep walking if file is not .json", func() {
-				shouldSkip := createWalkFn(noFiles)("path", &FakeFileInfo{isDirectory: true, name: "folder"}, nil)
+				shouldSkip := createWalkFn(noFiles, walkOptions{formats: dashboardFileFormats, log: logger})("path", fileInfoEntry{FileInfo: &FakeFileInfo{isDirectory: true, name: "folder"}}, nil)
 				So(shouldSkip, ShouldBeNil)
 			})
 		})
@@ -281,25 +1274,71 @@ func TestDashboardFileReader(t *testing.T) {
 				},
 			}
 
-			Convey("Missing dashboard should be unprovisioned if DisableDeletion = true", func() {
+			Convey("Missing dashboard should be unprovisioned but kept if DisableDeletion = true", func() {
 				cfg.DisableDeletion = true
 
-				reader, err := NewDashboardFileReader(cfg, logger)
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
 				So(err, ShouldBeNil)
 
-				err = reader.startWalkingDisk()
+				err = reader.startWalkingDisk(context.Background())
 				So(err, ShouldBeNil)
 
 				So(len(fakeService.provisioned["Default"]), ShouldEqual, 1)
 				So(fakeService.provisioned["Default"][0].ExternalId, ShouldEqual, absPath1)
+				var keptIds []int64
+				for _, dto := range fakeService.inserted {
+					keptIds = append(keptIds, dto.Dashboard.Id)
+				}
+				So(keptIds, ShouldContain, int64(2))
+			})
+
+			Convey("Missing dashboard should lose injected tags when unprovisioned", func() {
+				cfg.DisableDeletion = true
+				fakeService.provisioned["Default"][1].InjectedTags = []string{"provisioned"}
+
+				var saved *models.SaveDashboardCommand
+				bus.AddHandler("test", func(query *models.GetDashboardQuery) error {
+					query.Result = models.NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+						"id":    query.Id,
+						"title": "Deleted from disk",
+						"tags":  []interface{}{"network", "provisioned"},
+					}))
+					return nil
+				})
+				bus.AddHandler("test", func(cmd *models.SaveDashboardCommand) error {
+					saved = cmd
+					return nil
+				})
+
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
 
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+
+				So(saved, ShouldNotBeNil)
+				So(saved.Dashboard.Get("id").MustInt64(), ShouldEqual, 2)
+				So(saved.Dashboard.Get("tags").MustStringArray(), ShouldResemble, []string{"network"})
+				So(len(fakeService.provisioned["Default"]), ShouldEqual, 1)
+			})
+
+			Convey("Missing dashboard should be kept provisioned if scan skips deletion", func() {
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				err = reader.startWalkingDiskWithOptions(context.Background(), ScanOptions{SkipDelete: true})
+				So(err, ShouldBeNil)
+
+				So(len(fakeService.provisioned["Default"]), ShouldEqual, 2)
+				So(len(fakeService.inserted), ShouldEqual, 2)
+				So(fakeService.inserted[1].Dashboard.Id, ShouldEqual, 1)
 			})
 
 			Convey("Missing dashboard should be deleted if DisableDeletion = false", func() {
-				reader, err := NewDashboardFileReader(cfg, logger)
+				reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
 				So(err, ShouldBeNil)
 
-				err = reader.startWalkingDisk()
+				err = reader.startWalkingDisk(context.Background())
 				So(err, ShouldBeNil)
 
 				So(len(fakeService.provisioned["Default"]), ShouldEqual, 1)
@@ -358,6 +1397,10 @@ type fakeDashboardProvisioningService struct {
 	inserted     []*dashboards.SaveDashboardDTO
 	provisioned  map[string][]*models.DashboardProvisioning
 	getDashboard []*models.Dashboard
+	// saveErrors makes saving dashboards of the provider with the given name fail.
+	saveErrors map[string]error
+	// mu guards inserted and getDashboard, folders are saved and looked up by the workers of a scan.
+	mu sync.Mutex
 }
 
 func (s *fakeDashboardProvisioningService) GetProvisionedDashboardData(name string) ([]*models.DashboardProvisioning, error) {
@@ -369,6 +1412,13 @@ func (s *fakeDashboardProvisioningService) GetProvisionedDashboardData(name stri
 }
 
 func (s *fakeDashboardProvisioningService) SaveProvisionedDashboard(dto *dashboards.SaveDashboardDTO, provisioning *models.DashboardProvisioning) (*models.Dashboard, error) {
+	if err, ok := s.saveErrors[provisioning.Name]; ok {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	// Copy the structs as we need to change them but do not want to alter outside world.
 	var copyProvisioning = &models.DashboardProvisioning{}
 	*copyProvisioning = *provisioning
@@ -406,7 +1456,16 @@ func (s *fakeDashboardProvisioningService) SaveProvisionedDashboard(dto *dashboa
 }
 
 func (s *fakeDashboardProvisioningService) SaveFolderForProvisionedDashboards(dto *dashboards.SaveDashboardDTO) (*models.Dashboard, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if dto.Dashboard.Id == 0 {
+		dto.Dashboard.Id = rand.Int63n(1000000) + 1
+	}
+	dto.Dashboard.OrgId = dto.OrgId
 	s.inserted = append(s.inserted, dto)
+	// so the folder is found when looked up again
+	s.getDashboard = append(s.getDashboard, dto.Dashboard)
 	return dto.Dashboard, nil
 }
 
@@ -439,9 +1498,54 @@ func (s *fakeDashboardProvisioningService) GetProvisionedDashboardDataByDashboar
 	return nil, nil
 }
 
+type fakeRenderer struct {
+	mu         sync.Mutex
+	err        error
+	failTitles map[string]bool
+	rendered   []rendering.Opts
+	// release blocks renders until it is closed when set.
+	release chan struct{}
+}
+
+func (r *fakeRenderer) Render(ctx context.Context, opts rendering.Opts) (*rendering.RenderResult, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.release != nil {
+		<-r.release
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rendered = append(r.rendered, opts)
+	for title := range r.failTitles {
+		if strings.Contains(opts.Path, "/"+models.SlugifyTitle(title)+"?") {
+			return nil, errors.New("panel plugin not found")
+		}
+	}
+	return &rendering.RenderResult{FilePath: "dashboard.png"}, nil
+}
+
 func mockGetDashboardQuery(cmd *models.GetDashboardQuery) error {
+	fakeService.mu.Lock()
+	defer fakeService.mu.Unlock()
+
+	if cmd.Id != 0 {
+		for _, dto := range fakeService.inserted {
+			if dto.Dashboard.Id == cmd.Id {
+				cmd.Result = dto.Dashboard
+				return nil
+			}
+		}
+		return models.ErrDashboardNotFound
+	}
+
 	for _, d := range fakeService.getDashboard {
-		if d.Slug == cmd.Slug {
+		if cmd.Uid != "" && d.Uid != cmd.Uid {
+			continue
+		}
+		if (cmd.Uid != "" || d.Slug == cmd.Slug) && (d.OrgId == 0 || d.OrgId == cmd.OrgId) {
 			cmd.Result = d
 			return nil
 		}
@@ -449,3 +1553,32 @@ func mockGetDashboardQuery(cmd *models.GetDashboardQuery) error {
 
 	return models.ErrDashboardNotFound
 }
+
+func TestSortedDashboardPaths(t *testing.T) {
+	Convey("Sorting dashboard files", t, func() {
+		root := filepath.FromSlash("/var/lib/grafana/dashboards")
+		files := map[string]os.FileInfo{}
+		// the map is filled in an order differing from the expected one, map iteration is random anyway
+		for _, path := range []string{"team/b.json", "a.json", "team.json", "team/a/z.json", "a-b.json"} {
+			files[filepath.Join(root, filepath.FromSlash(path))] = nil
+		}
+
+		var relative []string
+		for _, path := range sortedDashboardPaths([]string{root}, files) {
+			rel, err := filepath.Rel(root, path)
+			So(err, ShouldBeNil)
+			relative = append(relative, filepath.ToSlash(rel))
+		}
+
+		So(relative, ShouldResemble, []string{"a-b.json", "a.json", "team.json", "team/a/z.json", "team/b.json"})
+
+		Convey("should sort files of several paths by the order of the paths first", func() {
+			other := filepath.FromSlash("/srv/dashboards")
+			files[filepath.Join(other, "0.json")] = nil
+
+			sorted := sortedDashboardPaths([]string{other, root}, files)
+			So(sorted[0], ShouldEqual, filepath.Join(other, "0.json"))
+			So(sorted[1], ShouldEqual, filepath.Join(root, "a-b.json"))
+		})
+	})
+}
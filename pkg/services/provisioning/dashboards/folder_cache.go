@@ -0,0 +1,59 @@
+package dashboards
+
+import (
+	"sync"
+
+	"github.com/grafana/grafana/pkg/services/dashboards"
+)
+
+// folderCache resolves every folder at most once per scan, however many dashboards go into it. A new cache is used
+// for every scan so folders deleted in between are created again. Files are read by several workers, so the first
+// lookup of a folder is shared by all workers asking for it and a folder is never created twice.
+type folderCache struct {
+	service dashboards.DashboardProvisioningService
+
+	mu      sync.Mutex
+	lookups map[folderKey]*folderLookup
+}
+
+// folderKey identifies a folder by uid if it has one and by title otherwise.
+type folderKey struct {
+	orgId int64
+	uid   string
+	title string
+}
+
+// folderLookup is the single lookup of a folder during a scan.
+type folderLookup struct {
+	once sync.Once
+	id   int64
+	err  error
+}
+
+func newFolderCache(service dashboards.DashboardProvisioningService) *folderCache {
+	return &folderCache{
+		service: service,
+		lookups: map[folderKey]*folderLookup{},
+	}
+}
+
+// getOrCreateFolder returns the id of the folder like getOrCreateFolder, looking it up only the first time.
+func (c *folderCache) getOrCreateFolder(cfg *DashboardsAsConfig, title string, uid string, tags []string) (int64, error) {
+	key := folderKey{orgId: cfg.OrgId, uid: uid}
+	if uid == "" {
+		key.title = title
+	}
+
+	c.mu.Lock()
+	lookup, ok := c.lookups[key]
+	if !ok {
+		lookup = &folderLookup{}
+		c.lookups[key] = lookup
+	}
+	c.mu.Unlock()
+
+	lookup.once.Do(func() {
+		lookup.id, lookup.err = getOrCreateFolder(cfg, c.service, title, uid, tags)
+	})
+	return lookup.id, lookup.err
+}
@@ -0,0 +1,92 @@
+package dashboards
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFolderCache(t *testing.T) {
+	Convey("Folder cache", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		folderTitles := func() []string {
+			var titles []string
+			for _, dto := range fakeService.inserted {
+				if dto.Dashboard.IsFolder {
+					titles = append(titles, dto.Dashboard.Title)
+				}
+			}
+			return titles
+		}
+
+		Convey("should look up a folder once per org", func() {
+			cache := newFolderCache(fakeService)
+			cfg := &DashboardsAsConfig{Name: "Default", OrgId: 1}
+			otherOrgCfg := &DashboardsAsConfig{Name: "Default", OrgId: 2}
+
+			first, err := cache.getOrCreateFolder(cfg, "Team", "", nil)
+			So(err, ShouldBeNil)
+			second, err := cache.getOrCreateFolder(cfg, "Team", "", nil)
+			So(err, ShouldBeNil)
+			So(second, ShouldEqual, first)
+
+			_, err = cache.getOrCreateFolder(otherOrgCfg, "Team", "", nil)
+			So(err, ShouldBeNil)
+			So(folderTitles(), ShouldResemble, []string{"Team", "Team"})
+		})
+
+		Convey("Scanning many dashboards of one folder", func() {
+			dir, err := ioutil.TempDir("", "provisioning-folders")
+			So(err, ShouldBeNil)
+			defer os.RemoveAll(dir)
+			So(os.Mkdir(filepath.Join(dir, "team"), 0750), ShouldBeNil)
+			for i := 0; i < 50; i++ {
+				content := fmt.Sprintf(`{"title": "Dashboard %d"}`, i)
+				err := ioutil.WriteFile(filepath.Join(dir, "team", fmt.Sprintf("dashboard%d.json", i)), []byte(content), 0644)
+				So(err, ShouldBeNil)
+			}
+
+			cfg := &DashboardsAsConfig{
+				Name:   "Default",
+				Type:   "file",
+				OrgId:  1,
+				Folder: "Provisioned",
+				Options: map[string]interface{}{
+					"path":                      dir,
+					"foldersFromFilesStructure": true,
+					"concurrency":               8,
+				},
+			}
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			Convey("should create the folder once", func() {
+				So(folderTitles(), ShouldResemble, []string{"Provisioned", "team"})
+			})
+
+			Convey("should create folders deleted since the last scan again", func() {
+				fakeService.getDashboard = nil
+				fakeService.inserted = nil
+
+				So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+				So(folderTitles(), ShouldResemble, []string{"Provisioned", "team"})
+			})
+		})
+	})
+}
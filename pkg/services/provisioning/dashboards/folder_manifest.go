@@ -0,0 +1,59 @@
+package dashboards
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// folderManifestName is the name, without extension, of the optional file in a directory describing the folder its
+// dashboards go into with the foldersFromFilesStructure option.
+const folderManifestName = "__folder"
+
+// folderManifestExtensions are the extensions a folder manifest can have. Json is valid yaml so both are read the
+// same way.
+var folderManifestExtensions = []string{".json", ".yaml", ".yml"}
+
+// folderManifest sets the title, uid and tags of the folder of a directory. The uid and tags are only applied when
+// the folder is created.
+type folderManifest struct {
+	Title string   `yaml:"title"`
+	Uid   string   `yaml:"uid"`
+	Tags  []string `yaml:"tags"`
+}
+
+func isFolderManifest(name string) bool {
+	ext := filepath.Ext(name)
+	if strings.TrimSuffix(name, ext) != folderManifestName {
+		return false
+	}
+
+	for _, manifestExt := range folderManifestExtensions {
+		if ext == manifestExt {
+			return true
+		}
+	}
+	return false
+}
+
+// readFolderManifest reads the folder manifest of the directory. Returns nil if the directory has none.
+func readFolderManifest(fsys fileSystem, dir string) (*folderManifest, error) {
+	for _, ext := range folderManifestExtensions {
+		content, err := fsys.ReadFile(filepath.Join(dir, folderManifestName+ext))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		manifest := &folderManifest{}
+		if err := yaml.Unmarshal(content, manifest); err != nil {
+			return nil, err
+		}
+		return manifest, nil
+	}
+	return nil, nil
+}
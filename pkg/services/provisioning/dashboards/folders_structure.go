@@ -0,0 +1,88 @@
+package dashboards
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+// filesStructureFolders resolves the folders of dashboards for the foldersFromFilesStructure option. Grafana folders
+// can't be nested, so a dashboard in a subdirectory goes into a folder titled with the path of the directory relative
+// to the provider path, like infra/network. Dashboards directly in the provider path use the folder of the provider.
+// Directories with the same path relative to different paths of a provider share their folder. A folder manifest in
+// a directory overrides the folder of its dashboards. Folders are looked up through the folder cache of the scan, so
+// existing folders are reused and each is only looked up once.
+type filesStructureFolders struct {
+	reader       *fileReader
+	roots        []string
+	rootFolderId int64
+
+	mu        sync.Mutex
+	manifests map[string]*folderManifest
+}
+
+func newFilesStructureFolders(reader *fileReader, roots []string, rootFolderId int64) *filesStructureFolders {
+	return &filesStructureFolders{
+		reader:       reader,
+		roots:        roots,
+		rootFolderId: rootFolderId,
+		manifests:    map[string]*folderManifest{},
+	}
+}
+
+// dashboardFolder is the folder a dashboard file goes into. The title is used by the options applying to folders.
+type dashboardFolder struct {
+	id    int64
+	title string
+}
+
+// folderForFile returns the folder the dashboard file at path goes into.
+func (f *filesStructureFolders) folderForFile(path string) (dashboardFolder, error) {
+	dir := filepath.Dir(path)
+	rel, err := filepath.Rel(rootOf(f.roots, path), dir)
+	if err != nil {
+		return dashboardFolder{}, err
+	}
+
+	manifest, err := f.folderManifest(dir)
+	if err != nil {
+		return dashboardFolder{}, err
+	}
+
+	root := dashboardFolder{id: f.rootFolderId, title: f.reader.Cfg.Folder}
+	title := filepath.ToSlash(rel)
+	if manifest == nil {
+		if title == "." {
+			return root, nil
+		}
+		id, err := f.reader.folders.getOrCreateFolder(f.reader.Cfg, title, "", nil)
+		return dashboardFolder{id: id, title: title}, err
+	}
+
+	if manifest.Title != "" {
+		title = manifest.Title
+	} else if title == "." {
+		title = f.reader.Cfg.Folder
+	}
+	if title == "" && manifest.Uid == "" {
+		return root, nil
+	}
+	id, err := f.reader.folders.getOrCreateFolder(f.reader.Cfg, title, manifest.Uid, manifest.Tags)
+	return dashboardFolder{id: id, title: title}, err
+}
+
+// folderManifest returns the folder manifest of the directory, reading it once per scan.
+func (f *filesStructureFolders) folderManifest(dir string) (*folderManifest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if manifest, ok := f.manifests[dir]; ok {
+		return manifest, nil
+	}
+
+	manifest, err := readFolderManifest(f.reader.fs, dir)
+	if err != nil {
+		return nil, err
+	}
+	f.manifests[dir] = manifest
+	return manifest, nil
+}
@@ -0,0 +1,111 @@
+package dashboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// dashboardFileFormat describes a file format dashboards can be provisioned from.
+type dashboardFileFormat struct {
+	name       string
+	extensions []string
+	parse      func(content []byte) (*simplejson.Json, error)
+	// imports is set for formats whose files can import other files. Their dashboards can change without the file
+	// changing, so the modification time of the file is not used to skip them.
+	imports bool
+}
+
+const jsonnetFormat = "jsonnet"
+
+var dashboardFileFormats = []*dashboardFileFormat{
+	{name: "json", extensions: []string{".json"}, parse: simplejson.NewJson},
+	{name: "yaml", extensions: []string{".yaml", ".yml"}, parse: parseYamlDashboard},
+	// jsonnet files are evaluated to json before parsing, see evaluateJsonnet
+	{name: jsonnetFormat, extensions: []string{".jsonnet"}, parse: simplejson.NewJson, imports: true},
+}
+
+// defaultDashboardFileFormats are used when the formats option is not set.
+var defaultDashboardFileFormats = []string{"json", "yaml"}
+
+// getDashboardFileFormats returns the file formats with the given names.
+func getDashboardFileFormats(names []string) ([]*dashboardFileFormat, error) {
+	var formats []*dashboardFileFormat
+	for _, name := range names {
+		format := findDashboardFileFormat(name)
+		if format == nil {
+			return nil, fmt.Errorf("unsupported dashboard file format %q", name)
+		}
+		formats = append(formats, format)
+	}
+	return formats, nil
+}
+
+func findDashboardFileFormat(name string) *dashboardFileFormat {
+	for _, format := range dashboardFileFormats {
+		if format.name == strings.ToLower(name) {
+			return format
+		}
+	}
+	return nil
+}
+
+// formatForFile returns the format the file should be parsed with or nil if it has none of the extensions. Gzip
+// compressed files have the format of the name without the .gz extension, except for formats importing other files.
+func formatForFile(formats []*dashboardFileFormat, name string) *dashboardFileFormat {
+	if isGzipFile(name) {
+		format := formatForFile(formats, strings.TrimSuffix(name, gzipExtension))
+		if format == nil || format.imports {
+			return nil
+		}
+		return format
+	}
+
+	for _, format := range formats {
+		for _, ext := range format.extensions {
+			if strings.HasSuffix(name, ext) {
+				return format
+			}
+		}
+	}
+	return nil
+}
+
+// parseYamlDashboard parses a dashboard written in yaml into the same json structure a json file would produce.
+func parseYamlDashboard(content []byte) (*simplejson.Json, error) {
+	var parsed interface{}
+	if err := yaml.Unmarshal(content, &parsed); err != nil {
+		return nil, err
+	}
+
+	// round trip through json so numbers end up as json.Number like for json dashboards
+	encoded, err := json.Marshal(convertYamlValue(parsed))
+	if err != nil {
+		return nil, err
+	}
+	return simplejson.NewJson(encoded)
+}
+
+// convertYamlValue converts the maps with interface{} keys produced by the yaml decoder into maps with string keys
+// that can be encoded as json.
+func convertYamlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = convertYamlValue(item)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = convertYamlValue(item)
+		}
+		return converted
+	default:
+		return v
+	}
+}
@@ -0,0 +1,39 @@
+package dashboards
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDashboardFileFormats(t *testing.T) {
+	Convey("Dashboard file formats", t, func() {
+		Convey("Yaml dashboard should parse to the same structure as json", func() {
+			data, err := parseYamlDashboard([]byte(`
+title: Requests
+schemaVersion: 16
+panels:
+  - id: 1
+    gridPos: {x: 0, y: 0, w: 12, h: 8}
+`))
+			So(err, ShouldBeNil)
+			So(data.Get("title").MustString(), ShouldEqual, "Requests")
+			So(data.Get("schemaVersion").MustInt(), ShouldEqual, 16)
+			So(data.Get("panels").GetIndex(0).GetPath("gridPos", "w").MustInt(), ShouldEqual, 12)
+		})
+
+		Convey("Broken yaml should return error", func() {
+			_, err := parseYamlDashboard([]byte("title: [broken"))
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Should pick format by file extension", func() {
+			formats, err := getDashboardFileFormats([]string{"json", "yaml"})
+			So(err, ShouldBeNil)
+
+			So(formatForFile(formats, "dash.json").name, ShouldEqual, "json")
+			So(formatForFile(formats, "dash.yml").name, ShouldEqual, "yaml")
+			So(formatForFile(formats, "dash.txt"), ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,167 @@
+package dashboards
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// fileSystem is the file system the file reader reads dashboard files from. Readers use the file system of the OS,
+// tests can use one in memory. It covers what the reader needs of the fs.FS interfaces of newer Go versions, which
+// can't be used while Grafana builds with Go 1.12.
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	Lstat(name string) (os.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	// ReadDir returns the entries of the directory sorted by name, without following symlinks.
+	ReadDir(name string) ([]dirEntry, error)
+	EvalSymlinks(name string) (string, error)
+}
+
+// dirEntry is an entry read from a directory, like fs.DirEntry of newer Go versions. Its name is known without
+// calling stat, so entries skipped by name don't have to be stat'ed.
+type dirEntry interface {
+	Name() string
+	IsDir() bool
+	// Type returns the type bits of the file mode of the entry.
+	Type() os.FileMode
+	// Info returns the os.FileInfo of the entry, which may have to stat it. Symlinks are not followed.
+	Info() (os.FileInfo, error)
+}
+
+// osFileSystem is the file system of the OS.
+type osFileSystem struct{}
+
+func (osFileSystem) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFileSystem) Lstat(name string) (os.FileInfo, error) {
+	return os.Lstat(name)
+}
+
+func (osFileSystem) ReadFile(name string) ([]byte, error) {
+	return ioutil.ReadFile(name)
+}
+
+func (osFileSystem) ReadDir(name string) ([]dirEntry, error) {
+	dir, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	names, err := dir.Readdirnames(-1)
+	dir.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(names)
+	entries := make([]dirEntry, len(names))
+	for i, entryName := range names {
+		entries[i] = &osDirEntry{dir: name, name: entryName}
+	}
+	return entries, nil
+}
+
+func (osFileSystem) EvalSymlinks(name string) (string, error) {
+	return filepath.EvalSymlinks(name)
+}
+
+// fileInfoEntry is the dirEntry of a file that was already stat'ed.
+type fileInfoEntry struct {
+	os.FileInfo
+}
+
+func (e fileInfoEntry) Type() os.FileMode {
+	return e.Mode() & os.ModeType
+}
+
+func (e fileInfoEntry) Info() (os.FileInfo, error) {
+	return e.FileInfo, nil
+}
+
+// walkDirFunc is called by walkDir for every file and directory. It is called a second time for a directory that
+// can't be read, with the error. Returning filepath.SkipDir skips the directory, or the remaining files of the
+// directory when returned for a file.
+type walkDirFunc func(path string, entry dirEntry, err error) error
+
+// walkDir walks the file tree rooted at root in fsys like fs.WalkDir of newer Go versions. Unlike filepath.Walk it
+// doesn't stat every entry, walkFn calls Info for the entries it needs the os.FileInfo of.
+func walkDir(fsys fileSystem, root string, walkFn walkDirFunc) error {
+	fileInfo, err := fsys.Lstat(root)
+	if err != nil {
+		err = walkFn(root, nil, err)
+	} else {
+		err = walkDirEntry(fsys, root, fileInfoEntry{FileInfo: fileInfo}, walkFn)
+	}
+
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+func walkDirEntry(fsys fileSystem, path string, entry dirEntry, walkFn walkDirFunc) error {
+	if err := walkFn(path, entry, nil); err != nil || !entry.IsDir() {
+		if err == filepath.SkipDir && entry.IsDir() {
+			return nil
+		}
+		return err
+	}
+
+	entries, err := fsys.ReadDir(path)
+	if err != nil {
+		if err = walkFn(path, entry, err); err != nil {
+			if err == filepath.SkipDir {
+				return nil
+			}
+			return err
+		}
+	}
+
+	for _, child := range entries {
+		if err := walkDirEntry(fsys, filepath.Join(path, child.Name()), child, walkFn); err != nil {
+			if err == filepath.SkipDir {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// osDirEntry is the dirEntry of a file of the OS file system. The file is stat'ed once its type or os.FileInfo is
+// needed, the result is kept for the later calls.
+type osDirEntry struct {
+	dir  string
+	name string
+
+	once sync.Once
+	info os.FileInfo
+	err  error
+}
+
+func (e *osDirEntry) Name() string {
+	return e.name
+}
+
+func (e *osDirEntry) IsDir() bool {
+	return e.Type().IsDir()
+}
+
+func (e *osDirEntry) Type() os.FileMode {
+	info, err := e.Info()
+	if err != nil {
+		return 0
+	}
+	return info.Mode() & os.ModeType
+}
+
+func (e *osDirEntry) Info() (os.FileInfo, error) {
+	e.once.Do(func() {
+		e.info, e.err = os.Lstat(filepath.Join(e.dir, e.name))
+	})
+	return e.info, e.err
+}
@@ -0,0 +1,307 @@
+package dashboards
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memFileSystem is a file system in memory holding files by path. Directories exist implicitly for the files in
+// them, symlinks are not supported.
+type memFileSystem struct {
+	files   map[string]string
+	modTime time.Time
+}
+
+func newMemFileSystem(files map[string]string) *memFileSystem {
+	return &memFileSystem{files: files, modTime: time.Now().Add(-time.Hour)}
+}
+
+type memFileInfo struct {
+	name    string
+	size    int64
+	dir     bool
+	modTime time.Time
+}
+
+func (fi memFileInfo) Name() string       { return fi.name }
+func (fi memFileInfo) Size() int64        { return fi.size }
+func (fi memFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi memFileInfo) IsDir() bool        { return fi.dir }
+func (fi memFileInfo) Sys() interface{}   { return nil }
+
+func (fi memFileInfo) Mode() os.FileMode {
+	if fi.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+func (fsys *memFileSystem) isDir(name string) bool {
+	for path := range fsys.files {
+		if strings.HasPrefix(path, name+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (fsys *memFileSystem) Stat(name string) (os.FileInfo, error) {
+	name = filepath.ToSlash(name)
+	if content, ok := fsys.files[name]; ok {
+		return memFileInfo{name: filepath.Base(name), size: int64(len(content)), modTime: fsys.modTime}, nil
+	}
+	if fsys.isDir(name) {
+		return memFileInfo{name: filepath.Base(name), dir: true, modTime: fsys.modTime}, nil
+	}
+	return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+}
+
+func (fsys *memFileSystem) Lstat(name string) (os.FileInfo, error) {
+	return fsys.Stat(name)
+}
+
+func (fsys *memFileSystem) ReadFile(name string) ([]byte, error) {
+	content, ok := fsys.files[filepath.ToSlash(name)]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return []byte(content), nil
+}
+
+func (fsys *memFileSystem) ReadDir(name string) ([]dirEntry, error) {
+	name = filepath.ToSlash(name)
+	seen := map[string]bool{}
+	var entries []os.FileInfo
+	for path := range fsys.files {
+		if !strings.HasPrefix(path, name+"/") {
+			continue
+		}
+		child := strings.SplitN(strings.TrimPrefix(path, name+"/"), "/", 2)[0]
+		if seen[child] {
+			continue
+		}
+		seen[child] = true
+		fileInfo, err := fsys.Stat(name + "/" + child)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, fileInfo)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return fileInfoEntries(entries), nil
+}
+
+func (fsys *memFileSystem) EvalSymlinks(name string) (string, error) {
+	if _, err := fsys.Stat(name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+func TestFileSystem(t *testing.T) {
+	Convey("Reading dashboards from a file system in memory", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		fsys := newMemFileSystem(map[string]string{
+			"/dashboards/nodes.json":          `{"title": "Nodes"}`,
+			"/dashboards/team/pods.yaml":      "title: Pods",
+			"/dashboards/.hidden/secret.json": `{"title": "Secret"}`,
+			"/dashboards/README.md":           "not a dashboard",
+		})
+		cfg := &DashboardsAsConfig{
+			Name:    "Default",
+			Type:    "file",
+			OrgId:   1,
+			Folder:  "Default",
+			Options: map[string]interface{}{"path": "/dashboards", "foldersFromFilesStructure": true},
+		}
+		reader, err := newDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{}, fsys)
+		So(err, ShouldBeNil)
+		So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+		titles := func() []string {
+			var titles []string
+			for _, dto := range fakeService.inserted {
+				if !dto.Dashboard.IsFolder {
+					titles = append(titles, dto.Dashboard.Title)
+				}
+			}
+			sort.Strings(titles)
+			return titles
+		}
+
+		Convey("should provision the dashboard files", func() {
+			So(titles(), ShouldResemble, []string{"Nodes", "Pods"})
+		})
+
+		Convey("should remove dashboards of deleted files", func() {
+			delete(fsys.files, "/dashboards/team/pods.yaml")
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(titles(), ShouldResemble, []string{"Nodes"})
+		})
+	})
+
+	Convey("Walking a file system", t, func() {
+		fsys := newMemFileSystem(map[string]string{
+			"/root/a.json":       "",
+			"/root/b/c.json":     "",
+			"/root/b/d/e.json":   "",
+			"/root/skipped/f.js": "",
+		})
+
+		var walked []string
+		err := walkDir(fsys, "/root", func(path string, entry dirEntry, err error) error {
+			So(err, ShouldBeNil)
+			walked = append(walked, filepath.ToSlash(path))
+			if entry.Name() == "skipped" {
+				return filepath.SkipDir
+			}
+			return nil
+		})
+		So(err, ShouldBeNil)
+		So(walked, ShouldResemble, []string{"/root", "/root/a.json", "/root/b", "/root/b/c.json", "/root/b/d", "/root/b/d/e.json", "/root/skipped"})
+	})
+}
+
+// statCountingFileSystem is the OS file system counting the calls that stat a file.
+// fileInfoEntries returns the entries of files that were already stat'ed.
+func fileInfoEntries(infos []os.FileInfo) []dirEntry {
+	entries := make([]dirEntry, len(infos))
+	for i, info := range infos {
+		entries[i] = fileInfoEntry{FileInfo: info}
+	}
+	return entries
+}
+
+type statCountingFileSystem struct {
+	osFileSystem
+	stats int
+}
+
+type statCountingDirEntry struct {
+	dirEntry
+	fsys *statCountingFileSystem
+}
+
+func (e statCountingDirEntry) Info() (os.FileInfo, error) {
+	e.fsys.stats++
+	return e.dirEntry.Info()
+}
+
+func (fsys *statCountingFileSystem) Stat(name string) (os.FileInfo, error) {
+	fsys.stats++
+	return fsys.osFileSystem.Stat(name)
+}
+
+func (fsys *statCountingFileSystem) Lstat(name string) (os.FileInfo, error) {
+	fsys.stats++
+	return fsys.osFileSystem.Lstat(name)
+}
+
+func (fsys *statCountingFileSystem) ReadDir(name string) ([]dirEntry, error) {
+	entries, err := fsys.osFileSystem.ReadDir(name)
+	for i, entry := range entries {
+		entries[i] = statCountingDirEntry{dirEntry: entry, fsys: fsys}
+	}
+	return entries, err
+}
+
+// writeDashboardTree writes dirs directories of files files each to root, every tenth file being a dashboard.
+func writeDashboardTree(root string, dirs int, files int) (int, error) {
+	dashboards := 0
+	for d := 0; d < dirs; d++ {
+		dir := filepath.Join(root, fmt.Sprintf("dir%d", d))
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return 0, err
+		}
+		for f := 0; f < files; f++ {
+			name := fmt.Sprintf("file%d.txt", f)
+			if f%10 == 0 {
+				name = fmt.Sprintf("dashboard%d.json", f)
+				dashboards++
+			}
+			if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("{}"), 0644); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return dashboards, nil
+}
+
+func TestWalkStats(t *testing.T) {
+	Convey("Walking the OS file system", t, func() {
+		dir, err := ioutil.TempDir("", "provisioning-walk")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		dashboards, err := writeDashboardTree(dir, 3, 20)
+		So(err, ShouldBeNil)
+
+		fsys := &statCountingFileSystem{}
+		filesOnDisk := map[string]os.FileInfo{}
+		opts := walkOptions{fs: fsys, root: dir, formats: dashboardFileFormats, log: log.New("test-logger")}
+		So(walkDir(fsys, dir, createWalkFn(filesOnDisk, opts)), ShouldBeNil)
+
+		Convey("should only read the file info of the root and the dashboard files", func() {
+			So(len(filesOnDisk), ShouldEqual, dashboards)
+			So(fsys.stats, ShouldEqual, dashboards+1)
+		})
+	})
+}
+
+func BenchmarkWalkDashboardFiles(b *testing.B) {
+	dir, err := ioutil.TempDir("", "provisioning-walk")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if _, err := writeDashboardTree(dir, 50, 200); err != nil {
+		b.Fatal(err)
+	}
+	opts := walkOptions{root: dir, formats: dashboardFileFormats, log: log.New("test-logger")}
+
+	b.Run("filepath.Walk", func(b *testing.B) {
+		opts.fs = osFileSystem{}
+		for i := 0; i < b.N; i++ {
+			walkFn := createWalkFn(map[string]os.FileInfo{}, opts)
+			err := filepath.Walk(dir, func(path string, fileInfo os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				return walkFn(path, fileInfoEntry{FileInfo: fileInfo}, nil)
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("walkDir", func(b *testing.B) {
+		fsys := &statCountingFileSystem{}
+		opts.fs = fsys
+		for i := 0; i < b.N; i++ {
+			if err := walkDir(fsys, dir, createWalkFn(map[string]os.FileInfo{}, opts)); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.Logf("%d stat calls per walk of %d files, filepath.Walk makes one per file and directory", fsys.stats/b.N, 50*200)
+	})
+}
@@ -0,0 +1,28 @@
+package dashboards
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+)
+
+// maxUidLength is the longest uid the dashboard service accepts.
+const maxUidLength = 40
+
+// generateUid returns the uid of a dashboard without uid for the generateUidFromPath option. It's a hash of the
+// provider name and the path of the file relative to the provider path, so it stays the same across restarts and
+// instances but differs between providers and files.
+func generateUid(provider string, relativePath string) string {
+	sum := sha256.Sum256([]byte(provider + "\x00" + filepath.ToSlash(relativePath)))
+	return hex.EncodeToString(sum[:])[:maxUidLength]
+}
+
+// generatedUid returns the uid generated for the dashboard file at path.
+func (fr *fileReader) generatedUid(path string) string {
+	root := rootOf(fr.resolvedPaths(), path)
+	relativePath, err := filepath.Rel(root, path)
+	if err != nil {
+		relativePath = path
+	}
+	return generateUid(fr.Cfg.Name, relativePath)
+}
@@ -0,0 +1,81 @@
+package dashboards
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/util"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGenerateUid(t *testing.T) {
+	Convey("Generating uids", t, func() {
+		uid := generateUid("Default", "team/cpu.json")
+
+		Convey("should return a valid uid of the maximum length", func() {
+			So(len(uid), ShouldEqual, maxUidLength)
+			So(util.IsValidShortUID(uid), ShouldBeTrue)
+		})
+
+		Convey("should return the same uid for the same provider and path", func() {
+			So(generateUid("Default", "team/cpu.json"), ShouldEqual, uid)
+			So(generateUid("Default", filepath.Join("team", "cpu.json")), ShouldEqual, uid)
+		})
+
+		Convey("should return different uids for other providers and paths", func() {
+			So(generateUid("Other", "team/cpu.json"), ShouldNotEqual, uid)
+			So(generateUid("Default", "team/memory.json"), ShouldNotEqual, uid)
+		})
+	})
+
+	Convey("Reading dashboards with generateUidFromPath", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		dir, err := ioutil.TempDir("", "provisioning-uids")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		dir, err = filepath.EvalSymlinks(dir)
+		So(err, ShouldBeNil)
+		So(os.Mkdir(filepath.Join(dir, "team"), 0750), ShouldBeNil)
+		So(ioutil.WriteFile(filepath.Join(dir, "team", "cpu.json"), []byte(`{"title": "CPU"}`), 0644), ShouldBeNil)
+		So(ioutil.WriteFile(filepath.Join(dir, "memory.json"), []byte(`{"uid": "memory", "title": "Memory"}`), 0644), ShouldBeNil)
+
+		cfg := &DashboardsAsConfig{
+			Name:  "Default",
+			Type:  "file",
+			OrgId: 1,
+			Options: map[string]interface{}{
+				"path":                dir,
+				"generateUidFromPath": true,
+			},
+		}
+		reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+		So(err, ShouldBeNil)
+		So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+		uids := map[string]string{}
+		for _, dto := range fakeService.inserted {
+			uids[dto.Dashboard.Title] = dto.Dashboard.Uid
+		}
+
+		Convey("should generate the uid of dashboards without uid from the relative path", func() {
+			So(uids["CPU"], ShouldEqual, generateUid("Default", "team/cpu.json"))
+		})
+
+		Convey("should keep the uid of dashboards with uid", func() {
+			So(uids["Memory"], ShouldEqual, "memory")
+		})
+	})
+}
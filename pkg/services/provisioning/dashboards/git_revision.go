@@ -0,0 +1,100 @@
+package dashboards
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// shortRevisionLength is the length of the abbreviated commit sha used in revision tags.
+const shortRevisionLength = 7
+
+// readGitRevision returns the sha of the commit checked out in the git work tree containing path. It reads the
+// repository files directly so no git binary is needed.
+func readGitRevision(path string) (string, error) {
+	gitDir, err := findGitDir(path)
+	if err != nil {
+		return "", err
+	}
+
+	head, err := ioutil.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return "", err
+	}
+
+	ref := strings.TrimSpace(string(head))
+	if !strings.HasPrefix(ref, "ref: ") {
+		// detached head
+		return ref, nil
+	}
+
+	return resolveGitRef(gitDir, strings.TrimPrefix(ref, "ref: "))
+}
+
+// findGitDir looks for the .git directory in path and its parents. Work trees and submodules use a .git file
+// pointing to the actual directory.
+func findGitDir(path string) (string, error) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		candidate := filepath.Join(dir, ".git")
+		if fi, err := os.Stat(candidate); err == nil {
+			if fi.IsDir() {
+				return candidate, nil
+			}
+
+			content, err := ioutil.ReadFile(candidate)
+			if err != nil {
+				return "", err
+			}
+			gitDir := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(content)), "gitdir:"))
+			if !filepath.IsAbs(gitDir) {
+				gitDir = filepath.Join(dir, gitDir)
+			}
+			return gitDir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("%s is not inside a git repository", path)
+		}
+		dir = parent
+	}
+}
+
+// resolveGitRef returns the sha a ref points to, looking at loose refs first and then at packed refs.
+func resolveGitRef(gitDir string, ref string) (string, error) {
+	if content, err := ioutil.ReadFile(filepath.Join(gitDir, filepath.FromSlash(ref))); err == nil {
+		return strings.TrimSpace(string(content)), nil
+	}
+
+	packed, err := os.Open(filepath.Join(gitDir, "packed-refs"))
+	if err != nil {
+		return "", fmt.Errorf("could not resolve git ref %s", ref)
+	}
+	defer packed.Close()
+
+	scanner := bufio.NewScanner(packed)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == ref {
+			return fields[0], nil
+		}
+	}
+
+	return "", fmt.Errorf("could not resolve git ref %s", ref)
+}
+
+// shortRevision abbreviates a commit sha.
+func shortRevision(sha string) string {
+	if len(sha) > shortRevisionLength {
+		return sha[:shortRevisionLength]
+	}
+	return sha
+}
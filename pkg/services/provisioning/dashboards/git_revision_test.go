@@ -0,0 +1,61 @@
+package dashboards
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReadGitRevision(t *testing.T) {
+	Convey("Reading git revision", t, func() {
+		repo, err := ioutil.TempDir("", "provisioning-git")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(repo)
+
+		sha := "3f2a9c1d8e7b6a5f4e3d2c1b0a9f8e7d6c5b4a39"
+		dashboards := filepath.Join(repo, "dashboards", "team-a")
+		So(os.MkdirAll(dashboards, 0750), ShouldBeNil)
+		So(os.MkdirAll(filepath.Join(repo, ".git", "refs", "heads"), 0750), ShouldBeNil)
+		writeFile := func(name string, content string) {
+			So(ioutil.WriteFile(filepath.Join(repo, ".git", name), []byte(content), 0640), ShouldBeNil)
+		}
+
+		Convey("should resolve branch from loose ref", func() {
+			writeFile("HEAD", "ref: refs/heads/master\n")
+			writeFile(filepath.Join("refs", "heads", "master"), sha+"\n")
+
+			revision, err := readGitRevision(dashboards)
+			So(err, ShouldBeNil)
+			So(revision, ShouldEqual, sha)
+		})
+
+		Convey("should resolve branch from packed refs", func() {
+			writeFile("HEAD", "ref: refs/heads/master\n")
+			writeFile("packed-refs", "# pack-refs with: peeled fully-peeled sorted\n"+sha+" refs/heads/master\n")
+
+			revision, err := readGitRevision(dashboards)
+			So(err, ShouldBeNil)
+			So(revision, ShouldEqual, sha)
+		})
+
+		Convey("should resolve detached head", func() {
+			writeFile("HEAD", sha+"\n")
+
+			revision, err := readGitRevision(dashboards)
+			So(err, ShouldBeNil)
+			So(revision, ShouldEqual, sha)
+		})
+
+		Convey("should fail outside of a repository", func() {
+			outside, err := ioutil.TempDir("", "provisioning-no-git")
+			So(err, ShouldBeNil)
+			defer os.RemoveAll(outside)
+
+			_, err = readGitRevision(outside)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,165 @@
+package dashboards
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+const (
+	// gitSourceType is the type of providers provisioning dashboards from a git repository.
+	gitSourceType = "git"
+	// defaultGitBinary is looked up in the PATH unless the gitBinary option is set.
+	defaultGitBinary = "git"
+	// defaultGitUsername is sent with the token option unless the username option is set.
+	defaultGitUsername = "git"
+	// gitCommandTimeout limits how long a clone or fetch may take.
+	gitCommandTimeout = 5 * time.Minute
+)
+
+// gitSource keeps a shallow clone of a branch of a git repository in its directory and pulls it on every sync using
+// the git command, which has to be installed.
+type gitSource struct {
+	url          string
+	branch       string
+	subdirectory string
+	// authHeader is sent with http requests of git when the token option is set.
+	authHeader string
+	sshKey     string
+	binary     string
+	workDir    string
+	log        log.Logger
+}
+
+func newGitSource(cfg *DashboardsAsConfig, workDir string, log log.Logger) (*gitSource, error) {
+	url := getStringOption(cfg.Options, "url")
+	if url == "" {
+		return nil, fmt.Errorf("url of the git repository is missing")
+	}
+
+	subdirectory := getStringOption(cfg.Options, "subdirectory")
+	if subdirectory != "" {
+		if _, err := pathWithinDir(workDir, subdirectory); err != nil {
+			return nil, fmt.Errorf("subdirectory must be within the repository: %v", err)
+		}
+	}
+
+	s := &gitSource{
+		url:          url,
+		branch:       getStringOption(cfg.Options, "branch"),
+		subdirectory: subdirectory,
+		sshKey:       getStringOption(cfg.Options, "sshKeyPath"),
+		binary:       getStringOption(cfg.Options, "gitBinary"),
+		workDir:      workDir,
+		log:          log,
+	}
+	if s.binary == "" {
+		s.binary = defaultGitBinary
+	}
+	if token := getStringOption(cfg.Options, "token"); token != "" {
+		username := getStringOption(cfg.Options, "username")
+		if username == "" {
+			username = defaultGitUsername
+		}
+		s.authHeader = "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+token))
+	}
+	return s, nil
+}
+
+func (s *gitSource) dir() string {
+	if s.subdirectory == "" {
+		return s.workDir
+	}
+	dir, _ := pathWithinDir(s.workDir, s.subdirectory)
+	return dir
+}
+
+// sync clones the repository into a new directory the first time and fetches the latest commit of the branch
+// afterwards. A failed fetch keeps the last checkout.
+func (s *gitSource) sync() error {
+	if _, err := os.Stat(filepath.Join(s.workDir, ".git")); err != nil {
+		return s.clone()
+	}
+
+	ref := s.branch
+	if ref == "" {
+		ref = "HEAD"
+	}
+	// fetching from the url instead of the origin remote picks up changes of the url option
+	if err := s.git(s.workDir, "fetch", "--depth", "1", "--", s.url, ref); err != nil {
+		return err
+	}
+	return s.git(s.workDir, "reset", "--hard", "FETCH_HEAD")
+}
+
+func (s *gitSource) clone() error {
+	s.log.Debug("cloning dashboard repository", "url", s.url, "branch", s.branch, "dir", s.workDir)
+	tmp := s.workDir + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(tmp), 0750); err != nil {
+		return err
+	}
+
+	args := []string{"clone", "--depth", "1", "--single-branch"}
+	if s.branch != "" {
+		args = append(args, "--branch", s.branch)
+	}
+	args = append(args, "--", s.url, tmp)
+	if err := s.git("", args...); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+
+	if err := os.RemoveAll(s.workDir); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.workDir)
+}
+
+// git runs the git command with args in dir. Git never prompts for credentials, authentication has to be set up by
+// the options or the environment of Grafana.
+func (s *gitSource) git(dir string, args ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), gitCommandTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := s.command(ctx, dir, args...)
+	cmd.Stderr = &stderr
+
+	command := args[0]
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("git %s timed out after %v", command, gitCommandTimeout)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("git %s failed: %s", command, message)
+		}
+		return fmt.Errorf("git %s failed: %v", command, err)
+	}
+	return nil
+}
+
+// command returns the git command with args in dir. The auth header is passed as config through the environment, as
+// the command line can be read by other users of the host. Reading config from the environment needs git 2.31.
+func (s *gitSource) command(ctx context.Context, dir string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, s.binary, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if s.authHeader != "" {
+		cmd.Env = append(cmd.Env, "GIT_CONFIG_COUNT=1", "GIT_CONFIG_KEY_0=http.extraHeader", "GIT_CONFIG_VALUE_0="+s.authHeader)
+	}
+	if s.sshKey != "" {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("GIT_SSH_COMMAND=ssh -i %q -o IdentitiesOnly=yes", s.sshKey))
+	}
+	return cmd
+}
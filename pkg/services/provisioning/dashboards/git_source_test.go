@@ -0,0 +1,128 @@
+package dashboards
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGitSource(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	Convey("Dashboards from a git repository", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		dir, err := ioutil.TempDir("", "provisioning-git")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		opts := ReaderOptions{WorkDir: filepath.Join(dir, "work")}
+		repo := filepath.Join(dir, "repo")
+
+		git := func(args ...string) string {
+			args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)
+			cmd := exec.Command("git", args...)
+			cmd.Dir = repo
+			out, err := cmd.CombinedOutput()
+			So(err, ShouldBeNil)
+			return strings.TrimSpace(string(out))
+		}
+		commit := func(name string, content string) string {
+			path := filepath.Join(repo, filepath.FromSlash(name))
+			So(os.MkdirAll(filepath.Dir(path), 0750), ShouldBeNil)
+			So(ioutil.WriteFile(path, []byte(content), 0644), ShouldBeNil)
+			git("add", "-A")
+			git("commit", "-q", "-m", "update "+name)
+			return git("rev-parse", "HEAD")
+		}
+
+		So(os.MkdirAll(repo, 0750), ShouldBeNil)
+		git("init", "-q")
+		git("checkout", "-q", "-b", "main")
+		commit("README.md", "dashboards")
+		sha := commit("grafana/nodes.json", `{"title": "Nodes", "uid": "nodes"}`)
+
+		cfg := &DashboardsAsConfig{
+			Name:  "Repo",
+			Type:  "git",
+			OrgId: 1,
+			Options: map[string]interface{}{
+				"url":          "file://" + filepath.ToSlash(repo),
+				"branch":       "main",
+				"subdirectory": "grafana",
+			},
+		}
+		reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), opts)
+		So(err, ShouldBeNil)
+		So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+		Convey("should provision the dashboards of the subdirectory with the commit", func() {
+			So(len(fakeService.inserted), ShouldEqual, 1)
+			So(fakeService.inserted[0].Dashboard.Title, ShouldEqual, "Nodes")
+			So(fakeService.provisioned["Repo"][0].ExternalId, ShouldEqual, filepath.Join(reader.resolvedPath(), "nodes.json"))
+			So(fakeService.provisioned["Repo"][0].Revision, ShouldEqual, sha)
+			So(fakeService.inserted[0].Dashboard.Data.Get("tags").MustStringArray(), ShouldBeEmpty)
+		})
+
+		Convey("should pull new commits", func() {
+			sha := commit("grafana/pods.json", `{"title": "Pods", "uid": "pods"}`)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(len(fakeService.inserted), ShouldEqual, 2)
+			So(fakeService.inserted[1].Dashboard.Title, ShouldEqual, "Pods")
+			So(fakeService.provisioned["Repo"][1].Revision, ShouldEqual, sha)
+		})
+
+		Convey("should keep the last checkout when fetching fails", func() {
+			reader.source.(*gitSource).url = "file://" + filepath.ToSlash(filepath.Join(dir, "missing"))
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(len(fakeService.provisioned["Repo"]), ShouldEqual, 1)
+		})
+	})
+
+	Convey("Git source", t, func() {
+		Convey("should require a url", func() {
+			cfg := &DashboardsAsConfig{Name: "Repo", Type: "git", Options: map[string]interface{}{}}
+			_, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("should reject subdirectories outside of the repository", func() {
+			cfg := &DashboardsAsConfig{Name: "Repo", Type: "git", Options: map[string]interface{}{"url": "https://example.com/repo.git", "subdirectory": "../etc"}}
+			_, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("should send the token as basic auth", func() {
+			cfg := &DashboardsAsConfig{Name: "Repo", Type: "git", Options: map[string]interface{}{"url": "https://example.com/repo.git", "token": "secret"}}
+			source, err := newGitSource(cfg, "/work", log.New("test-logger"))
+			So(err, ShouldBeNil)
+			So(source.authHeader, ShouldEqual, "Authorization: Basic Z2l0OnNlY3JldA==")
+
+			cmd := source.command(context.Background(), "/work", "fetch", "origin")
+			So(cmd.Env, ShouldContain, "GIT_CONFIG_KEY_0=http.extraHeader")
+			So(cmd.Env, ShouldContain, "GIT_CONFIG_VALUE_0=Authorization: Basic Z2l0OnNlY3JldA==")
+			So(cmd.Env, ShouldContain, "GIT_CONFIG_COUNT=1")
+			for _, arg := range cmd.Args {
+				So(arg, ShouldNotContainSubstring, "Z2l0OnNlY3JldA==")
+			}
+		})
+	})
+}
@@ -0,0 +1,50 @@
+package dashboards
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// gzipExtension marks gzip compressed dashboard files, like dashboard.json.gz.
+const gzipExtension = ".gz"
+
+// isGzipFile returns true if the dashboard file at path is gzip compressed.
+func isGzipFile(path string) bool {
+	return strings.HasSuffix(path, gzipExtension)
+}
+
+// externalIdOfPath returns the external id of the dashboard in the file at path. Compressed files are tracked by the
+// path without the .gz extension, so compressing a dashboard file keeps its dashboard instead of provisioning it again.
+func externalIdOfPath(path string) string {
+	return strings.TrimSuffix(path, gzipExtension)
+}
+
+// gunzip decompresses the content of a gzip compressed file. A truncated or corrupt stream is an error.
+func gunzip(content []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return ioutil.ReadAll(reader)
+}
+
+// removeShadowedGzipFiles removes compressed files that exist next to the uncompressed file of the same name, like
+// dashboard.json.gz next to dashboard.json. Both would be provisioned as the same dashboard, the uncompressed one wins.
+func removeShadowedGzipFiles(filesFoundOnDisk map[string]os.FileInfo, log log.Logger) {
+	for path := range filesFoundOnDisk {
+		if !isGzipFile(path) {
+			continue
+		}
+		if _, ok := filesFoundOnDisk[externalIdOfPath(path)]; ok {
+			log.Warn("skipping compressed dashboard file, the uncompressed file exists too", "file", path)
+			delete(filesFoundOnDisk, path)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package dashboards
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGzipDashboards(t *testing.T) {
+	Convey("Gzip compressed dashboard files", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		dir, err := ioutil.TempDir("", "provisioning-gzip")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		dir, err = filepath.EvalSymlinks(dir)
+		So(err, ShouldBeNil)
+		path := filepath.Join(dir, "dashboard.json")
+
+		compress := func(content string) []byte {
+			var buf bytes.Buffer
+			writer := gzip.NewWriter(&buf)
+			_, err := writer.Write([]byte(content))
+			So(err, ShouldBeNil)
+			So(writer.Close(), ShouldBeNil)
+			return buf.Bytes()
+		}
+
+		provisionedIds := func() []string {
+			var ids []string
+			for _, p := range fakeService.provisioned["Default"] {
+				ids = append(ids, p.ExternalId)
+			}
+			return ids
+		}
+
+		cfg := &DashboardsAsConfig{
+			Name:    "Default",
+			Type:    "file",
+			OrgId:   1,
+			Options: map[string]interface{}{"path": dir},
+		}
+		reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+		So(err, ShouldBeNil)
+
+		dashboard := `{"title": "Compressed", "uid": "compressed"}`
+
+		Convey("should provision the decompressed dashboard by the path without .gz", func() {
+			So(ioutil.WriteFile(path+".gz", compress(dashboard), 0644), ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(len(fakeService.inserted), ShouldEqual, 1)
+			So(fakeService.inserted[0].Dashboard.Title, ShouldEqual, "Compressed")
+			So(provisionedIds(), ShouldResemble, []string{path})
+			So(fakeService.provisioned["Default"][0].SourcePath, ShouldEqual, path+".gz")
+		})
+
+		Convey("should keep the dashboard when its file is replaced by a compressed one", func() {
+			So(ioutil.WriteFile(path, []byte(dashboard), 0644), ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+			So(len(fakeService.inserted), ShouldEqual, 1)
+			id := fakeService.inserted[0].Dashboard.Id
+
+			So(os.Remove(path), ShouldBeNil)
+			So(ioutil.WriteFile(path+".gz", compress(dashboard), 0644), ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(len(fakeService.inserted), ShouldEqual, 1)
+			So(fakeService.inserted[0].Dashboard.Id, ShouldEqual, id)
+			So(provisionedIds(), ShouldResemble, []string{path})
+			So(fakeService.provisioned["Default"][0].SourcePath, ShouldEqual, path+".gz")
+		})
+
+		Convey("should prefer the uncompressed file if both exist", func() {
+			So(ioutil.WriteFile(path, []byte(`{"title": "Uncompressed", "uid": "compressed"}`), 0644), ShouldBeNil)
+			So(ioutil.WriteFile(path+".gz", compress(dashboard), 0644), ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(len(fakeService.inserted), ShouldEqual, 1)
+			So(fakeService.inserted[0].Dashboard.Title, ShouldEqual, "Uncompressed")
+		})
+
+		Convey("should skip a truncated stream like a broken dashboard", func() {
+			compressed := compress(dashboard)
+			So(ioutil.WriteFile(path+".gz", compressed[:len(compressed)/2], 0644), ShouldBeNil)
+			So(ioutil.WriteFile(filepath.Join(dir, "other.json"), []byte(`{"title": "Other"}`), 0644), ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(len(fakeService.inserted), ShouldEqual, 1)
+			So(fakeService.inserted[0].Dashboard.Title, ShouldEqual, "Other")
+		})
+	})
+}
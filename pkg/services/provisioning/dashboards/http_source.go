@@ -0,0 +1,210 @@
+package dashboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+const (
+	// httpSourceType is the type of providers downloading dashboards from a http server.
+	httpSourceType = "http"
+	// defaultHttpTimeoutSeconds limits requests of http providers unless the timeoutSeconds option is set.
+	defaultHttpTimeoutSeconds = 30
+)
+
+// httpSource downloads the dashboards listed in a manifest, a json array of dashboard urls, into its directory.
+// Dashboards below the directory of the manifest keep their path relative to it, others are saved by host and path.
+// A dashboard is only downloaded again when the server reports a change for its ETag or Last-Modified header.
+type httpSource struct {
+	manifestUrl *url.URL
+	headers     map[string]string
+	workDir     string
+	client      *http.Client
+	log         log.Logger
+	// validators are the cache headers of the downloaded dashboards by url.
+	validators map[string]httpValidators
+}
+
+// httpValidators are the ETag and Last-Modified headers a dashboard was downloaded with.
+type httpValidators struct {
+	etag         string
+	lastModified string
+}
+
+func newHttpSource(cfg *DashboardsAsConfig, workDir string, log log.Logger) (*httpSource, error) {
+	rawUrl := getStringOption(cfg.Options, "url")
+	if rawUrl == "" {
+		return nil, fmt.Errorf("url of the dashboard manifest is missing")
+	}
+	manifestUrl, err := url.Parse(rawUrl)
+	if err != nil || (manifestUrl.Scheme != "http" && manifestUrl.Scheme != "https") {
+		return nil, fmt.Errorf("url %q is not a http or https url", rawUrl)
+	}
+
+	timeout := getInt64Option(cfg.Options, "timeoutSeconds")
+	if timeout <= 0 {
+		timeout = defaultHttpTimeoutSeconds
+	}
+
+	return &httpSource{
+		manifestUrl: manifestUrl,
+		headers:     getStringMapOption(cfg.Options, "headers"),
+		workDir:     workDir,
+		client:      &http.Client{Timeout: time.Duration(timeout) * time.Second},
+		log:         log,
+		validators:  map[string]httpValidators{},
+	}, nil
+}
+
+func (s *httpSource) dir() string {
+	return s.workDir
+}
+
+// sync downloads the dashboards of the manifest and removes the files of dashboards no longer listed. When the
+// manifest can't be fetched nothing is changed. Dashboards failing to download keep their last copy and are tried
+// again on the next sync.
+func (s *httpSource) sync() error {
+	urls, err := s.fetchManifest()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.workDir, 0750); err != nil {
+		return err
+	}
+
+	listed := map[string]bool{}
+	for _, dashboardUrl := range urls {
+		target, err := s.localPath(dashboardUrl)
+		if err != nil {
+			s.log.Warn("skipping dashboard url", "url", dashboardUrl.String(), "error", err)
+			continue
+		}
+		if listed[target] {
+			s.log.Warn("skipping dashboard url, another url of the manifest is saved to the same file", "url", dashboardUrl.String(), "file", target)
+			continue
+		}
+		listed[target] = true
+
+		if err := s.download(dashboardUrl, target); err != nil {
+			s.log.Error("failed to download dashboard, keeping the last copy", "url", dashboardUrl.String(), "error", err)
+		}
+	}
+
+	return removeUnlistedFiles(s.workDir, listed, s.log)
+}
+
+// fetchManifest returns the dashboard urls of the manifest, relative urls resolved against the manifest url.
+func (s *httpSource) fetchManifest() ([]*url.URL, error) {
+	resp, err := s.get(s.manifestUrl, httpValidators{})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch manifest %s: %s", s.manifestUrl, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []string
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, fmt.Errorf("manifest %s is not a json array of urls: %v", s.manifestUrl, err)
+	}
+
+	urls := make([]*url.URL, 0, len(entries))
+	for _, entry := range entries {
+		ref, err := url.Parse(entry)
+		if err != nil {
+			return nil, fmt.Errorf("manifest %s lists invalid url %q: %v", s.manifestUrl, entry, err)
+		}
+		urls = append(urls, s.manifestUrl.ResolveReference(ref))
+	}
+	return urls, nil
+}
+
+// localPath returns the file in the directory of the source the dashboard at dashboardUrl is saved to.
+func (s *httpSource) localPath(dashboardUrl *url.URL) (string, error) {
+	name := path.Join(strings.Replace(dashboardUrl.Host, ":", "_", -1), dashboardUrl.Path)
+	manifestDir := path.Dir(s.manifestUrl.Path)
+	if !strings.HasSuffix(manifestDir, "/") {
+		manifestDir += "/"
+	}
+	if dashboardUrl.Host == s.manifestUrl.Host && strings.HasPrefix(dashboardUrl.Path, manifestDir) {
+		name = strings.TrimPrefix(dashboardUrl.Path, manifestDir)
+	}
+
+	if name == "" || strings.HasSuffix(name, "/") {
+		return "", fmt.Errorf("url has no file name")
+	}
+	return pathWithinDir(s.workDir, name)
+}
+
+// download saves the dashboard at dashboardUrl to target unless the server reports it unchanged since the last
+// download.
+func (s *httpSource) download(dashboardUrl *url.URL, target string) error {
+	key := dashboardUrl.String()
+	validators, cached := s.validators[key]
+	if _, err := os.Stat(target); err != nil {
+		validators, cached = httpValidators{}, false
+	}
+
+	resp, err := s.get(dashboardUrl, validators)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached:
+		return nil
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("unexpected response %s", resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := replaceFile(target, body); err != nil {
+		return err
+	}
+
+	s.validators[key] = httpValidators{
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}
+	return nil
+}
+
+func (s *httpSource) get(u *url.URL, validators httpValidators) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range s.headers {
+		req.Header.Set(name, value)
+	}
+	if validators.etag != "" {
+		req.Header.Set("If-None-Match", validators.etag)
+	}
+	if validators.lastModified != "" {
+		req.Header.Set("If-Modified-Since", validators.lastModified)
+	}
+
+	return s.client.Do(req)
+}
@@ -0,0 +1,175 @@
+package dashboards
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeDashboardServer serves a manifest and dashboards with an ETag, counting the downloads of each path.
+type fakeDashboardServer struct {
+	mu         sync.Mutex
+	files      map[string]string
+	downloads  map[string]int
+	authHeader string
+	down       bool
+}
+
+func (s *fakeDashboardServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.authHeader = r.Header.Get("Authorization")
+	content, ok := s.files[r.URL.Path]
+	if s.down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	etag := `"` + content + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	s.downloads[r.URL.Path]++
+	w.Header().Set("ETag", etag)
+	w.Write([]byte(content))
+}
+
+func TestHttpSource(t *testing.T) {
+	Convey("Dashboards from a http server", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		dir, err := ioutil.TempDir("", "provisioning-http")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		opts := ReaderOptions{WorkDir: dir}
+
+		server := &fakeDashboardServer{
+			files: map[string]string{
+				"/dashboards/manifest.json":  `["nodes.json", "team/pods.json"]`,
+				"/dashboards/nodes.json":     `{"title": "Nodes", "uid": "nodes"}`,
+				"/dashboards/team/pods.json": `{"title": "Pods", "uid": "pods"}`,
+			},
+			downloads: map[string]int{},
+		}
+		ts := httptest.NewServer(server)
+		defer ts.Close()
+
+		cfg := &DashboardsAsConfig{
+			Name:  "Remote",
+			Type:  "http",
+			OrgId: 1,
+			Options: map[string]interface{}{
+				"url":     ts.URL + "/dashboards/manifest.json",
+				"headers": map[string]interface{}{"Authorization": "Bearer secret"},
+			},
+		}
+		reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), opts)
+		So(err, ShouldBeNil)
+		So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+		provisionedIds := func() []string {
+			var ids []string
+			for _, p := range fakeService.provisioned["Remote"] {
+				rel, err := filepath.Rel(reader.resolvedPath(), p.ExternalId)
+				So(err, ShouldBeNil)
+				ids = append(ids, filepath.ToSlash(rel))
+			}
+			sort.Strings(ids)
+			return ids
+		}
+
+		Convey("should provision the dashboards of the manifest by their path relative to it", func() {
+			So(provisionedIds(), ShouldResemble, []string{"nodes.json", "team/pods.json"})
+			So(server.authHeader, ShouldEqual, "Bearer secret")
+		})
+
+		Convey("should not download unchanged dashboards again", func() {
+			server.files["/dashboards/nodes.json"] = `{"title": "Nodes v2", "uid": "nodes"}`
+			// scans are seconds apart, the download must not look as old as the saved dashboard
+			for _, p := range fakeService.provisioned["Remote"] {
+				p.Updated -= 60
+			}
+			fakeService.inserted = nil
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(server.downloads["/dashboards/nodes.json"], ShouldEqual, 2)
+			So(server.downloads["/dashboards/team/pods.json"], ShouldEqual, 1)
+			So(len(fakeService.inserted), ShouldEqual, 1)
+			So(fakeService.inserted[0].Dashboard.Title, ShouldEqual, "Nodes v2")
+		})
+
+		Convey("should remove dashboards no longer listed", func() {
+			server.files["/dashboards/manifest.json"] = `["nodes.json"]`
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(provisionedIds(), ShouldResemble, []string{"nodes.json"})
+		})
+
+		Convey("should keep dashboards while the server is down", func() {
+			server.down = true
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(provisionedIds(), ShouldResemble, []string{"nodes.json", "team/pods.json"})
+		})
+
+		Convey("should keep the last copy of a dashboard failing to download", func() {
+			delete(server.files, "/dashboards/team/pods.json")
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(provisionedIds(), ShouldResemble, []string{"nodes.json", "team/pods.json"})
+		})
+	})
+
+	Convey("Http source", t, func() {
+		Convey("should require a http url", func() {
+			for _, options := range []map[string]interface{}{{}, {"url": "ftp://example.com/manifest.json"}} {
+				cfg := &DashboardsAsConfig{Name: "Remote", Type: "http", Options: options}
+				_, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldNotBeNil)
+			}
+		})
+
+		Convey("should save dashboards outside the manifest directory by host and path", func() {
+			cfg := &DashboardsAsConfig{Name: "Remote", Type: "http", Options: map[string]interface{}{"url": "http://example.com/a/manifest.json"}}
+			source, err := newHttpSource(cfg, "/work", log.New("test-logger"))
+			So(err, ShouldBeNil)
+
+			urls := map[string]string{
+				"http://example.com/a/b/nodes.json":      filepath.FromSlash("/work/b/nodes.json"),
+				"http://example.com/c/pods.json":         filepath.FromSlash("/work/example.com/c/pods.json"),
+				"http://other:8080/a/volumes.json":       filepath.FromSlash("/work/other_8080/a/volumes.json"),
+				"http://example.com/a/../../../etc.json": filepath.FromSlash("/work/example.com/etc.json"),
+			}
+			for raw, expected := range urls {
+				u, err := source.manifestUrl.Parse(raw)
+				So(err, ShouldBeNil)
+				target, err := source.localPath(u)
+				So(err, ShouldBeNil)
+				So(target, ShouldEqual, expected)
+			}
+		})
+	})
+}
@@ -0,0 +1,44 @@
+package dashboards
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+// resolveDashboardInputs replaces the ${DS_NAME} style variables of dashboards exported for sharing, which list them
+// in __inputs, the same way importing the dashboard in the UI does. Datasource inputs are mapped to datasources by
+// the inputs option, constants use their exported value unless the option maps them too. Dashboards without
+// __inputs are returned unchanged.
+func (fr *fileReader) resolveDashboardInputs(data *simplejson.Json) (*simplejson.Json, error) {
+	inputDefs, ok := data.CheckGet("__inputs")
+	if !ok {
+		return data, nil
+	}
+
+	mapping := getStringMapOption(fr.Cfg.Options, "inputs")
+	var inputs []plugins.ImportDashboardInput
+	for _, def := range inputDefs.MustArray() {
+		inputDef := simplejson.NewFromAny(def)
+		input := plugins.ImportDashboardInput{
+			Type:     inputDef.Get("type").MustString(),
+			PluginId: inputDef.Get("pluginId").MustString(),
+			Name:     inputDef.Get("name").MustString(),
+		}
+
+		value, mapped := mapping[input.Name]
+		switch {
+		case mapped:
+			input.Value = value
+		case input.Type == "constant":
+			input.Value = inputDef.Get("value").MustString()
+		default:
+			return nil, fmt.Errorf("no value for dashboard input %s of type %s, map it to a %s in the inputs option of the provider",
+				input.Name, input.Type, inputDef.Get("pluginName").MustString(input.Type))
+		}
+		inputs = append(inputs, input)
+	}
+
+	return plugins.NewDashTemplateEvaluator(data, inputs).Eval()
+}
@@ -0,0 +1,66 @@
+package dashboards
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var inputDashboards = "testdata/test-dashboards/inputs"
+
+func TestDashboardInputs(t *testing.T) {
+	Convey("Provisioning dashboards exported with inputs", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		cfg := &DashboardsAsConfig{
+			Name:    "Default",
+			Type:    "file",
+			OrgId:   1,
+			Options: map[string]interface{}{"path": inputDashboards},
+		}
+		provision := func() {
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+		}
+
+		Convey("should skip dashboard with unmapped datasource input", func() {
+			provision()
+			So(len(fakeService.inserted), ShouldEqual, 0)
+		})
+
+		Convey("should replace mapped inputs", func() {
+			cfg.Options["inputs"] = map[interface{}]interface{}{"DS_PROMETHEUS": "Metrics"}
+			provision()
+
+			So(len(fakeService.inserted), ShouldEqual, 1)
+			data := fakeService.inserted[0].Dashboard.Data
+			_, hasInputs := data.CheckGet("__inputs")
+			So(hasInputs, ShouldBeFalse)
+
+			panel := data.Get("panels").GetIndex(0)
+			So(panel.Get("datasource").MustString(), ShouldEqual, "Metrics")
+			So(panel.Get("targets").GetIndex(0).Get("expr").MustString(), ShouldEqual, `up{job="node"}`)
+			So(data.GetPath("templating", "list").GetIndex(0).Get("datasource").MustString(), ShouldEqual, "Metrics")
+		})
+
+		Convey("should let the mapping override constants", func() {
+			cfg.Options["inputs"] = map[interface{}]interface{}{"DS_PROMETHEUS": "Metrics", "VAR_JOB": "node-exporter"}
+			provision()
+
+			So(len(fakeService.inserted), ShouldEqual, 1)
+			expr := fakeService.inserted[0].Dashboard.Data.Get("panels").GetIndex(0).Get("targets").GetIndex(0).Get("expr")
+			So(expr.MustString(), ShouldEqual, `up{job="node-exporter"}`)
+		})
+	})
+}
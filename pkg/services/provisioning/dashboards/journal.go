@@ -0,0 +1,151 @@
+package dashboards
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/events"
+)
+
+const (
+	// journalActor identifies provisioning as the actor of journal entries.
+	journalActor = "provisioning"
+	// defaultJournalMaxSizeBytes is the size a journal file is rotated at when journalMaxSizeBytes is not set.
+	defaultJournalMaxSizeBytes = 10 * 1024 * 1024
+)
+
+// Actions recorded in the journal.
+const (
+	journalActionCreated       = "created"
+	journalActionUpdated       = "updated"
+	journalActionMoved         = "moved"
+	journalActionDeleted       = "deleted"
+	journalActionUnprovisioned = "unprovisioned"
+)
+
+// journalEntry is a single line of the journal.
+type journalEntry struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Provider    string    `json:"provider"`
+	OrgId       int64     `json:"orgId"`
+	DashboardId int64     `json:"dashboardId"`
+	Uid         string    `json:"uid,omitempty"`
+	Action      string    `json:"action"`
+	Source      string    `json:"source"`
+	Hash        string    `json:"hash,omitempty"`
+	Actor       string    `json:"actor"`
+}
+
+// dashboardJournal appends the provisioning actions of a provider as json lines to a file. Entries are buffered and
+// written by flush. The file is rotated before writing once it exceeds maxSize or was last written on another day.
+type dashboardJournal struct {
+	path    string
+	maxSize int64
+	now     func() time.Time
+	entries []journalEntry
+}
+
+func newDashboardJournal(path string, maxSize int64) *dashboardJournal {
+	if maxSize <= 0 {
+		maxSize = defaultJournalMaxSizeBytes
+	}
+	return &dashboardJournal{path: path, maxSize: maxSize, now: time.Now}
+}
+
+func (j *dashboardJournal) record(entry journalEntry) {
+	entry.Timestamp = j.now()
+	entry.Actor = journalActor
+	j.entries = append(j.entries, entry)
+}
+
+// flush writes the buffered entries to the journal file.
+func (j *dashboardJournal) flush() error {
+	if len(j.entries) == 0 {
+		return nil
+	}
+
+	if err := j.rotate(); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(j.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, entry := range j.entries {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	j.entries = nil
+	return file.Sync()
+}
+
+// rotate renames the journal file to one with the time of the rotation in its name, for example
+// journal.jsonl to journal-20190501-120000.jsonl.
+func (j *dashboardJournal) rotate() error {
+	fi, err := os.Stat(j.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	now := j.now()
+	if fi.Size() < j.maxSize && fi.ModTime().Format("20060102") == now.Format("20060102") {
+		return nil
+	}
+
+	ext := filepath.Ext(j.path)
+	rotated := strings.TrimSuffix(j.path, ext) + "-" + now.Format("20060102-150405") + ext
+	return os.Rename(j.path, rotated)
+}
+
+// recordJournal publishes the action of the entry on the bus and adds the entry to the journal of the provider if
+// the journalPath option is set.
+func (fr *fileReader) recordJournal(entry journalEntry) {
+	entry.Provider = fr.Cfg.Name
+	entry.OrgId = fr.Cfg.OrgId
+
+	err := bus.Publish(&events.DashboardProvisioned{
+		Timestamp:   time.Now(),
+		Provider:    entry.Provider,
+		OrgId:       entry.OrgId,
+		DashboardId: entry.DashboardId,
+		Uid:         entry.Uid,
+		Path:        entry.Source,
+		Action:      entry.Action,
+	})
+	if err != nil {
+		fr.log.Warn("failed to publish provisioning event", "action", entry.Action, "dashboardId", entry.DashboardId, "error", err)
+	}
+
+	if fr.journal == nil {
+		return
+	}
+	fr.journal.record(entry)
+}
+
+func (fr *fileReader) flushJournal() {
+	if fr.journal == nil {
+		return
+	}
+
+	if err := fr.journal.flush(); err != nil {
+		fr.log.Error("failed to write provisioning journal", "path", fr.journal.path, "error", err)
+	}
+}
@@ -0,0 +1,126 @@
+package dashboards
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func readJournal(path string) []journalEntry {
+	file, err := os.Open(path)
+	So(err, ShouldBeNil)
+	defer file.Close()
+
+	var entries []journalEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry := journalEntry{}
+		So(json.Unmarshal(scanner.Bytes(), &entry), ShouldBeNil)
+		entries = append(entries, entry)
+	}
+	So(scanner.Err(), ShouldBeNil)
+	return entries
+}
+
+func TestDashboardJournal(t *testing.T) {
+	Convey("Dashboard journal", t, func() {
+		dir, err := ioutil.TempDir("", "provisioning-journal")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		journalPath := filepath.Join(dir, "journal.jsonl")
+
+		Convey("scan should append entry per action", func() {
+			bus.ClearBusHandlers()
+			origNewDashboardProvisioningService := dashboards.NewProvisioningService
+			fakeService = mockDashboardProvisioningService()
+			bus.AddHandler("test", mockGetDashboardQuery)
+			defer func() {
+				dashboards.NewProvisioningService = origNewDashboardProvisioningService
+			}()
+
+			fakeService.provisioned["Default"] = []*models.DashboardProvisioning{
+				{Name: "Default", ExternalId: "/removed/dashboard.json", DashboardId: 42, CheckSum: "abc"},
+			}
+			cfg := &DashboardsAsConfig{
+				Name:    "Default",
+				Type:    "file",
+				OrgId:   1,
+				Options: map[string]interface{}{"path": defaultDashboards, "journalPath": journalPath},
+			}
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			err = reader.startWalkingDisk(context.Background())
+			So(err, ShouldBeNil)
+
+			entries := readJournal(journalPath)
+			So(len(entries), ShouldEqual, 3)
+
+			So(entries[0].Action, ShouldEqual, journalActionDeleted)
+			So(entries[0].DashboardId, ShouldEqual, 42)
+			So(entries[0].Source, ShouldEqual, "/removed/dashboard.json")
+			So(entries[0].Hash, ShouldEqual, "abc")
+
+			for _, entry := range entries[1:] {
+				So(entry.Action, ShouldEqual, journalActionCreated)
+				So(entry.Hash, ShouldNotBeEmpty)
+			}
+			So(filepath.Base(entries[1].Source), ShouldEqual, "dashboard1.json")
+			So(filepath.Base(entries[2].Source), ShouldEqual, "dashboard2.json")
+
+			for _, entry := range entries {
+				So(entry.Provider, ShouldEqual, "Default")
+				So(entry.OrgId, ShouldEqual, 1)
+				So(entry.Actor, ShouldEqual, journalActor)
+				So(entry.Timestamp.IsZero(), ShouldBeFalse)
+			}
+
+			Convey("and not write anything when nothing changed", func() {
+				err = reader.startWalkingDisk(context.Background())
+				So(err, ShouldBeNil)
+
+				So(len(readJournal(journalPath)), ShouldEqual, 3)
+			})
+		})
+
+		Convey("should rotate file exceeding the size limit", func() {
+			journal := newDashboardJournal(journalPath, 100)
+			journal.record(journalEntry{Provider: "Default", Action: journalActionCreated, Source: "a.json"})
+			So(journal.flush(), ShouldBeNil)
+			So(len(readJournal(journalPath)), ShouldEqual, 1)
+
+			journal.now = func() time.Time { return time.Now().Add(time.Second) }
+			journal.record(journalEntry{Provider: "Default", Action: journalActionUpdated, Source: "a.json"})
+			So(journal.flush(), ShouldBeNil)
+
+			files, err := ioutil.ReadDir(dir)
+			So(err, ShouldBeNil)
+			So(len(files), ShouldEqual, 2)
+
+			entries := readJournal(journalPath)
+			So(len(entries), ShouldEqual, 1)
+			So(entries[0].Action, ShouldEqual, journalActionUpdated)
+		})
+
+		Convey("should keep appending below the size limit", func() {
+			journal := newDashboardJournal(journalPath, 0)
+			journal.record(journalEntry{Provider: "Default", Action: journalActionCreated, Source: "a.json"})
+			So(journal.flush(), ShouldBeNil)
+			journal.record(journalEntry{Provider: "Default", Action: journalActionUpdated, Source: "a.json"})
+			So(journal.flush(), ShouldBeNil)
+
+			So(len(readJournal(journalPath)), ShouldEqual, 2)
+		})
+	})
+}
@@ -0,0 +1,85 @@
+package dashboards
+
+// stripJsonComments removes // and /* */ comments and trailing commas before a closing brace or bracket from json
+// content, for the allowComments option. Strings are copied unchanged, so urls like http://host stay intact. Comments
+// are replaced by whitespace keeping their line breaks, so parse errors still point to the right line.
+func stripJsonComments(content []byte) []byte {
+	return stripTrailingCommas(stripComments(content))
+}
+
+func stripComments(content []byte) []byte {
+	result := make([]byte, 0, len(content))
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case c == '"':
+			end := endOfString(content, i)
+			result = append(result, content[i:end]...)
+			i = end - 1
+		case c == '/' && i+1 < len(content) && content[i+1] == '/':
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+			if i < len(content) {
+				result = append(result, '\n')
+			}
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			i += 2
+			for i < len(content) && !(content[i] == '*' && i+1 < len(content) && content[i+1] == '/') {
+				if content[i] == '\n' {
+					result = append(result, '\n')
+				}
+				i++
+			}
+			// skip the slash of the closing */
+			i++
+			result = append(result, ' ')
+		default:
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func stripTrailingCommas(content []byte) []byte {
+	result := make([]byte, 0, len(content))
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch c {
+		case '"':
+			end := endOfString(content, i)
+			result = append(result, content[i:end]...)
+			i = end - 1
+		case ',':
+			next := i + 1
+			for next < len(content) && isJsonWhitespace(content[next]) {
+				next++
+			}
+			if next < len(content) && (content[next] == '}' || content[next] == ']') {
+				continue
+			}
+			result = append(result, c)
+		default:
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// endOfString returns the index after the closing quote of the string starting at start, or the length of content if
+// the string is not terminated.
+func endOfString(content []byte, start int) int {
+	for i := start + 1; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(content)
+}
+
+func isJsonWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
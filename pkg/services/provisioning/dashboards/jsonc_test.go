@@ -0,0 +1,36 @@
+package dashboards
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStripJsonComments(t *testing.T) {
+	Convey("Stripping json comments", t, func() {
+		Convey("should remove line and block comments", func() {
+			stripped := stripJsonComments([]byte("{\n  // title\n  \"title\": \"a\", /* uid */ \"uid\": \"b\"\n}"))
+			So(string(stripped), ShouldEqual, "{\n  \n  \"title\": \"a\",   \"uid\": \"b\"\n}")
+		})
+
+		Convey("should keep line breaks of block comments", func() {
+			stripped := stripJsonComments([]byte("/* a\nb */{}"))
+			So(string(stripped), ShouldEqual, "\n {}")
+		})
+
+		Convey("should not touch comments and commas in strings", func() {
+			content := `{"url": "http://host/*path*/", "query": "a,]", "quote": "\"//\""}`
+			So(string(stripJsonComments([]byte(content))), ShouldEqual, content)
+		})
+
+		Convey("should remove trailing commas", func() {
+			stripped := stripJsonComments([]byte("{\"a\": [1, 2, ], \"b\": {\"c\": 1,\n},}"))
+			So(string(stripped), ShouldEqual, "{\"a\": [1, 2 ], \"b\": {\"c\": 1\n}}")
+		})
+
+		Convey("should remove trailing commas followed by comments", func() {
+			stripped := stripJsonComments([]byte("[1, // last\n]"))
+			So(string(stripped), ShouldEqual, "[1 \n]")
+		})
+	})
+}
@@ -0,0 +1,64 @@
+package dashboards
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultJsonnetBinary is looked up in the PATH unless the jsonnetBinary option is set.
+	defaultJsonnetBinary = "jsonnet"
+	// jsonnetEvaluationTimeout limits how long evaluating a single jsonnet file may take.
+	jsonnetEvaluationTimeout = 30 * time.Second
+)
+
+// evaluateJsonnet runs the jsonnet binary on the file at path and returns the json it evaluates to. The jpath
+// directories of the provider are searched for imported libraries after the directory of the file.
+func (fr *fileReader) evaluateJsonnet(path string) ([]byte, error) {
+	binary := getStringOption(fr.Cfg.Options, "jsonnetBinary")
+	if binary == "" {
+		binary = defaultJsonnetBinary
+	}
+
+	var args []string
+	for _, dir := range fr.jpath {
+		args = append(args, "--jpath", dir)
+	}
+	args = append(args, path)
+
+	ctx, cancel := context.WithTimeout(context.Background(), jsonnetEvaluationTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("jsonnet evaluation timed out after %v", jsonnetEvaluationTimeout)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("jsonnet evaluation failed: %s", message)
+		}
+		return nil, fmt.Errorf("jsonnet evaluation failed: %v", err)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// resolveJpath makes the relative jpath directories relative to the provider path.
+func resolveJpath(jpath []string, providerPath string) []string {
+	var resolved []string
+	for _, dir := range jpath {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(providerPath, dir)
+		}
+		resolved = append(resolved, dir)
+	}
+	return resolved
+}
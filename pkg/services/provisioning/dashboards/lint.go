@@ -0,0 +1,93 @@
+package dashboards
+
+import (
+	"context"
+	"os"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// LintResult is the outcome of linting a single dashboard file. Error is nil for dashboards passing all checks.
+type LintResult struct {
+	Path  string
+	Error error
+}
+
+// LintDashboards reads all dashboard files in path the way the provider configured by cfg would, running the
+// transforms and the validation in strict mode, without saving anything. Results are sorted by path.
+func LintDashboards(path string, cfg *DashboardsAsConfig, logger log.Logger) ([]LintResult, error) {
+	options := map[string]interface{}{}
+	for key, value := range cfg.Options {
+		options[key] = value
+	}
+	options["path"] = path
+	delete(options, "paths")
+	options["validate"] = validateModeStrict
+	// linting must not depend on the state of the machine it runs on
+	delete(options, "tagWithCommit")
+	delete(options, "backupDir")
+
+	lintCfg := *cfg
+	lintCfg.Options = options
+	// the path to lint is given on the command line, not in the config file
+	lintCfg.ConfigPath = ""
+	reader, err := NewDashboardFileReader(&lintCfg, logger, ReaderOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return reader.readDashboardFiles(context.Background())
+}
+
+// readDashboardFiles reads all dashboard files of the provider without saving anything. Results are sorted by path.
+func (fr *fileReader) readDashboardFiles(ctx context.Context) ([]LintResult, error) {
+	filesFoundOnDisk, err := fr.findDashboardFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []LintResult
+	for path, fileInfo := range filesFoundOnDisk {
+		result := LintResult{Path: path}
+		resolvedFileInfo, err := resolveSymlink(fr.fs, fileInfo, path)
+		if err == nil {
+			_, err = fr.readDashboardFromFile(path, resolvedFileInfo.ModTime(), dashboardFolder{title: fr.Cfg.Folder}, false)
+		}
+		result.Error = err
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Path < results[j].Path
+	})
+	return results, nil
+}
+
+// findDashboardFiles returns the dashboard files in the provider paths without saving anything.
+func (fr *fileReader) findDashboardFiles(ctx context.Context) (map[string]os.FileInfo, error) {
+	filesFoundOnDisk := map[string]os.FileInfo{}
+	for _, root := range fr.resolvedPaths() {
+		opts := walkOptions{
+			fs:             fr.fs,
+			root:           root,
+			formats:        fr.formats,
+			followSymlinks: getBoolOption(fr.Cfg.Options, "followSymlinks"),
+			maxDepth:       getInt64Option(fr.Cfg.Options, "maxDepth"),
+			include:        fr.includePatterns,
+			exclude:        fr.excludePatterns,
+			log:            fr.log,
+			ctx:            ctx,
+		}
+		if err := walkDir(fr.fs, root, createWalkFn(filesFoundOnDisk, opts)); err != nil {
+			return nil, err
+		}
+	}
+
+	if fr.allowedRoot != "" && fr.source == nil {
+		fr.removeFilesOutsideRoot(filesFoundOnDisk)
+	}
+	fr.removeFilesForOtherOrgs(filesFoundOnDisk)
+	removeShadowedGzipFiles(filesFoundOnDisk, fr.log)
+	return filesFoundOnDisk, nil
+}
@@ -0,0 +1,37 @@
+package dashboards
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLintDashboards(t *testing.T) {
+	Convey("Linting dashboards", t, func() {
+		logger := log.New("test-logger")
+		configs, err := ReadConfigFile("testdata/test-configs/lint/rules.yaml", logger)
+		So(err, ShouldBeNil)
+		So(len(configs), ShouldEqual, 1)
+
+		results, err := LintDashboards(ownerTags, configs[0], logger)
+		So(err, ShouldBeNil)
+		So(len(results), ShouldEqual, 2)
+
+		Convey("should pass dashboard following the rules", func() {
+			So(filepath.Base(results[0].Path), ShouldEqual, "with-owner.json")
+			So(results[0].Error, ShouldBeNil)
+		})
+
+		Convey("should flag dashboard violating the rules", func() {
+			So(filepath.Base(results[1].Path), ShouldEqual, "without-owner.json")
+			So(results[1].Error, ShouldNotBeNil)
+		})
+
+		Convey("should not change the provider config", func() {
+			So(configs[0].Options["path"], ShouldEqual, "/var/lib/grafana/dashboards")
+			So(configs[0].Options, ShouldNotContainKey, "validate")
+		})
+	})
+}
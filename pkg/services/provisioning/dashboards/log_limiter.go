@@ -0,0 +1,114 @@
+package dashboards
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// defaultLogRateLimitWindow is the window repeated log messages are counted in when no window is configured.
+const defaultLogRateLimitWindow = time.Minute
+
+// rateLimitedLogger keeps a noisy provider from flooding the logs. Identical messages, same level, message and
+// context, are only logged limit times per window. When the window of a suppressed message ends a summary with the
+// number of suppressed messages is logged instead.
+type rateLimitedLogger struct {
+	log.Logger
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mu     sync.Mutex
+	events map[string]*logEventCount
+}
+
+type logEventCount struct {
+	msg   string
+	start time.Time
+	count int
+}
+
+func newRateLimitedLogger(logger log.Logger, limit int, window time.Duration) *rateLimitedLogger {
+	if window <= 0 {
+		window = defaultLogRateLimitWindow
+	}
+
+	return &rateLimitedLogger{
+		Logger: logger,
+		limit:  limit,
+		window: window,
+		now:    time.Now,
+		events: map[string]*logEventCount{},
+	}
+}
+
+func (l *rateLimitedLogger) Debug(msg string, ctx ...interface{}) {
+	l.log("debug", l.Logger.Debug, msg, ctx)
+}
+
+func (l *rateLimitedLogger) Info(msg string, ctx ...interface{}) {
+	l.log("info", l.Logger.Info, msg, ctx)
+}
+
+func (l *rateLimitedLogger) Warn(msg string, ctx ...interface{}) {
+	l.log("warn", l.Logger.Warn, msg, ctx)
+}
+
+func (l *rateLimitedLogger) Error(msg string, ctx ...interface{}) {
+	l.log("error", l.Logger.Error, msg, ctx)
+}
+
+func (l *rateLimitedLogger) log(level string, write func(string, ...interface{}), msg string, ctx []interface{}) {
+	key := level + "\x00" + msg + "\x00" + fmt.Sprint(ctx...)
+	now := l.now()
+
+	l.mu.Lock()
+	event, exists := l.events[key]
+	var ended *logEventCount
+	if exists && now.Sub(event.start) >= l.window {
+		ended = event
+		exists = false
+	}
+	if !exists {
+		event = &logEventCount{msg: msg, start: now}
+		l.events[key] = event
+	}
+	event.count++
+	allowed := event.count <= l.limit
+	l.mu.Unlock()
+
+	if ended != nil {
+		l.logSuppressed(ended)
+	}
+	if allowed {
+		write(msg, ctx...)
+	}
+}
+
+// flush logs the summaries of messages whose window has ended. It is called after every scan so suppressed
+// messages are reported even if they don't occur again.
+func (l *rateLimitedLogger) flush() {
+	now := l.now()
+
+	l.mu.Lock()
+	var ended []*logEventCount
+	for key, event := range l.events {
+		if now.Sub(event.start) >= l.window {
+			ended = append(ended, event)
+			delete(l.events, key)
+		}
+	}
+	l.mu.Unlock()
+
+	for _, event := range ended {
+		l.logSuppressed(event)
+	}
+}
+
+func (l *rateLimitedLogger) logSuppressed(event *logEventCount) {
+	if suppressed := event.count - l.limit; suppressed > 0 {
+		l.Logger.Info("similar log messages suppressed", "msg", event.msg, "count", suppressed, "window", l.window)
+	}
+}
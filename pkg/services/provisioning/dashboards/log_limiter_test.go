@@ -0,0 +1,63 @@
+package dashboards
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type recordingLogger struct {
+	log.Logger
+	messages []string
+	counts   []interface{}
+}
+
+func (l *recordingLogger) Info(msg string, ctx ...interface{}) {
+	l.messages = append(l.messages, msg)
+	for i := 0; i+1 < len(ctx); i += 2 {
+		if ctx[i] == "count" {
+			l.counts = append(l.counts, ctx[i+1])
+		}
+	}
+}
+
+func (l *recordingLogger) Warn(msg string, ctx ...interface{}) {
+	l.messages = append(l.messages, msg)
+}
+
+func TestRateLimitedLogger(t *testing.T) {
+	Convey("Rate limited logger", t, func() {
+		recorder := &recordingLogger{Logger: log.New("test-logger")}
+		limiter := newRateLimitedLogger(recorder, 2, time.Minute)
+		now := time.Date(2019, 5, 1, 12, 0, 0, 0, time.UTC)
+		limiter.now = func() time.Time { return now }
+
+		for i := 0; i < 5; i++ {
+			limiter.Info("saving dashboard", "file", "a.json")
+		}
+		limiter.Info("saving dashboard", "file", "b.json")
+
+		Convey("should suppress repeated identical messages", func() {
+			So(recorder.messages, ShouldResemble, []string{"saving dashboard", "saving dashboard", "saving dashboard"})
+		})
+
+		Convey("should log summary once the window ends", func() {
+			limiter.flush()
+			So(len(recorder.messages), ShouldEqual, 3)
+
+			now = now.Add(time.Minute)
+			limiter.flush()
+			So(recorder.messages[3], ShouldEqual, "similar log messages suppressed")
+			So(recorder.counts, ShouldResemble, []interface{}{3})
+			So(len(recorder.messages), ShouldEqual, 4)
+		})
+
+		Convey("should log again in the next window", func() {
+			now = now.Add(time.Minute)
+			limiter.Info("saving dashboard", "file", "a.json")
+			So(recorder.messages[3:], ShouldResemble, []string{"similar log messages suppressed", "saving dashboard"})
+		})
+	})
+}
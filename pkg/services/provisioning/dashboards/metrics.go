@@ -0,0 +1,32 @@
+package dashboards
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/metrics"
+)
+
+// Actions counted by the provisioning dashboards metric.
+const (
+	metricActionInserted      = "inserted"
+	metricActionUpdated       = "updated"
+	metricActionUnchanged     = "unchanged"
+	metricActionDeleted       = "deleted"
+	metricActionUnprovisioned = "unprovisioned"
+	metricActionFailed        = "failed"
+)
+
+// countDashboard counts a dashboard of the provider the action was taken for, in the metrics and the status of the
+// scan.
+func (fr *fileReader) countDashboard(action string) {
+	metrics.M_Provisioning_Dashboards.WithLabelValues(fr.Cfg.Name, action).Inc()
+	fr.status.count(action)
+}
+
+// recordScan records the duration of a scan of the provider that started at start and, if it succeeded, its time.
+func (fr *fileReader) recordScan(start time.Time, err error) {
+	metrics.M_Provisioning_Scan_Duration.WithLabelValues(fr.Cfg.Name).Set(time.Since(start).Seconds())
+	if err == nil {
+		metrics.M_Provisioning_Last_Successful_Scan.WithLabelValues(fr.Cfg.Name).Set(float64(time.Now().Unix()))
+	}
+}
@@ -0,0 +1,91 @@
+package dashboards
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	dto "github.com/prometheus/client_model/go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProvisioningMetrics(t *testing.T) {
+	Convey("Provisioning metrics", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		dir, err := ioutil.TempDir("", "provisioning-metrics")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		writeDashboard := func(name string, content string, modTime time.Time) {
+			path := filepath.Join(dir, name)
+			So(ioutil.WriteFile(path, []byte(content), 0644), ShouldBeNil)
+			So(os.Chtimes(path, modTime, modTime), ShouldBeNil)
+		}
+
+		// every run of the test uses its own provider, the metrics are global
+		provider := "metrics-" + filepath.Base(dir)
+		counted := func(action string) float64 {
+			metric := &dto.Metric{}
+			So(metrics.M_Provisioning_Dashboards.WithLabelValues(provider, action).Write(metric), ShouldBeNil)
+			return metric.GetCounter().GetValue()
+		}
+		lastSuccess := func() float64 {
+			metric := &dto.Metric{}
+			So(metrics.M_Provisioning_Last_Successful_Scan.WithLabelValues(provider).Write(metric), ShouldBeNil)
+			return metric.GetGauge().GetValue()
+		}
+
+		cfg := &DashboardsAsConfig{
+			Name:    provider,
+			Type:    "file",
+			OrgId:   1,
+			Options: map[string]interface{}{"path": dir},
+		}
+		reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+		So(err, ShouldBeNil)
+
+		provisioned := time.Now().Add(-time.Hour)
+		writeDashboard("cpu.json", `{"title": "CPU"}`, provisioned)
+		writeDashboard("disk.json", `{"title": "Disk"}`, provisioned)
+		writeDashboard("broken.json", `{"title": `, provisioned)
+		So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+		Convey("should count inserted and failed dashboards", func() {
+			So(counted(metricActionInserted), ShouldEqual, 2)
+			So(counted(metricActionFailed), ShouldEqual, 1)
+			So(lastSuccess(), ShouldBeGreaterThan, 0)
+		})
+
+		Convey("should count updated, unchanged and deleted dashboards", func() {
+			writeDashboard("cpu.json", `{"title": "CPU usage"}`, time.Now())
+			So(os.Remove(filepath.Join(dir, "disk.json")), ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(counted(metricActionUpdated), ShouldEqual, 1)
+			So(counted(metricActionDeleted), ShouldEqual, 1)
+
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+			So(counted(metricActionUnchanged), ShouldEqual, 1)
+		})
+
+		Convey("should record the duration of scans", func() {
+			metric := &dto.Metric{}
+			So(metrics.M_Provisioning_Scan_Duration.WithLabelValues(provider).Write(metric), ShouldBeNil)
+			So(metric.GetGauge().GetValue(), ShouldBeGreaterThan, 0)
+		})
+	})
+}
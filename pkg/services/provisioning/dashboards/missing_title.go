@@ -0,0 +1,37 @@
+package dashboards
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// Values of the onMissingTitle option choosing what happens to a dashboard without title, which would be saved with
+// an empty name that is hard to find.
+const (
+	// missingTitleSkip skips the dashboard with a warning. This is the default.
+	missingTitleSkip = "skip"
+	// missingTitleFilename uses the name of the file as title.
+	missingTitleFilename = "filename"
+)
+
+// errMissingTitle is returned for dashboards without title skipped with a warning.
+var errMissingTitle = errors.New("dashboard has no title")
+
+// hasTitle returns true if the dashboard has a title other than whitespace.
+func hasTitle(data *simplejson.Json) bool {
+	return strings.TrimSpace(data.Get("title").MustString()) != ""
+}
+
+// titleFromFilename returns the name of the file at path without its extensions and with dashes, underscores and
+// dots replaced by spaces, so node-exporter_full.json becomes "node exporter full".
+func titleFromFilename(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), gzipExtension)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '-' || r == '_' || r == '.' || r == ' '
+	})
+	return strings.Join(words, " ")
+}
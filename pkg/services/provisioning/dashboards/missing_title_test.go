@@ -0,0 +1,71 @@
+package dashboards
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var missingTitleDashboards = "testdata/test-dashboards/missing-title"
+
+func TestMissingTitle(t *testing.T) {
+	Convey("Deriving titles from file names", t, func() {
+		So(titleFromFilename("/dashboards/node-exporter_full.json"), ShouldEqual, "node exporter full")
+		So(titleFromFilename("/dashboards/api.latency.yaml"), ShouldEqual, "api latency")
+		So(titleFromFilename("/dashboards/k8s--pods.json.gz"), ShouldEqual, "k8s pods")
+	})
+
+	Convey("Reading dashboards without title", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		cfg := &DashboardsAsConfig{
+			Name:    "Default",
+			Type:    "file",
+			OrgId:   1,
+			Options: map[string]interface{}{"path": missingTitleDashboards},
+		}
+
+		titles := func() map[string]string {
+			titles := map[string]string{}
+			for _, dto := range fakeService.inserted {
+				titles[dto.Dashboard.Uid] = dto.Dashboard.Title
+			}
+			return titles
+		}
+
+		Convey("should reject unknown modes", func() {
+			cfg.Options["onMissingTitle"] = "ignore"
+			_, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("should skip them with a warning by default", func() {
+			logger := &recordingLogger{Logger: log.New("test-logger")}
+			reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+			So(err, ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(titles(), ShouldResemble, map[string]string{"titled": "Titled"})
+			So(logger.messages, ShouldContain, "skipping dashboard without title")
+		})
+
+		Convey("should title them after the file with onMissingTitle filename", func() {
+			cfg.Options["onMissingTitle"] = missingTitleFilename
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(titles(), ShouldResemble, map[string]string{"untitled": "node exporter full", "titled": "Titled"})
+		})
+	})
+}
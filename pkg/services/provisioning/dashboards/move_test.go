@@ -0,0 +1,102 @@
+package dashboards
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMovingDashboardFiles(t *testing.T) {
+	Convey("Moving a dashboard file", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		dir, err := ioutil.TempDir("", "provisioning-move")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		dir, err = filepath.EvalSymlinks(dir)
+		So(err, ShouldBeNil)
+		So(os.Mkdir(filepath.Join(dir, "a"), 0750), ShouldBeNil)
+		So(os.Mkdir(filepath.Join(dir, "b"), 0750), ShouldBeNil)
+
+		cfg := &DashboardsAsConfig{
+			Name:   "Default",
+			Type:   "file",
+			OrgId:  1,
+			Folder: "Provisioned",
+			Options: map[string]interface{}{
+				"path":                      dir,
+				"foldersFromFilesStructure": true,
+			},
+		}
+		reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+		So(err, ShouldBeNil)
+
+		folderId := func(title string) int64 {
+			for _, dto := range fakeService.inserted {
+				if dto.Dashboard.IsFolder && dto.Dashboard.Title == title {
+					return dto.Dashboard.Id
+				}
+			}
+			return 0
+		}
+
+		Convey("with a uid should move its dashboard to the new folder", func() {
+			oldPath := filepath.Join(dir, "a", "dashboard.json")
+			newPath := filepath.Join(dir, "b", "dashboard.json")
+			So(ioutil.WriteFile(oldPath, []byte(`{"uid": "moving", "title": "Moving"}`), 0644), ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+			So(len(fakeService.provisioned["Default"]), ShouldEqual, 1)
+			dashboardId := fakeService.provisioned["Default"][0].DashboardId
+
+			So(os.Rename(oldPath, newPath), ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(len(fakeService.provisioned["Default"]), ShouldEqual, 1)
+			provisioned := fakeService.provisioned["Default"][0]
+			So(provisioned.DashboardId, ShouldEqual, dashboardId)
+			So(provisioned.ExternalId, ShouldEqual, newPath)
+
+			saved := fakeService.inserted[len(fakeService.inserted)-1]
+			So(saved.Dashboard.Id, ShouldEqual, dashboardId)
+			So(saved.Dashboard.FolderId, ShouldEqual, folderId("b"))
+			So(reader.insertedDashboardIds, ShouldBeEmpty)
+		})
+
+		Convey("without a uid should recreate its dashboard", func() {
+			oldPath := filepath.Join(dir, "a", "dashboard.json")
+			newPath := filepath.Join(dir, "b", "dashboard.json")
+			So(ioutil.WriteFile(oldPath, []byte(`{"title": "Moving"}`), 0644), ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+			dashboardId := fakeService.provisioned["Default"][0].DashboardId
+
+			So(os.Rename(oldPath, newPath), ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(len(fakeService.provisioned["Default"]), ShouldEqual, 1)
+			So(fakeService.provisioned["Default"][0].DashboardId, ShouldNotEqual, dashboardId)
+		})
+
+		Convey("with a uid should remove its dashboard when no other file takes it over", func() {
+			oldPath := filepath.Join(dir, "a", "dashboard.json")
+			So(ioutil.WriteFile(oldPath, []byte(`{"uid": "moving", "title": "Moving"}`), 0644), ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(os.Remove(oldPath), ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+			So(fakeService.provisioned["Default"], ShouldBeEmpty)
+		})
+	})
+}
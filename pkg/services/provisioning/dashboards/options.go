@@ -0,0 +1,110 @@
+package dashboards
+
+// The helpers below read typed values from the free form provider options. Options parsed from yaml go through
+// values.JSONValue so strings are already interpolated, while tests and other callers may set Go values directly.
+
+// getStringOption returns the option as a string or an empty string if it is not set or not a string.
+func getStringOption(options map[string]interface{}, key string) string {
+	value, ok := options[key].(string)
+	if !ok {
+		return ""
+	}
+	return value
+}
+
+// getBoolOption returns the option as a bool. Missing or non bool values are treated as false.
+func getBoolOption(options map[string]interface{}, key string) bool {
+	value, ok := options[key].(bool)
+	if !ok {
+		return false
+	}
+	return value
+}
+
+// getInt64Option returns the option as an int64. Yaml numbers are decoded as int while json numbers are floats so
+// both are accepted. Missing or non numeric values are treated as 0.
+func getInt64Option(options map[string]interface{}, key string) int64 {
+	switch value := options[key].(type) {
+	case int:
+		return int64(value)
+	case int64:
+		return value
+	case float64:
+		return int64(value)
+	default:
+		return 0
+	}
+}
+
+// getStringMapOption returns the option as a map of strings. Yaml maps are decoded with interface{} keys so both
+// forms are accepted, entries with non string keys or values are ignored.
+func getStringMapOption(options map[string]interface{}, key string) map[string]string {
+	result := map[string]string{}
+	switch value := options[key].(type) {
+	case map[string]string:
+		for k, v := range value {
+			result[k] = v
+		}
+	case map[string]interface{}:
+		for k, v := range value {
+			if s, ok := v.(string); ok {
+				result[k] = s
+			}
+		}
+	case map[interface{}]interface{}:
+		for k, v := range value {
+			ks, kok := k.(string)
+			vs, vok := v.(string)
+			if kok && vok {
+				result[ks] = vs
+			}
+		}
+	}
+	return result
+}
+
+// getStringSliceOption returns the option as a slice of strings. Yaml lists are decoded as []interface{} so both
+// forms are accepted, non string items are ignored.
+func getStringSliceOption(options map[string]interface{}, key string) []string {
+	var result []string
+	switch value := options[key].(type) {
+	case []string:
+		result = append(result, value...)
+	case []interface{}:
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+	}
+	return result
+}
+
+// getMapSliceOption returns the option as a slice of maps with string keys. Yaml maps are decoded with interface{}
+// keys, they are converted the same way as yaml dashboards. Items that are not maps are ignored.
+func getMapSliceOption(options map[string]interface{}, key string) []map[string]interface{} {
+	items, ok := options[key].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var result []map[string]interface{}
+	for _, item := range items {
+		if m, ok := convertYamlValue(item).(map[string]interface{}); ok {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// getMapOption returns the option as a map with string keys, converted the same way as yaml dashboards. Options that
+// are not maps return nil.
+func getMapOption(options map[string]interface{}, key string) map[string]interface{} {
+	value, ok := options[key]
+	if !ok {
+		return nil
+	}
+
+	m, _ := convertYamlValue(value).(map[string]interface{})
+	return m
+}
@@ -0,0 +1,100 @@
+package dashboards
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// allOrgs is the orgId of providers provisioning their dashboards into every org.
+const allOrgs = "*"
+
+// provisionsIntoSeveralOrgs returns true if the provider has orgIds or orgId "*" set.
+func provisionsIntoSeveralOrgs(cfg *DashboardsAsConfig) bool {
+	return cfg.AllOrgs || len(cfg.OrgIds) > 0
+}
+
+// orgProvisioningName returns the name the dashboards of a provider provisioning into several orgs are recorded
+// under in the org, so removing dashboards in one org doesn't touch the ones of other orgs.
+func orgProvisioningName(name string, orgId int64) string {
+	return fmt.Sprintf("%s@%d", name, orgId)
+}
+
+// provisionsAs returns true if the reader records its dashboards under the provisioning name.
+func (fr *fileReader) provisionsAs(name string) bool {
+	if !provisionsIntoSeveralOrgs(fr.Cfg) {
+		return fr.Cfg.Name == name
+	}
+	return strings.HasPrefix(name, fr.Cfg.Name+"@")
+}
+
+// providerOrgIds returns the orgs the provider provisions into, looking up all orgs on every call for orgId "*" so
+// orgs created later are picked up by the next scan.
+func providerOrgIds(cfg *DashboardsAsConfig) ([]int64, error) {
+	if !cfg.AllOrgs {
+		return cfg.OrgIds, nil
+	}
+
+	query := &models.SearchOrgsQuery{}
+	if err := bus.Dispatch(query); err != nil {
+		return nil, err
+	}
+
+	orgIds := make([]int64, 0, len(query.Result))
+	for _, org := range query.Result {
+		orgIds = append(orgIds, org.Id)
+	}
+	sort.Slice(orgIds, func(i, j int) bool { return orgIds[i] < orgIds[j] })
+	return orgIds, nil
+}
+
+// scanOrgs scans the provider once for each of its orgs. A failing org doesn't stop the other orgs from being
+// scanned unless the scan is atomic.
+func (fr *fileReader) scanOrgs(ctx context.Context, opts ScanOptions) error {
+	orgIds, err := providerOrgIds(fr.Cfg)
+	if err != nil {
+		return err
+	}
+
+	var scanErr error
+	for _, orgId := range orgIds {
+		if err := fr.orgReader(orgId).scan(ctx, opts); err != nil {
+			if opts.atomic || ctx.Err() != nil {
+				return err
+			}
+			fr.log.Error("failed to provision dashboards into org", "orgId", orgId, "error", err)
+			scanErr = err
+		}
+	}
+	return scanErr
+}
+
+// orgReader returns the reader provisioning the dashboards of the provider into the org. It shares the files and the
+// options of the provider but has its own provisioning name and state.
+func (fr *fileReader) orgReader(orgId int64) *fileReader {
+	if reader, ok := fr.orgReaders[orgId]; ok {
+		return reader
+	}
+
+	cfg := *fr.Cfg
+	cfg.Name = orgProvisioningName(fr.Cfg.Name, orgId)
+	cfg.OrgId = orgId
+	cfg.OrgIds = nil
+	cfg.AllOrgs = false
+
+	reader := *fr
+	reader.Cfg = &cfg
+	reader.orgReaders = nil
+	reader.insertedDashboardIds = nil
+	reader.updatedDashboards = nil
+
+	if fr.orgReaders == nil {
+		fr.orgReaders = map[int64]*fileReader{}
+	}
+	fr.orgReaders[orgId] = &reader
+	return &reader
+}
@@ -0,0 +1,135 @@
+package dashboards
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// getProviderPaths returns the directories of a provider. The path option is a single path or a list of paths, the
+// paths option adds further paths after it.
+func getProviderPaths(options map[string]interface{}) []string {
+	var paths []string
+	if path, ok := options["path"].(string); ok {
+		paths = append(paths, path)
+	} else {
+		paths = append(paths, getStringSliceOption(options, "path")...)
+	}
+	return append(paths, getStringSliceOption(options, "paths")...)
+}
+
+// providerPaths returns the directories of a provider with environment variables and a leading ~ expanded. Relative
+// paths of providers read from a config file are made relative to its directory, see relativeToConfigFile. Providers
+// without a path fall back to the deprecated folder option. logger may be nil.
+func providerPaths(cfg *DashboardsAsConfig, logger log.Logger) ([]string, error) {
+	paths := getProviderPaths(cfg.Options)
+	if len(paths) == 0 && sourceRequiresPath(cfg.Type) {
+		folder, ok := cfg.Options["folder"].(string)
+		if !ok {
+			return nil, fmt.Errorf("path param is not a string or a list of strings")
+		}
+		paths = []string{folder}
+	}
+
+	for i, path := range paths {
+		expanded, err := expandEnvVariables([]byte(path), true)
+		if err != nil {
+			return nil, fmt.Errorf("could not expand path %s: %v", path, err)
+		}
+		if paths[i], err = expandHome(string(expanded)); err != nil {
+			return nil, err
+		}
+		if cfg.ConfigPath != "" && sourceRequiresPath(cfg.Type) && !filepath.IsAbs(paths[i]) {
+			paths[i] = relativeToConfigFile(cfg, paths[i], logger)
+		}
+	}
+	return paths, nil
+}
+
+// relativeToConfigFile joins the relative path of a provider to the directory of its config file. Relative paths used
+// to be relative to the working directory of Grafana, so a path that only exists relative to the working directory is
+// still used from there, with a warning to change the config.
+func relativeToConfigFile(cfg *DashboardsAsConfig, path string, logger log.Logger) string {
+	resolved := filepath.Join(filepath.Dir(cfg.ConfigPath), path)
+	if _, err := os.Stat(resolved); !os.IsNotExist(err) {
+		return resolved
+	}
+	if _, err := os.Stat(path); err != nil {
+		return resolved
+	}
+
+	if logger != nil {
+		logger.Warn("[Deprecated] relative dashboard provider path only found relative to the working directory, "+
+			"make it relative to the directory of the config file", "provider", cfg.Name, "path", path,
+			"configFile", cfg.ConfigPath, "expectedPath", resolved)
+	}
+	return path
+}
+
+// expandHome replaces a leading ~ of path with the home directory of the user running Grafana and a leading ~name
+// with the home directory of the user name, like a shell does. Tildes anywhere else are kept.
+func expandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+
+	name, rest := path[1:], ""
+	if i := strings.IndexAny(name, "/"+string(filepath.Separator)); i >= 0 {
+		name, rest = name[:i], name[i:]
+	}
+
+	var home string
+	if name == "" {
+		var err error
+		if home, err = os.UserHomeDir(); err != nil {
+			return "", fmt.Errorf("could not expand ~ in path %s: %v", path, err)
+		}
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return "", fmt.Errorf("could not expand ~%s in path %s: %v", name, path, err)
+		}
+		home = u.HomeDir
+	}
+	return filepath.Join(home, rest), nil
+}
+
+// resolvedPaths returns all paths of the provider resolved like resolvedPath, in the order they are configured.
+func (fr *fileReader) resolvedPaths() []string {
+	if len(fr.paths) <= 1 {
+		return []string{fr.resolvedPath()}
+	}
+
+	resolved := make([]string, 0, len(fr.paths))
+	for _, path := range fr.paths {
+		resolved = append(resolved, fr.resolvePath(path))
+	}
+	return resolved
+}
+
+// rootOf returns the first of the resolved provider paths containing path, or the first path if none does.
+func rootOf(roots []string, path string) string {
+	for _, root := range roots {
+		if isPathWithinRoot(path, root) {
+			return root
+		}
+	}
+	return roots[0]
+}
+
+// checkProviderPath returns an error if the path of a file provider doesn't exist or isn't a directory, so a typo in
+// the path doesn't leave a provider quietly walking nothing.
+func checkProviderPath(fsys fileSystem, path string) error {
+	stat, err := fsys.Stat(path)
+	if err != nil {
+		return fmt.Errorf("path %s does not exist or can't be read: %v", path, err)
+	}
+	if !stat.IsDir() {
+		return fmt.Errorf("path %s is not a directory", path)
+	}
+	return nil
+}
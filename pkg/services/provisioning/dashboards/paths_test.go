@@ -0,0 +1,179 @@
+package dashboards
+
+import (
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestResolvePath(t *testing.T) {
+	Convey("Resolving provider paths", t, func() {
+		// the home directory is not read from HOME on windows
+		if runtime.GOOS == "windows" {
+			return
+		}
+
+		home, err := ioutil.TempDir("", "provisioning-home")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(home)
+		home, err = filepath.EvalSymlinks(home)
+		So(err, ShouldBeNil)
+		So(os.Mkdir(filepath.Join(home, "sub"), 0750), ShouldBeNil)
+
+		origHome := os.Getenv("HOME")
+		So(os.Setenv("HOME", home), ShouldBeNil)
+		defer os.Setenv("HOME", origHome)
+
+		cfg := &DashboardsAsConfig{
+			Name:    "Default",
+			Type:    "file",
+			OrgId:   1,
+			Options: map[string]interface{}{"path": "~/sub"},
+		}
+
+		reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+		So(err, ShouldBeNil)
+
+		Convey("should expand ~ to the home directory", func() {
+			So(reader.resolvePath("~"), ShouldEqual, home)
+		})
+
+		Convey("should expand ~/ paths within the home directory", func() {
+			So(reader.resolvedPath(), ShouldEqual, filepath.Join(home, "sub"))
+		})
+
+		Convey("should keep absolute paths", func() {
+			So(reader.resolvePath(filepath.Join(home, "sub")), ShouldEqual, filepath.Join(home, "sub"))
+		})
+
+		Convey("should keep tildes that don't lead the path", func() {
+			expanded, err := expandHome("dashboards/~/sub")
+			So(err, ShouldBeNil)
+			So(expanded, ShouldEqual, "dashboards/~/sub")
+		})
+
+		Convey("should expand ~name to the home directory of the user", func() {
+			current, err := user.Current()
+			So(err, ShouldBeNil)
+
+			expanded, err := expandHome("~" + current.Username + "/dashboards")
+			So(err, ShouldBeNil)
+			So(expanded, ShouldEqual, filepath.Join(current.HomeDir, "dashboards"))
+		})
+
+		Convey("should fail for unknown users", func() {
+			_, err := expandHome("~no-such-user-for-provisioning/dashboards")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestProviderPaths(t *testing.T) {
+	Convey("Expanding environment variables in provider paths", t, func() {
+		So(os.Setenv("GF_TEST_DASHBOARD_DIR", "/var/lib/grafana/dashboards"), ShouldBeNil)
+		defer os.Unsetenv("GF_TEST_DASHBOARD_DIR")
+		So(os.Unsetenv("GF_TEST_UNSET_DIR"), ShouldBeNil)
+
+		cfg := &DashboardsAsConfig{Name: "Default", Type: "file", Options: map[string]interface{}{}}
+
+		Convey("should expand variables", func() {
+			cfg.Options["path"] = "${GF_TEST_DASHBOARD_DIR}/team-a"
+			cfg.Options["paths"] = []interface{}{"$GF_TEST_DASHBOARD_DIR/team-b"}
+
+			paths, err := providerPaths(cfg, nil)
+			So(err, ShouldBeNil)
+			So(paths, ShouldResemble, []string{"/var/lib/grafana/dashboards/team-a", "/var/lib/grafana/dashboards/team-b"})
+		})
+
+		Convey("should fail for variables that are not set", func() {
+			cfg.Options["path"] = "${GF_TEST_UNSET_DIR}/team-a"
+
+			_, err := providerPaths(cfg, nil)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "GF_TEST_UNSET_DIR is not set")
+		})
+
+		Convey("should keep windows paths", func() {
+			cfg.Options["path"] = `C:\grafana\dashboards\team-a`
+
+			paths, err := providerPaths(cfg, nil)
+			So(err, ShouldBeNil)
+			So(paths, ShouldResemble, []string{`C:\grafana\dashboards\team-a`})
+		})
+
+		Convey("should fail for unset variables in paths of version 1 config files", func() {
+			dir, err := ioutil.TempDir("", "provisioning-paths")
+			So(err, ShouldBeNil)
+			defer os.RemoveAll(dir)
+
+			config := filepath.Join(dir, "dashboards.yaml")
+			content := "apiVersion: 1\nproviders:\n- name: default\n  type: file\n  options:\n    path: ${GF_TEST_UNSET_DIR}/team-a\n"
+			So(ioutil.WriteFile(config, []byte(content), 0644), ShouldBeNil)
+
+			configs, err := ReadConfigFile(config, log.New("test-logger"))
+			So(err, ShouldBeNil)
+			So(configs[0].Validate(), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestProviderPathsRelativeToConfigFile(t *testing.T) {
+	Convey("Resolving provider paths against the config file", t, func() {
+		dir, err := ioutil.TempDir("", "provisioning-config")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		config := filepath.Join(dir, "dashboards.yaml")
+		content := "apiVersion: 1\nproviders:\n- name: relative\n  type: file\n  options:\n    path: ./dashboards\n" +
+			"- name: absolute\n  type: file\n  options:\n    path: /var/lib/grafana/dashboards\n" +
+			"- name: legacy\n  type: file\n  options:\n    path: " + oneDashboard + "\n"
+		So(ioutil.WriteFile(config, []byte(content), 0644), ShouldBeNil)
+
+		configs, err := ReadConfigFile(config, log.New("test-logger"))
+		So(err, ShouldBeNil)
+		So(len(configs), ShouldEqual, 3)
+
+		Convey("should resolve relative paths against the directory of the config file", func() {
+			paths, err := providerPaths(configs[0], nil)
+			So(err, ShouldBeNil)
+			So(paths, ShouldResemble, []string{filepath.Join(dir, "dashboards")})
+		})
+
+		Convey("should keep absolute paths", func() {
+			if runtime.GOOS == "windows" {
+				return
+			}
+
+			paths, err := providerPaths(configs[1], nil)
+			So(err, ShouldBeNil)
+			So(paths, ShouldResemble, []string{"/var/lib/grafana/dashboards"})
+		})
+
+		Convey("should warn about relative paths only found relative to the working directory and use them", func() {
+			recorder := &recordingLogger{Logger: log.New("test-logger")}
+			paths, err := providerPaths(configs[2], recorder)
+			So(err, ShouldBeNil)
+			So(paths, ShouldResemble, []string{oneDashboard})
+			So(len(recorder.messages), ShouldEqual, 1)
+
+			recorder.messages = nil
+			paths, err = providerPaths(configs[0], recorder)
+			So(err, ShouldBeNil)
+			So(paths, ShouldResemble, []string{filepath.Join(dir, "dashboards")})
+			So(recorder.messages, ShouldBeEmpty)
+		})
+
+		Convey("should keep relative paths of providers not read from a file", func() {
+			cfg := &DashboardsAsConfig{Name: "Default", Type: "file", Options: map[string]interface{}{"path": "dashboards"}}
+			paths, err := providerPaths(cfg, nil)
+			So(err, ShouldBeNil)
+			So(paths, ShouldResemble, []string{"dashboards"})
+		})
+	})
+}
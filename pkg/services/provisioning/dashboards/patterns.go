@@ -0,0 +1,59 @@
+package dashboards
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+// globPattern matches paths of dashboard files relative to the provider path, using forward slashes. * and ? don't
+// match a slash while ** does. Patterns without a slash are matched against the file name only, so prod-*.json
+// matches files in every directory. Patterns ending with a slash only match directories.
+type globPattern struct {
+	glob      glob.Glob
+	matchBase bool
+	dirOnly   bool
+}
+
+// compileGlobPatterns compiles the patterns of an option, failing on the first invalid one.
+func compileGlobPatterns(option string, patterns []string) ([]*globPattern, error) {
+	var compiled []*globPattern
+	for _, pattern := range patterns {
+		dirOnly := strings.HasSuffix(pattern, "/")
+		trimmed := strings.TrimSuffix(pattern, "/")
+		g, err := glob.Compile(trimmed, '/')
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern %q: %v", option, pattern, err)
+		}
+		compiled = append(compiled, &globPattern{glob: g, matchBase: !strings.Contains(trimmed, "/"), dirOnly: dirOnly})
+	}
+	return compiled, nil
+}
+
+func (p *globPattern) match(rel string) bool {
+	if p.matchBase {
+		return p.glob.Match(filepath.Base(filepath.FromSlash(rel)))
+	}
+	return p.glob.Match(rel)
+}
+
+// matchesAnyPattern returns true if the path, relative to root, matches one of the patterns.
+func matchesAnyPattern(patterns []*globPattern, root string, path string, isDir bool) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range patterns {
+		if pattern.dirOnly && !isDir {
+			continue
+		}
+		if pattern.match(rel) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package dashboards
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGlobPatterns(t *testing.T) {
+	Convey("Glob patterns", t, func() {
+		patterns, err := compileGlobPatterns("includePatterns", []string{"prod-*.json", "shared/**.json", "team/?/*.json"})
+		So(err, ShouldBeNil)
+
+		matches := func(rel string) bool {
+			return matchesAnyPattern(patterns, "/dashboards", "/dashboards/"+rel, false)
+		}
+
+		Convey("should match patterns without slash against the file name", func() {
+			So(matches("prod-api.json"), ShouldBeTrue)
+			So(matches("nested/prod-api.json"), ShouldBeTrue)
+			So(matches("dev-api.json"), ShouldBeFalse)
+		})
+
+		Convey("should let ** but not * match across directories", func() {
+			So(matches("shared/network.json"), ShouldBeTrue)
+			So(matches("shared/nested/disk.json"), ShouldBeTrue)
+			So(matches("team/a/overview.json"), ShouldBeTrue)
+			So(matches("team/a/b/overview.json"), ShouldBeFalse)
+			So(matches("team/ab/overview.json"), ShouldBeFalse)
+		})
+
+		Convey("should only match directories with pattern ending with slash", func() {
+			excludes, err := compileGlobPatterns("excludePatterns", []string{"drafts/"})
+			So(err, ShouldBeNil)
+
+			So(matchesAnyPattern(excludes, "/dashboards", "/dashboards/team/drafts", true), ShouldBeTrue)
+			So(matchesAnyPattern(excludes, "/dashboards", "/dashboards/drafts", false), ShouldBeFalse)
+		})
+
+		Convey("should reject invalid pattern", func() {
+			_, err := compileGlobPatterns("includePatterns", []string{"[a-"})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
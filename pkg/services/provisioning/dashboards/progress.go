@@ -0,0 +1,38 @@
+package dashboards
+
+// ScanOptions changes the behaviour of a single scan triggered for example through the reload API.
+type ScanOptions struct {
+	// SkipDelete makes the scan only insert and update dashboards. Dashboards missing on disk are neither deleted
+	// nor unprovisioned regardless of the DisableDeletion setting of the provider.
+	SkipDelete bool
+	// Progress receives a ProgressEvent for each processed file if set. Events are dropped rather than blocking the
+	// scan when the channel is full. The channel is not closed by the scan.
+	Progress chan<- ProgressEvent
+
+	// atomic is set when the provider is scanned as part of a transaction group. The first dashboard failing to
+	// save aborts the scan.
+	atomic bool
+}
+
+// ProgressEvent is sent for every dashboard file processed during a scan so callers like the reload API can report
+// progress of long running scans.
+type ProgressEvent struct {
+	Provider  string
+	Path      string
+	Processed int
+	Total     int
+	Error     error
+}
+
+// reportProgress sends the event without blocking. A slow consumer only loses events, it never stalls the scan.
+func (fr *fileReader) reportProgress(progress chan<- ProgressEvent, event ProgressEvent) {
+	if progress == nil {
+		return
+	}
+
+	select {
+	case progress <- event:
+	default:
+		fr.log.Debug("dropped provisioning progress event", "file", event.Path)
+	}
+}
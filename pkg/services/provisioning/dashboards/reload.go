@@ -0,0 +1,101 @@
+package dashboards
+
+import (
+	"context"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// scanCoalescer runs the scans of a provider one at a time. A scan requested while another is running can't reuse
+// it, the running scan may have read the files before they changed, so one more scan follows it. All requests
+// made while a scan is running share that follow-up scan instead of queueing up one scan each.
+type scanCoalescer struct {
+	mu      sync.Mutex
+	running bool
+	next    *coalescedScan
+}
+
+// coalescedScan is a scan waiting for the running scan to finish, along with its result once it ran.
+type coalescedScan struct {
+	scan func() error
+	done chan struct{}
+	err  error
+}
+
+// run runs the scan, or waits for the follow-up of the running scan, and returns the error of the scan that ran.
+func (c *scanCoalescer) run(scan func() error) error {
+	c.mu.Lock()
+	if c.running {
+		if c.next == nil {
+			c.next = &coalescedScan{scan: scan, done: make(chan struct{})}
+		}
+		next := c.next
+		c.mu.Unlock()
+
+		<-next.done
+		return next.err
+	}
+	c.running = true
+	c.mu.Unlock()
+
+	err := scan()
+	c.runFollowUps()
+	return err
+}
+
+// runFollowUps runs the scans requested while the previous one was running until none is left.
+func (c *scanCoalescer) runFollowUps() {
+	for {
+		c.mu.Lock()
+		next := c.next
+		c.next = nil
+		if next == nil {
+			c.running = false
+			c.mu.Unlock()
+			return
+		}
+		c.mu.Unlock()
+
+		next.err = next.scan()
+		close(next.done)
+	}
+}
+
+// Reload scans the provider right away instead of waiting for its next interval. It doesn't overlap a scan already
+// running, it waits for it and scans once more afterwards, sharing that scan with other reloads made in the meantime.
+func (fr *fileReader) Reload(ctx context.Context) error {
+	return fr.scans.run(func() error {
+		return fr.startWalkingDisk(ctx)
+	})
+}
+
+// Reload scans all providers right away like Reload of a single provider, transaction groups as a whole. Returns
+// the first error, but still scans the remaining providers.
+func (provider *DashboardProvisionerImpl) Reload(ctx context.Context) error {
+	var firstErr error
+	groups := provider.transactionGroups()
+	for _, reader := range provider.fileReaders {
+		var err error
+		if name := reader.Cfg.TransactionGroup; name != "" {
+			if readers := groups[name]; readers[0] == reader {
+				err = reloadTransactionGroup(ctx, name, readers)
+			}
+		} else if err = reader.Reload(ctx); err != nil {
+			err = errutil.Wrapf(err, "Failed to provision config %v", reader.Cfg.Name)
+		}
+
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// reloadTransactionGroup provisions the transaction group right away. The scans of a group run one at a time like
+// the scans of its first provider.
+func reloadTransactionGroup(ctx context.Context, name string, readers []*fileReader) error {
+	return readers[0].scans.run(func() error {
+		return provisionTransactionGroup(ctx, name, readers, ScanOptions{})
+	})
+}
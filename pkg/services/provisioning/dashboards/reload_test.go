@@ -0,0 +1,133 @@
+package dashboards
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestScanCoalescer(t *testing.T) {
+	Convey("Coalescing scans", t, func() {
+		coalescer := &scanCoalescer{}
+		var scans int32
+		var running int32
+		var overlapped int32
+		started := make(chan struct{})
+		release := make(chan struct{})
+
+		blockingScan := func() error {
+			atomic.AddInt32(&scans, 1)
+			started <- struct{}{}
+			<-release
+			return nil
+		}
+		scan := func() error {
+			if atomic.AddInt32(&running, 1) > 1 {
+				atomic.StoreInt32(&overlapped, 1)
+			}
+			defer atomic.AddInt32(&running, -1)
+			atomic.AddInt32(&scans, 1)
+			return errors.New("scan failed")
+		}
+
+		go func() { _ = coalescer.run(blockingScan) }()
+		<-started
+
+		Convey("should run one follow-up scan for all requests made during a scan", func() {
+			var wg sync.WaitGroup
+			errs := make([]error, 5)
+			for i := range errs {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					errs[i] = coalescer.run(scan)
+				}(i)
+			}
+
+			// wait until all requests joined the follow-up scan
+			for {
+				coalescer.mu.Lock()
+				joined := coalescer.next != nil
+				coalescer.mu.Unlock()
+				if joined {
+					break
+				}
+			}
+			close(release)
+			wg.Wait()
+
+			So(atomic.LoadInt32(&scans), ShouldBeBetweenOrEqual, 2, 3)
+			So(atomic.LoadInt32(&overlapped), ShouldEqual, 0)
+			for _, err := range errs {
+				So(err, ShouldNotBeNil)
+			}
+		})
+
+		Convey("should run requests made after the scan right away", func() {
+			close(release)
+			for {
+				coalescer.mu.Lock()
+				running := coalescer.running
+				coalescer.mu.Unlock()
+				if !running {
+					break
+				}
+			}
+
+			So(coalescer.run(scan), ShouldNotBeNil)
+			So(atomic.LoadInt32(&scans), ShouldEqual, 2)
+		})
+	})
+}
+
+func TestReload(t *testing.T) {
+	Convey("Reloading providers", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		cfg := &DashboardsAsConfig{
+			Name:    "Default",
+			Type:    "file",
+			OrgId:   1,
+			Options: map[string]interface{}{"path": defaultDashboards},
+		}
+		reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+		So(err, ShouldBeNil)
+		provisioner := &DashboardProvisionerImpl{log: log.New("test-logger"), fileReaders: []*fileReader{reader}}
+
+		Convey("should scan the providers right away", func() {
+			So(provisioner.Reload(context.Background()), ShouldBeNil)
+			So(len(fakeService.inserted), ShouldEqual, 2)
+		})
+
+		Convey("should not overlap concurrent reloads", func() {
+			var wg sync.WaitGroup
+			errs := make([]error, 5)
+			for i := range errs {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					errs[i] = reader.Reload(context.Background())
+				}(i)
+			}
+			wg.Wait()
+
+			for _, err := range errs {
+				So(err, ShouldBeNil)
+			}
+			So(len(fakeService.inserted), ShouldEqual, 2)
+		})
+	})
+}
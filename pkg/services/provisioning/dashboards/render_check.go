@@ -0,0 +1,171 @@
+package dashboards
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/rendering"
+)
+
+const (
+	renderCheckTimeout         = 30 * time.Second
+	renderCheckConcurrentLimit = 5
+	renderCheckWidth           = 1000
+	renderCheckHeight          = 500
+)
+
+// renderChecker renders saved dashboards in the background for the renderCheck option, so a slow renderer doesn't
+// hold up the scan, and keeps the dashboards failing to render until they are saved again or removed. Dashboards are
+// tracked by id, so the org readers of a provider share one checker.
+type renderChecker struct {
+	service rendering.Service
+	log     log.Logger
+	pending sync.WaitGroup
+
+	mu sync.Mutex
+	// queue holds the checks waiting for one of at most renderCheckConcurrentLimit workers.
+	queue   []renderCheck
+	workers int
+	// checks is the latest check started for each dashboard, the results of older checks are dropped.
+	checks    map[int64]int64
+	lastCheck int64
+	unhealthy map[int64]UnhealthyDashboard
+}
+
+// renderCheck is a queued render of a saved dashboard.
+type renderCheck struct {
+	id    int64
+	check int64
+	path  string
+	uid   string
+	opts  rendering.Opts
+}
+
+func newRenderChecker(service rendering.Service, logger log.Logger) *renderChecker {
+	return &renderChecker{
+		service:   service,
+		log:       logger,
+		checks:    map[int64]int64{},
+		unhealthy: map[int64]UnhealthyDashboard{},
+	}
+}
+
+// check drops the earlier result of the dashboard and queues it to be rendered in the background. Nothing is checked
+// if no renderer is configured.
+func (rc *renderChecker) check(path string, dash *models.Dashboard) {
+	if rc.service == nil {
+		rc.log.Debug("skipping render check, no rendering service", "file", path)
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.lastCheck++
+	rc.checks[dash.Id] = rc.lastCheck
+	delete(rc.unhealthy, dash.Id)
+
+	rc.queue = append(rc.queue, renderCheck{
+		id:    dash.Id,
+		check: rc.lastCheck,
+		path:  path,
+		uid:   dash.Uid,
+		opts: rendering.Opts{
+			Width:           renderCheckWidth,
+			Height:          renderCheckHeight,
+			Timeout:         renderCheckTimeout,
+			OrgId:           dash.OrgId,
+			OrgRole:         models.ROLE_ADMIN,
+			Path:            fmt.Sprintf("d/%s/%s?orgId=%d", dash.Uid, dash.Slug, dash.OrgId),
+			ConcurrentLimit: renderCheckConcurrentLimit,
+		},
+	})
+	if rc.workers < renderCheckConcurrentLimit {
+		rc.workers++
+		rc.pending.Add(1)
+		go rc.work()
+	}
+}
+
+// work runs queued checks until the queue is empty. Checks of dashboards saved again or removed while queued are
+// skipped.
+func (rc *renderChecker) work() {
+	defer rc.pending.Done()
+
+	for {
+		rc.mu.Lock()
+		if len(rc.queue) == 0 {
+			rc.workers--
+			rc.mu.Unlock()
+			return
+		}
+		next := rc.queue[0]
+		rc.queue = rc.queue[1:]
+		current := rc.checks[next.id] == next.check
+		rc.mu.Unlock()
+
+		if current {
+			rc.run(next)
+		}
+	}
+}
+
+func (rc *renderChecker) run(check renderCheck) {
+	err := rc.render(check.opts)
+	switch err {
+	case nil:
+		return
+	case rendering.ErrNoRenderer, rendering.ErrPhantomJSNotInstalled:
+		rc.log.Debug("skipping render check, no renderer configured", "file", check.path)
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.checks[check.id] != check.check {
+		// saved again or removed while rendering
+		return
+	}
+	rc.log.Error("dashboard failed to render", "file", check.path, "uid", check.uid, "error", err)
+	rc.unhealthy[check.id] = UnhealthyDashboard{Path: check.path, Uid: check.uid, Error: err.Error()}
+}
+
+func (rc *renderChecker) render(opts rendering.Opts) error {
+	ctx, cancel := context.WithTimeout(context.Background(), renderCheckTimeout)
+	defer cancel()
+
+	_, err := rc.service.Render(ctx, opts)
+	return err
+}
+
+// forget drops the dashboard once it is no longer provisioned, including the result of a check still running.
+func (rc *renderChecker) forget(dashboardId int64) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	delete(rc.checks, dashboardId)
+	delete(rc.unhealthy, dashboardId)
+}
+
+// wait waits for the queued and running checks to finish.
+func (rc *renderChecker) wait() {
+	rc.pending.Wait()
+}
+
+// list returns the dashboards failing to render sorted by path.
+func (rc *renderChecker) list() []UnhealthyDashboard {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	unhealthy := make([]UnhealthyDashboard, 0, len(rc.unhealthy))
+	for _, dashboard := range rc.unhealthy {
+		unhealthy = append(unhealthy, dashboard)
+	}
+	sort.Slice(unhealthy, func(i, j int) bool { return unhealthy[i].Path < unhealthy[j].Path })
+	return unhealthy
+}
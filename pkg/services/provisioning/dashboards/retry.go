@@ -0,0 +1,111 @@
+package dashboards
+
+import (
+	"database/sql/driver"
+	"net"
+	"time"
+
+	"github.com/VividCortex/mysqlerr"
+	"github.com/go-sql-driver/mysql"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// defaultSaveRetryBackoff is the wait before the first retry of a save when saveRetryBackoffMillis is not set.
+const defaultSaveRetryBackoff = 500 * time.Millisecond
+
+// retryingProvisioningService retries saving dashboards and folders failing with a transient database error, for
+// example while the database fails over. Errors that won't go away by saving again, like invalid dashboards, are
+// returned right away. The wait between attempts starts at backoff and doubles with every retry.
+type retryingProvisioningService struct {
+	dashboards.DashboardProvisioningService
+
+	retries int
+	backoff time.Duration
+	log     log.Logger
+	sleep   func(time.Duration)
+}
+
+func newRetryingProvisioningService(service dashboards.DashboardProvisioningService, retries int, backoff time.Duration, log log.Logger) *retryingProvisioningService {
+	if backoff <= 0 {
+		backoff = defaultSaveRetryBackoff
+	}
+
+	return &retryingProvisioningService{
+		DashboardProvisioningService: service,
+		retries:                      retries,
+		backoff:                      backoff,
+		log:                          log,
+		sleep:                        time.Sleep,
+	}
+}
+
+func (s *retryingProvisioningService) SaveProvisionedDashboard(dto *dashboards.SaveDashboardDTO, provisioning *models.DashboardProvisioning) (*models.Dashboard, error) {
+	var saved *models.Dashboard
+	err := s.retry("dashboard", dto.Dashboard.Title, func() error {
+		var err error
+		saved, err = s.DashboardProvisioningService.SaveProvisionedDashboard(dto, provisioning)
+		return err
+	})
+	return saved, err
+}
+
+func (s *retryingProvisioningService) SaveFolderForProvisionedDashboards(dto *dashboards.SaveDashboardDTO) (*models.Dashboard, error) {
+	var saved *models.Dashboard
+	err := s.retry("folder", dto.Dashboard.Title, func() error {
+		var err error
+		saved, err = s.DashboardProvisioningService.SaveFolderForProvisionedDashboards(dto)
+		return err
+	})
+	return saved, err
+}
+
+// retry runs save until it succeeds, fails with an error that isn't transient or all retries are used up.
+func (s *retryingProvisioningService) retry(kind string, title string, save func() error) error {
+	backoff := s.backoff
+	for attempt := 0; ; attempt++ {
+		err := save()
+		if err == nil || attempt >= s.retries || !isTransientError(err) {
+			return err
+		}
+
+		s.log.Warn("saving failed with a transient error, retrying", "kind", kind, "title", title,
+			"attempt", attempt+1, "retryIn", backoff, "error", err)
+		s.sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// isTransientError returns true if err is a database error that may go away by trying again: lost connections,
+// locked sqlite databases, deadlocks and lock timeouts.
+func isTransientError(err error) bool {
+	if err == driver.ErrBadConn || err == mysql.ErrInvalidConn {
+		return true
+	}
+
+	switch e := err.(type) {
+	case sqlite3.Error:
+		return e.Code == sqlite3.ErrBusy || e.Code == sqlite3.ErrLocked
+	case *mysql.MySQLError:
+		return e.Number == mysqlerr.ER_LOCK_DEADLOCK || e.Number == mysqlerr.ER_LOCK_WAIT_TIMEOUT
+	case *pq.Error:
+		return e.Code.Class() == "08" || transientPostgresCodes[e.Code]
+	case net.Error:
+		return true
+	}
+
+	return false
+}
+
+// transientPostgresCodes are the postgres errors besides connection exceptions that may go away by trying again:
+// serialization failures, deadlocks and the server shutting down or starting up.
+var transientPostgresCodes = map[pq.ErrorCode]bool{
+	"40001": true,
+	"40P01": true,
+	"57P01": true,
+	"57P02": true,
+	"57P03": true,
+}
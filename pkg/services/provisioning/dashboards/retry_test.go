@@ -0,0 +1,136 @@
+package dashboards
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// flakyProvisioningService fails the first saves of dashboards and folders with the configured errors.
+type flakyProvisioningService struct {
+	dashboards.DashboardProvisioningService
+	dashboardErrors []error
+	folderErrors    []error
+	dashboardSaves  int
+	folderSaves     int
+}
+
+func (s *flakyProvisioningService) SaveProvisionedDashboard(dto *dashboards.SaveDashboardDTO, provisioning *models.DashboardProvisioning) (*models.Dashboard, error) {
+	s.dashboardSaves++
+	if len(s.dashboardErrors) > 0 {
+		err := s.dashboardErrors[0]
+		s.dashboardErrors = s.dashboardErrors[1:]
+		return nil, err
+	}
+	return s.DashboardProvisioningService.SaveProvisionedDashboard(dto, provisioning)
+}
+
+func (s *flakyProvisioningService) SaveFolderForProvisionedDashboards(dto *dashboards.SaveDashboardDTO) (*models.Dashboard, error) {
+	s.folderSaves++
+	if len(s.folderErrors) > 0 {
+		err := s.folderErrors[0]
+		s.folderErrors = s.folderErrors[1:]
+		return nil, err
+	}
+	return s.DashboardProvisioningService.SaveFolderForProvisionedDashboards(dto)
+}
+
+func TestRetryingSaves(t *testing.T) {
+	Convey("Retrying saves failing with transient errors", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		flaky := &flakyProvisioningService{DashboardProvisioningService: fakeService}
+		dashboards.NewProvisioningService = func() dashboards.DashboardProvisioningService {
+			return flaky
+		}
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		cfg := &DashboardsAsConfig{
+			Name:    "Default",
+			Type:    "file",
+			OrgId:   1,
+			Options: map[string]interface{}{"path": oneDashboard, "saveRetries": 2, "saveRetryBackoffMillis": 1},
+		}
+
+		Convey("should save dashboards after a transient error", func() {
+			flaky.dashboardErrors = []error{sqlite3.Error{Code: sqlite3.ErrBusy}}
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+			So(flaky.dashboardSaves, ShouldEqual, 2)
+			So(len(fakeService.inserted), ShouldEqual, 1)
+		})
+
+		Convey("should create folders after a transient error", func() {
+			cfg.Folder = "Retried"
+			flaky.folderErrors = []error{driver.ErrBadConn}
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+			So(flaky.folderSaves, ShouldEqual, 2)
+			So(len(fakeService.inserted), ShouldEqual, 2)
+		})
+
+		Convey("should give up after the configured retries", func() {
+			transient := &pq.Error{Code: "57P01"}
+			flaky.dashboardErrors = []error{transient, transient, transient, transient}
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+			So(flaky.dashboardSaves, ShouldEqual, 3)
+			So(fakeService.inserted, ShouldBeEmpty)
+		})
+
+		Convey("should not retry permanent errors", func() {
+			flaky.dashboardErrors = []error{models.ErrDashboardTitleEmpty}
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+			So(flaky.dashboardSaves, ShouldEqual, 1)
+			So(fakeService.inserted, ShouldBeEmpty)
+		})
+
+		Convey("should not retry without saveRetries", func() {
+			delete(cfg.Options, "saveRetries")
+			flaky.dashboardErrors = []error{sqlite3.Error{Code: sqlite3.ErrBusy}}
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+			So(flaky.dashboardSaves, ShouldEqual, 1)
+		})
+
+		Convey("should reject negative retries", func() {
+			cfg.Options["saveRetries"] = -1
+			_, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestIsTransientError(t *testing.T) {
+	Convey("Telling transient from permanent errors", t, func() {
+		So(isTransientError(driver.ErrBadConn), ShouldBeTrue)
+		So(isTransientError(sqlite3.Error{Code: sqlite3.ErrLocked}), ShouldBeTrue)
+		So(isTransientError(&pq.Error{Code: "08006"}), ShouldBeTrue)
+		So(isTransientError(&pq.Error{Code: "23505"}), ShouldBeFalse)
+		So(isTransientError(sqlite3.Error{Code: sqlite3.ErrConstraint}), ShouldBeFalse)
+		So(isTransientError(models.ErrDashboardWithSameUIDExists), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,141 @@
+package dashboards
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// s3SourceType is the type of providers downloading dashboards from a S3 bucket.
+const s3SourceType = "s3"
+
+// s3Client is the part of the S3 api used by s3Source.
+type s3Client interface {
+	ListObjectsV2Pages(input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool) error
+	GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error)
+}
+
+// s3Source downloads the dashboard objects below a prefix of a bucket into its directory, keeping their key relative
+// to the prefix as path. Objects are only downloaded again when their ETag changes.
+type s3Source struct {
+	client  s3Client
+	bucket  string
+	prefix  string
+	workDir string
+	formats []*dashboardFileFormat
+	log     log.Logger
+	// etags are the ETags of the downloaded objects by key.
+	etags map[string]string
+}
+
+// newS3Source creates the source for the bucket, prefix and region options. Credentials are taken from the accessKey
+// and secretKey options if set, and from the standard AWS credential chain otherwise.
+func newS3Source(cfg *DashboardsAsConfig, workDir string, formats []*dashboardFileFormat, log log.Logger) (*s3Source, error) {
+	bucket := getStringOption(cfg.Options, "bucket")
+	if bucket == "" {
+		return nil, fmt.Errorf("bucket is missing")
+	}
+
+	awsCfg := aws.Config{}
+	if region := getStringOption(cfg.Options, "region"); region != "" {
+		awsCfg.Region = aws.String(region)
+	}
+	if endpoint := getStringOption(cfg.Options, "endpoint"); endpoint != "" {
+		awsCfg.Endpoint = aws.String(endpoint)
+		awsCfg.S3ForcePathStyle = aws.Bool(true)
+	}
+	accessKey := getStringOption(cfg.Options, "accessKey")
+	secretKey := getStringOption(cfg.Options, "secretKey")
+	if accessKey != "" || secretKey != "" {
+		awsCfg.Credentials = credentials.NewStaticCredentials(accessKey, secretKey, "")
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            awsCfg,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 session: %v", err)
+	}
+
+	return &s3Source{
+		client:  s3.New(sess),
+		bucket:  bucket,
+		prefix:  getStringOption(cfg.Options, "prefix"),
+		workDir: workDir,
+		formats: formats,
+		log:     log,
+		etags:   map[string]string{},
+	}, nil
+}
+
+func (s *s3Source) dir() string {
+	return s.workDir
+}
+
+// sync downloads new and changed dashboard objects and removes the files of deleted ones. When the bucket can't be
+// listed nothing is changed. Objects failing to download keep their last copy and are tried again on the next sync.
+func (s *s3Source) sync() error {
+	var objects []*s3.Object
+	input := &s3.ListObjectsV2Input{Bucket: aws.String(s.bucket), Prefix: aws.String(s.prefix)}
+	err := s.client.ListObjectsV2Pages(input, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		objects = append(objects, page.Contents...)
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list objects of bucket %s: %v", s.bucket, err)
+	}
+
+	if err := os.MkdirAll(s.workDir, 0750); err != nil {
+		return err
+	}
+
+	listed := map[string]bool{}
+	for _, object := range objects {
+		key := aws.StringValue(object.Key)
+		if formatForFile(s.formats, key) == nil {
+			continue
+		}
+
+		target, err := pathWithinDir(s.workDir, strings.TrimPrefix(strings.TrimPrefix(key, s.prefix), "/"))
+		if err != nil {
+			s.log.Warn("skipping object", "key", key, "error", err)
+			continue
+		}
+		listed[target] = true
+
+		etag := aws.StringValue(object.ETag)
+		if _, err := os.Stat(target); err == nil && etag != "" && s.etags[key] == etag {
+			continue
+		}
+
+		if err := s.download(key, target); err != nil {
+			s.log.Error("failed to download dashboard, keeping the last copy", "key", key, "error", err)
+			continue
+		}
+		s.etags[key] = etag
+	}
+
+	return removeUnlistedFiles(s.workDir, listed, s.log)
+}
+
+func (s *s3Source) download(key string, target string) error {
+	output, err := s.client.GetObject(&s3.GetObjectInput{Bucket: aws.String(s.bucket), Key: aws.String(key)})
+	if err != nil {
+		return err
+	}
+	defer output.Body.Close()
+
+	content, err := ioutil.ReadAll(output.Body)
+	if err != nil {
+		return err
+	}
+	return replaceFile(target, content)
+}
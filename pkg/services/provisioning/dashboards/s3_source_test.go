@@ -0,0 +1,145 @@
+package dashboards
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeS3Client serves objects by key with their content as ETag, counting the downloads of each key.
+type fakeS3Client struct {
+	objects   map[string]string
+	downloads map[string]int
+	listErr   error
+}
+
+func (c *fakeS3Client) ListObjectsV2Pages(input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool) error {
+	if c.listErr != nil {
+		return c.listErr
+	}
+
+	var keys []string
+	for key := range c.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// one page per object to exercise paging
+	for i, key := range keys {
+		object := &s3.Object{Key: aws.String(key), ETag: aws.String(`"` + c.objects[key] + `"`)}
+		if !fn(&s3.ListObjectsV2Output{Contents: []*s3.Object{object}}, i == len(keys)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (c *fakeS3Client) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	key := aws.StringValue(input.Key)
+	content, ok := c.objects[key]
+	if !ok {
+		return nil, errors.New("NoSuchKey")
+	}
+	c.downloads[key]++
+	return &s3.GetObjectOutput{Body: ioutil.NopCloser(bytes.NewBufferString(content))}, nil
+}
+
+func TestS3Source(t *testing.T) {
+	Convey("Dashboards from a S3 bucket", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		dir, err := ioutil.TempDir("", "provisioning-s3")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		opts := ReaderOptions{WorkDir: dir}
+
+		client := &fakeS3Client{
+			objects: map[string]string{
+				"grafana/nodes.json":     `{"title": "Nodes", "uid": "nodes"}`,
+				"grafana/team/pods.json": `{"title": "Pods", "uid": "pods"}`,
+				"grafana/README.md":      "not a dashboard",
+			},
+			downloads: map[string]int{},
+		}
+
+		cfg := &DashboardsAsConfig{
+			Name:    "Bucket",
+			Type:    "s3",
+			OrgId:   1,
+			Options: map[string]interface{}{"bucket": "dashboards", "prefix": "grafana/", "region": "eu-west-1"},
+		}
+		reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), opts)
+		So(err, ShouldBeNil)
+		reader.source.(*s3Source).client = client
+		So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+		provisionedIds := func() []string {
+			var ids []string
+			for _, p := range fakeService.provisioned["Bucket"] {
+				rel, err := filepath.Rel(reader.resolvedPath(), p.ExternalId)
+				So(err, ShouldBeNil)
+				ids = append(ids, filepath.ToSlash(rel))
+			}
+			sort.Strings(ids)
+			return ids
+		}
+
+		Convey("should provision the dashboards below the prefix by their key relative to it", func() {
+			So(provisionedIds(), ShouldResemble, []string{"nodes.json", "team/pods.json"})
+			So(client.downloads["grafana/README.md"], ShouldEqual, 0)
+		})
+
+		Convey("should only download objects with a changed ETag", func() {
+			client.objects["grafana/nodes.json"] = `{"title": "Nodes v2", "uid": "nodes"}`
+			// scans are seconds apart, the download must not look as old as the saved dashboard
+			for _, p := range fakeService.provisioned["Bucket"] {
+				p.Updated -= 60
+			}
+			fakeService.inserted = nil
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(client.downloads["grafana/nodes.json"], ShouldEqual, 2)
+			So(client.downloads["grafana/team/pods.json"], ShouldEqual, 1)
+			So(len(fakeService.inserted), ShouldEqual, 1)
+			So(fakeService.inserted[0].Dashboard.Title, ShouldEqual, "Nodes v2")
+		})
+
+		Convey("should remove dashboards of deleted objects", func() {
+			delete(client.objects, "grafana/team/pods.json")
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(provisionedIds(), ShouldResemble, []string{"nodes.json"})
+		})
+
+		Convey("should keep dashboards when the bucket can't be listed", func() {
+			client.listErr = errors.New("AccessDenied")
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(provisionedIds(), ShouldResemble, []string{"nodes.json", "team/pods.json"})
+		})
+	})
+
+	Convey("S3 source should require a bucket", t, func() {
+		cfg := &DashboardsAsConfig{Name: "Bucket", Type: "s3", Options: map[string]interface{}{}}
+		_, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,260 @@
+package dashboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// jsonSchema is the subset of json schema needed to describe dashboards: type, required, properties, items, enum,
+// minimum and references to definitions of the root schema.
+type jsonSchema struct {
+	Type        schemaTypes            `json:"type"`
+	Required    []string               `json:"required"`
+	Properties  map[string]*jsonSchema `json:"properties"`
+	Items       *jsonSchema            `json:"items"`
+	Enum        []interface{}          `json:"enum"`
+	Minimum     *float64               `json:"minimum"`
+	Ref         string                 `json:"$ref"`
+	Definitions map[string]*jsonSchema `json:"definitions"`
+
+	// propertyNames are the names of properties in sorted order, so errors are always reported in the same order
+	propertyNames []string
+}
+
+// schemaTypes are the types of a schema, written as a single type or a list of types.
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+const (
+	// schemaDefinitionRefPrefix is the prefix of references to definitions of the root schema.
+	schemaDefinitionRefPrefix = "#/definitions/"
+	// maxReportedSchemaViolations is the number of violations listed in the error of a dashboard.
+	maxReportedSchemaViolations = 10
+)
+
+var (
+	compiledDashboardSchema    *jsonSchema
+	compiledDashboardSchemaErr error
+	compileDashboardSchemaOnce sync.Once
+)
+
+// getDashboardSchema returns the bundled dashboard schema, compiling it on first use.
+func getDashboardSchema() (*jsonSchema, error) {
+	compileDashboardSchemaOnce.Do(func() {
+		compiledDashboardSchema, compiledDashboardSchemaErr = compileSchema(dashboardSchema)
+	})
+	return compiledDashboardSchema, compiledDashboardSchemaErr
+}
+
+// compileSchema parses the schema and replaces references by the definitions they point to, so validating doesn't
+// need to look them up.
+func compileSchema(source string) (*jsonSchema, error) {
+	var root jsonSchema
+	if err := json.Unmarshal([]byte(source), &root); err != nil {
+		return nil, err
+	}
+
+	compiled := map[*jsonSchema]bool{}
+	var compile func(schema *jsonSchema) (*jsonSchema, error)
+	compile = func(schema *jsonSchema) (*jsonSchema, error) {
+		if schema == nil {
+			return nil, nil
+		}
+		if schema.Ref != "" {
+			name := strings.TrimPrefix(schema.Ref, schemaDefinitionRefPrefix)
+			definition, ok := root.Definitions[name]
+			if !ok || name == schema.Ref {
+				return nil, fmt.Errorf("unknown schema reference %q", schema.Ref)
+			}
+			schema = definition
+		}
+		if compiled[schema] {
+			return schema, nil
+		}
+		compiled[schema] = true
+
+		var err error
+		for name, property := range schema.Properties {
+			if schema.Properties[name], err = compile(property); err != nil {
+				return nil, err
+			}
+			schema.propertyNames = append(schema.propertyNames, name)
+		}
+		sort.Strings(schema.propertyNames)
+		schema.Items, err = compile(schema.Items)
+		return schema, err
+	}
+
+	if _, err := compile(&root); err != nil {
+		return nil, err
+	}
+	return &root, nil
+}
+
+// validateDashboardSchema checks the dashboard against the bundled dashboard schema. Returns an error listing the
+// violations, or nil if there are none.
+func validateDashboardSchema(data *simplejson.Json) error {
+	schema, err := getDashboardSchema()
+	if err != nil {
+		return err
+	}
+
+	violations := schema.validate(data.Interface(), nil, nil)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	more := ""
+	if len(violations) > maxReportedSchemaViolations {
+		more = fmt.Sprintf(" and %d more", len(violations)-maxReportedSchemaViolations)
+		violations = violations[:maxReportedSchemaViolations]
+	}
+	return fmt.Errorf("dashboard doesn't match the dashboard schema: %s%s", strings.Join(violations, ", "), more)
+}
+
+// schemaLocation is the location of a value in the validated json. Locations are only turned into strings for
+// violations, so valid dashboards are checked without building paths.
+type schemaLocation struct {
+	parent *schemaLocation
+	key    string
+	index  int
+}
+
+func (l *schemaLocation) String() string {
+	if l == nil {
+		return "dashboard"
+	}
+	if l.key == "" {
+		return l.parent.String() + "[" + strconv.Itoa(l.index) + "]"
+	}
+	if l.parent == nil {
+		return l.key
+	}
+	return l.parent.String() + "." + l.key
+}
+
+// validate appends the violations of the schema by the value at location to violations.
+func (s *jsonSchema) validate(value interface{}, location *schemaLocation, violations []string) []string {
+	if len(s.Type) > 0 && !s.hasTypeOf(value) {
+		return append(violations, fmt.Sprintf("%s must be %s, got %s", location, strings.Join(s.Type, " or "), jsonTypeOf(value)))
+	}
+
+	if len(s.Enum) > 0 && !s.isInEnum(value) {
+		return append(violations, fmt.Sprintf("%s must be one of %v, got %v", location, s.Enum, value))
+	}
+
+	if s.Minimum != nil {
+		if number, ok := jsonNumber(value); ok && number < *s.Minimum {
+			violations = append(violations, fmt.Sprintf("%s must be at least %v, got %v", location, *s.Minimum, number))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				violations = append(violations, fmt.Sprintf("%s is missing required property %s", location, name))
+			}
+		}
+		for _, name := range s.propertyNames {
+			if property, ok := v[name]; ok {
+				violations = s.Properties[name].validate(property, &schemaLocation{parent: location, key: name}, violations)
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				violations = s.Items.validate(item, &schemaLocation{parent: location, index: i}, violations)
+			}
+		}
+	}
+
+	return violations
+}
+
+func (s *jsonSchema) hasTypeOf(value interface{}) bool {
+	actual := jsonTypeOf(value)
+	for _, t := range s.Type {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *jsonSchema) isInEnum(value interface{}) bool {
+	number, isNumber := jsonNumber(value)
+	for _, allowed := range s.Enum {
+		if allowedNumber, ok := jsonNumber(allowed); ok && isNumber {
+			if number == allowedNumber {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(value, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonTypeOf returns the json schema type of a parsed json value. Numbers without a fraction are integers.
+func jsonTypeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+
+	if number, ok := jsonNumber(value); ok {
+		if number == math.Trunc(number) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// jsonNumber returns the value of a number parsed by simplejson, or set by a transformation.
+func jsonNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		number, err := v.Float64()
+		return number, err == nil
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
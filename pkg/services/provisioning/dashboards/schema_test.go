@@ -0,0 +1,113 @@
+package dashboards
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var schemaDashboards = "testdata/test-dashboards/schema"
+
+func TestDashboardSchema(t *testing.T) {
+	Convey("Validating dashboards against the dashboard schema", t, func() {
+		Convey("should compile the bundled schema", func() {
+			_, err := getDashboardSchema()
+			So(err, ShouldBeNil)
+		})
+
+		Convey("should accept a valid dashboard", func() {
+			So(validateDashboardSchema(readSchemaDashboard(schemaDashboards+"/valid.json")), ShouldBeNil)
+		})
+
+		Convey("should list the violations of an invalid dashboard", func() {
+			err := validateDashboardSchema(readSchemaDashboard(schemaDashboards + "/invalid.json"))
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "dashboard doesn't match the dashboard schema: "+
+				"panels[0].gridPos.h must be integer, got string, "+
+				"panels[1] is missing required property type, "+
+				"tags must be array, got string")
+		})
+
+		Convey("should check nested panels and enums", func() {
+			data := simplejson.NewFromAny(map[string]interface{}{
+				"title": "Nested",
+				"style": "blue",
+				"panels": []interface{}{
+					map[string]interface{}{"type": "row", "panels": []interface{}{
+						map[string]interface{}{"type": "graph", "gridPos": map[string]interface{}{"h": 8, "w": 0, "x": 0, "y": 0}},
+					}},
+				},
+			})
+			err := validateDashboardSchema(data)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "dashboard doesn't match the dashboard schema: "+
+				"panels[0].panels[0].gridPos.w must be at least 1, got 0, "+
+				"style must be one of [dark light], got blue")
+		})
+
+		Convey("should reject unknown references", func() {
+			_, err := compileSchema(`{"items": {"$ref": "#/definitions/missing"}}`)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Reading dashboards with validateSchema", func() {
+			bus.ClearBusHandlers()
+			origNewDashboardProvisioningService := dashboards.NewProvisioningService
+			fakeService = mockDashboardProvisioningService()
+			bus.AddHandler("test", mockGetDashboardQuery)
+			defer func() {
+				dashboards.NewProvisioningService = origNewDashboardProvisioningService
+			}()
+
+			cfg := &DashboardsAsConfig{
+				Name:  "Default",
+				Type:  "file",
+				OrgId: 1,
+				Options: map[string]interface{}{
+					"path":           schemaDashboards,
+					"validateSchema": true,
+				},
+			}
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			Convey("should only save the dashboards matching the schema", func() {
+				So(len(fakeService.inserted), ShouldEqual, 1)
+				So(fakeService.inserted[0].Dashboard.Title, ShouldEqual, "Valid")
+			})
+		})
+	})
+}
+
+func BenchmarkValidateDashboardSchema(b *testing.B) {
+	data := readSchemaDashboard("testdata/test-dashboards/folder-one/dashboard1.json")
+	if _, err := getDashboardSchema(); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := validateDashboardSchema(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func readSchemaDashboard(path string) *simplejson.Json {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		panic(err)
+	}
+	data, err := simplejson.NewJson(content)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -0,0 +1,124 @@
+package dashboards
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	// selfMonitoringDashboardUid is the stable uid of the provisioning health dashboard.
+	selfMonitoringDashboardUid = "grafana-provisioning-health"
+	// selfMonitoringExternalId takes the place of the file path in the provisioning data of the dashboard.
+	selfMonitoringExternalId = "grafana://provisioning-health"
+)
+
+// selfMonitoringDashboard visualizes the provisioning metrics Grafana exposes. It is saved again whenever it changes
+// so upgrading Grafana also upgrades the dashboard.
+const selfMonitoringDashboard = `{
+  "uid": "grafana-provisioning-health",
+  "title": "Grafana provisioning health",
+  "tags": ["grafana", "provisioning"],
+  "editable": false,
+  "schemaVersion": 18,
+  "time": {"from": "now-6h", "to": "now"},
+  "refresh": "1m",
+  "templating": {
+    "list": [
+      {"name": "datasource", "type": "datasource", "query": "prometheus", "label": "Datasource", "current": {}, "options": [], "refresh": 1, "hide": 0},
+      {"name": "provider", "type": "query", "datasource": "$datasource", "label": "Provider", "query": "label_values(grafana_provisioning_scan_duration_seconds, provider)", "refresh": 2, "includeAll": true, "multi": true, "current": {"text": "All", "value": "$__all"}, "options": [], "hide": 0}
+    ]
+  },
+  "panels": [
+    {
+      "id": 1,
+      "type": "graph",
+      "title": "Dashboard changes",
+      "datasource": "$datasource",
+      "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8},
+      "targets": [{"refId": "A", "expr": "sum by (action) (increase(grafana_provisioning_dashboards_total{provider=~\"$provider\"}[5m]))", "legendFormat": "{{action}}"}]
+    },
+    {
+      "id": 2,
+      "type": "graph",
+      "title": "Failed dashboards",
+      "datasource": "$datasource",
+      "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8},
+      "targets": [{"refId": "A", "expr": "sum by (provider) (increase(grafana_provisioning_dashboards_total{provider=~\"$provider\", action=\"failed\"}[5m]))", "legendFormat": "{{provider}}"}]
+    },
+    {
+      "id": 3,
+      "type": "graph",
+      "title": "Scan duration",
+      "datasource": "$datasource",
+      "gridPos": {"x": 0, "y": 8, "w": 12, "h": 8},
+      "yaxes": [{"format": "s", "show": true}, {"format": "short", "show": false}],
+      "targets": [{"refId": "A", "expr": "grafana_provisioning_scan_duration_seconds{provider=~\"$provider\"}", "legendFormat": "{{provider}}"}]
+    },
+    {
+      "id": 4,
+      "type": "graph",
+      "title": "Time since last successful scan",
+      "datasource": "$datasource",
+      "gridPos": {"x": 12, "y": 8, "w": 12, "h": 8},
+      "yaxes": [{"format": "s", "show": true}, {"format": "short", "show": false}],
+      "targets": [{"refId": "A", "expr": "time() - grafana_provisioning_last_successful_scan_timestamp_seconds{provider=~\"$provider\"}", "legendFormat": "{{provider}}"}]
+    }
+  ]
+}`
+
+// installSelfMonitoringDashboard saves the provisioning health dashboard into the folder of the provider unless the
+// saved one is up to date.
+func (fr *fileReader) installSelfMonitoringDashboard(folderId int64, provisionedDashboardRefs map[string]*models.DashboardProvisioning) error {
+	checkSum, err := util.Sha256SumString(selfMonitoringDashboard)
+	if err != nil {
+		return err
+	}
+
+	provisionedData, alreadyProvisioned := provisionedDashboardRefs[selfMonitoringExternalId]
+	if alreadyProvisioned && provisionedData.CheckSum == checkSum {
+		return nil
+	}
+
+	data, err := simplejson.NewJson([]byte(selfMonitoringDashboard))
+	if err != nil {
+		return err
+	}
+
+	dash, err := createDashboardJson(data, time.Now(), fr.Cfg, folderId)
+	if err != nil {
+		return err
+	}
+
+	if alreadyProvisioned {
+		dash.Dashboard.SetId(provisionedData.DashboardId)
+	}
+
+	fr.log.Debug("saving self monitoring dashboard", "provisioner", fr.Cfg.Name, "folderId", folderId)
+	dp := &models.DashboardProvisioning{
+		ExternalId: selfMonitoringExternalId,
+		Name:       fr.Cfg.Name,
+		Updated:    time.Now().Unix(),
+		CheckSum:   checkSum,
+	}
+
+	saved, err := fr.dashboardProvisioningService.SaveProvisionedDashboard(dash, dp)
+	if err != nil {
+		return err
+	}
+
+	action := journalActionUpdated
+	if !alreadyProvisioned {
+		action = journalActionCreated
+	}
+	fr.recordJournal(journalEntry{
+		DashboardId: saved.Id,
+		Uid:         saved.Uid,
+		Action:      action,
+		Source:      selfMonitoringExternalId,
+		Hash:        checkSum,
+	})
+	return nil
+}
@@ -0,0 +1,71 @@
+package dashboards
+
+import (
+	"os"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// dashboardSidecarSuffix is appended to the name of a dashboard file to get the name of its sidecar file, for
+// example dashboard.json.provisioning.yaml.
+const dashboardSidecarSuffix = ".provisioning.yaml"
+
+// dashboardSidecar holds settings for a single dashboard file that override the settings of the provider.
+type dashboardSidecar struct {
+	// Orgs restricts the dashboard to providers of these orgs. Empty means every org.
+	Orgs []int64 `yaml:"orgs"`
+}
+
+func isDashboardSidecar(name string) bool {
+	return strings.HasSuffix(name, dashboardSidecarSuffix)
+}
+
+// readDashboardSidecar reads the sidecar of the dashboard file at path. Returns nil if the file has no sidecar.
+func readDashboardSidecar(fsys fileSystem, path string) (*dashboardSidecar, error) {
+	content, err := fsys.ReadFile(path + dashboardSidecarSuffix)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	sidecar := &dashboardSidecar{}
+	if err := yaml.Unmarshal(content, sidecar); err != nil {
+		return nil, err
+	}
+	return sidecar, nil
+}
+
+func (s *dashboardSidecar) allowsOrg(orgId int64) bool {
+	if s == nil || len(s.Orgs) == 0 {
+		return true
+	}
+
+	for _, id := range s.Orgs {
+		if id == orgId {
+			return true
+		}
+	}
+	return false
+}
+
+// removeFilesForOtherOrgs drops files found on disk whose sidecar restricts them to other orgs, so they are not
+// provisioned into the org of the provider and are unprovisioned there if they were before. Files with a sidecar
+// that can't be read are dropped too rather than risking a dashboard ending up in the wrong org.
+func (fr *fileReader) removeFilesForOtherOrgs(filesFoundOnDisk map[string]os.FileInfo) {
+	for path := range filesFoundOnDisk {
+		sidecar, err := readDashboardSidecar(fr.fs, path)
+		if err != nil {
+			fr.log.Error("skipping dashboard file with invalid sidecar", "file", path, "error", err)
+			delete(filesFoundOnDisk, path)
+			continue
+		}
+
+		if !sidecar.allowsOrg(fr.Cfg.OrgId) {
+			fr.log.Debug("skipping dashboard file restricted to other orgs", "file", path, "orgs", sidecar.Orgs)
+			delete(filesFoundOnDisk, path)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package dashboards
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// defaultWorkDir is the work directory of readers created without one.
+func defaultWorkDir() string {
+	return filepath.Join(os.TempDir(), "grafana-provisioning")
+}
+
+// dashboardSource fetches the dashboards of a provider into a local directory that is then walked like the path of a
+// file provider, so every option of the file provider works the same for all provider types.
+type dashboardSource interface {
+	// dir returns the local directory holding the dashboards.
+	dir() string
+	// sync updates the dashboards in dir. When it fails the dashboards of the last successful sync are kept.
+	sync() error
+}
+
+// newDashboardSource returns the source of the provider type reading from path, or nil for file providers which read
+// path directly. Sources listing their files only fetch those in one of formats and keep them in workDir.
+func newDashboardSource(cfg *DashboardsAsConfig, path string, formats []*dashboardFileFormat, workDir string, log log.Logger) (dashboardSource, error) {
+	switch cfg.Type {
+	case "", "file":
+		return nil, nil
+	case archiveSourceType:
+		return newArchiveSource(path, sourceWorkDir(workDir, cfg), log), nil
+	case httpSourceType:
+		return newHttpSource(cfg, sourceWorkDir(workDir, cfg), log)
+	case s3SourceType:
+		return newS3Source(cfg, sourceWorkDir(workDir, cfg), formats, log)
+	case gitSourceType:
+		return newGitSource(cfg, sourceWorkDir(workDir, cfg), log)
+	default:
+		return nil, fmt.Errorf("type %s is not supported", cfg.Type)
+	}
+}
+
+// sourceRequiresPath returns false for provider types reading dashboards from somewhere the path option can't point
+// to.
+func sourceRequiresPath(providerType string) bool {
+	return providerType != httpSourceType && providerType != s3SourceType && providerType != gitSourceType
+}
+
+// sourceWorkDir returns the directory in workDir the source of the provider keeps its dashboards in. Its name is
+// derived from the provider so dashboards keep their path across restarts.
+func sourceWorkDir(workDir string, cfg *DashboardsAsConfig) string {
+	return filepath.Join(workDir, cfg.Type, url.QueryEscape(cfg.Name))
+}
+
+// pathWithinDir returns the path in dir a file fetched by a source under the slash separated name is saved to.
+// Absolute names and names leaving dir through .. are rejected, so an archive or server can't write anywhere else.
+func pathWithinDir(dir string, name string) (string, error) {
+	name = strings.Replace(name, "\\", "/", -1)
+	if path.IsAbs(name) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("%s is an absolute path", name)
+	}
+
+	target := filepath.Join(dir, filepath.FromSlash(path.Clean(name)))
+	if !isPathWithinRoot(target, dir) {
+		return "", fmt.Errorf("%s is outside of the directory", name)
+	}
+	return target, nil
+}
+
+// downloadSuffix is appended to the name of a file while replaceFile writes it.
+const downloadSuffix = ".download"
+
+// replaceFile writes content to a temporary file it then renames to target, so a scan never reads a partial file.
+func replaceFile(target string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+		return err
+	}
+
+	tmp := target + downloadSuffix
+	if err := ioutil.WriteFile(tmp, content, 0640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, target)
+}
+
+// removeUnlistedFiles removes the files in dir of dashboards the source no longer lists, which unprovisions them.
+func removeUnlistedFiles(dir string, listed map[string]bool, log log.Logger) error {
+	return filepath.Walk(dir, func(path string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fileInfo.IsDir() || listed[path] {
+			return nil
+		}
+		log.Debug("removing dashboard no longer listed by the source", "file", path)
+		return os.Remove(path)
+	})
+}
+
+// syncSource updates the dashboards of providers with a source. A failed update is logged and the dashboards of the
+// last successful one are provisioned.
+func (fr *fileReader) syncSource() {
+	if fr.source == nil {
+		return
+	}
+
+	if err := fr.source.sync(); err != nil {
+		fr.log.Error("failed to update dashboards of provider, keeping the last copy", "error", err)
+	}
+}
@@ -0,0 +1,137 @@
+package dashboards
+
+import (
+	"sync"
+	"time"
+)
+
+// ProviderStatus is the result of the last scan of a dashboard provider.
+type ProviderStatus struct {
+	Provider string `json:"provider"`
+	// LastRun is the time the last scan finished, zero if the provider wasn't scanned yet.
+	LastRun  time.Time `json:"lastRun"`
+	Duration float64   `json:"durationSeconds"`
+	// Error is the error that stopped the last scan.
+	Error     string `json:"error,omitempty"`
+	Inserted  int    `json:"inserted"`
+	Updated   int    `json:"updated"`
+	Unchanged int    `json:"unchanged"`
+	Deleted   int    `json:"deleted"`
+	// Unprovisioned are the dashboards missing on disk kept by a provider with disableDeletion.
+	Unprovisioned int `json:"unprovisioned"`
+	// FailedFiles are the dashboard files that couldn't be read or saved.
+	FailedFiles []FailedDashboardFile `json:"failedFiles"`
+	// UnhealthyDashboards are the dashboards failing the renderCheck. They are checked in the background after
+	// saving, so they can change between scans.
+	UnhealthyDashboards []UnhealthyDashboard `json:"unhealthyDashboards"`
+}
+
+// FailedDashboardFile is a dashboard file that couldn't be provisioned and why.
+type FailedDashboardFile struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// UnhealthyDashboard is a provisioned dashboard that failed to render and why.
+type UnhealthyDashboard struct {
+	Path  string `json:"path"`
+	Uid   string `json:"uid"`
+	Error string `json:"error"`
+}
+
+// statusRecorder collects the status of the running scan of a provider and publishes it once the scan is done, so
+// the status never shows a scan half way.
+type statusRecorder struct {
+	mu      sync.Mutex
+	running ProviderStatus
+	last    ProviderStatus
+}
+
+func newStatusRecorder(provider string) *statusRecorder {
+	return &statusRecorder{
+		running: ProviderStatus{Provider: provider, FailedFiles: []FailedDashboardFile{}},
+		last:    ProviderStatus{Provider: provider, FailedFiles: []FailedDashboardFile{}},
+	}
+}
+
+// begin starts collecting the status of a new scan.
+func (r *statusRecorder) begin() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.running = ProviderStatus{Provider: r.last.Provider, FailedFiles: []FailedDashboardFile{}}
+}
+
+// count counts a dashboard the action of the provisioning metric was taken for.
+func (r *statusRecorder) count(action string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch action {
+	case metricActionInserted:
+		r.running.Inserted++
+	case metricActionUpdated:
+		r.running.Updated++
+	case metricActionUnchanged:
+		r.running.Unchanged++
+	case metricActionDeleted:
+		r.running.Deleted++
+	case metricActionUnprovisioned:
+		r.running.Unprovisioned++
+	}
+}
+
+// fail records a dashboard file that couldn't be provisioned.
+func (r *statusRecorder) fail(path string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.running.FailedFiles = append(r.running.FailedFiles, FailedDashboardFile{Path: path, Error: err.Error()})
+}
+
+// failures returns the number of files that failed in the running scan.
+func (r *statusRecorder) failures() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.running.FailedFiles)
+}
+
+// finish publishes the status of the scan that started at start and ended with err.
+func (r *statusRecorder) finish(start time.Time, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.running.LastRun = time.Now()
+	r.running.Duration = r.running.LastRun.Sub(start).Seconds()
+	if err != nil {
+		r.running.Error = err.Error()
+	}
+	r.last = r.running
+}
+
+// get returns the status of the last scan.
+func (r *statusRecorder) get() ProviderStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := r.last
+	status.FailedFiles = append([]FailedDashboardFile{}, r.last.FailedFiles...)
+	return status
+}
+
+// Status returns the status of the last scan of the provider.
+func (fr *fileReader) Status() ProviderStatus {
+	status := fr.status.get()
+	status.UnhealthyDashboards = fr.renders.list()
+	return status
+}
+
+// Status returns the status of the last scan of every provider in the order they are configured.
+func (provider *DashboardProvisionerImpl) Status() []ProviderStatus {
+	statuses := make([]ProviderStatus, 0, len(provider.fileReaders))
+	for _, reader := range provider.fileReaders {
+		statuses = append(statuses, reader.Status())
+	}
+	return statuses
+}
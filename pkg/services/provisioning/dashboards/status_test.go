@@ -0,0 +1,113 @@
+package dashboards
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProviderStatus(t *testing.T) {
+	Convey("Status of a provider", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		dir, err := ioutil.TempDir("", "provisioning-status")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		writeDashboard := func(name string, content string, modTime time.Time) {
+			path := filepath.Join(dir, name)
+			So(ioutil.WriteFile(path, []byte(content), 0644), ShouldBeNil)
+			So(os.Chtimes(path, modTime, modTime), ShouldBeNil)
+		}
+
+		cfg := &DashboardsAsConfig{
+			Name:    "Default",
+			Type:    "file",
+			OrgId:   1,
+			Options: map[string]interface{}{"path": dir},
+		}
+		reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+		So(err, ShouldBeNil)
+
+		Convey("should be empty before the first scan", func() {
+			status := reader.Status()
+			So(status.Provider, ShouldEqual, "Default")
+			So(status.LastRun.IsZero(), ShouldBeTrue)
+			So(status.FailedFiles, ShouldBeEmpty)
+		})
+
+		Convey("after a scan", func() {
+			provisioned := time.Now().Add(-time.Hour)
+			writeDashboard("cpu.json", `{"title": "CPU"}`, provisioned)
+			writeDashboard("disk.json", `{"title": "Disk"}`, provisioned)
+			writeDashboard("broken.json", `{"title": `, provisioned)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			Convey("should count the dashboards and list the files that failed", func() {
+				status := reader.Status()
+				So(status.LastRun.IsZero(), ShouldBeFalse)
+				So(status.Inserted, ShouldEqual, 2)
+				So(status.Error, ShouldBeEmpty)
+				So(len(status.FailedFiles), ShouldEqual, 1)
+				So(status.FailedFiles[0].Path, ShouldEqual, filepath.Join(dir, "broken.json"))
+				So(status.FailedFiles[0].Error, ShouldNotBeEmpty)
+			})
+
+			Convey("should only show the last scan", func() {
+				writeDashboard("broken.json", `{"title": "Fixed"}`, time.Now())
+				writeDashboard("cpu.json", `{"title": "CPU usage"}`, time.Now())
+				So(os.Remove(filepath.Join(dir, "disk.json")), ShouldBeNil)
+				So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+				status := reader.Status()
+				So(status.Inserted, ShouldEqual, 1)
+				So(status.Updated, ShouldEqual, 1)
+				So(status.Deleted, ShouldEqual, 1)
+				So(status.FailedFiles, ShouldBeEmpty)
+			})
+
+			Convey("should list files failing to save and log how many failed", func() {
+				fakeService.saveErrors = map[string]error{"Default": errors.New("database is gone")}
+				recorder := &recordingLogger{Logger: log.New("test-logger")}
+				reader.log = recorder
+				writeDashboard("cpu.json", `{"title": "CPU usage"}`, time.Now())
+				So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+				// files failing to read are recorded by the workers reading them, so the order isn't fixed
+				status := reader.Status()
+				So(len(status.FailedFiles), ShouldEqual, 2)
+				So(status.FailedFiles, ShouldContain, FailedDashboardFile{Path: filepath.Join(dir, "cpu.json"), Error: "database is gone"})
+				So(recorder.messages, ShouldContain, "2 of 3 dashboards failed")
+			})
+
+			Convey("should be listed by the provisioner as json", func() {
+				provisioner := &DashboardProvisionerImpl{log: log.New("test-logger"), fileReaders: []*fileReader{reader}}
+				data, err := json.Marshal(provisioner.Status())
+				So(err, ShouldBeNil)
+
+				var statuses []map[string]interface{}
+				So(json.Unmarshal(data, &statuses), ShouldBeNil)
+				So(len(statuses), ShouldEqual, 1)
+				So(statuses[0]["provider"], ShouldEqual, "Default")
+				So(statuses[0]["inserted"], ShouldEqual, 2)
+				So(statuses[0]["failedFiles"], ShouldHaveLength, 1)
+			})
+		})
+	})
+}
@@ -0,0 +1,47 @@
+package dashboards
+
+import (
+	"context"
+	"errors"
+)
+
+// errProviderStopped is returned by scans aborted because their provider was stopped.
+var errProviderStopped = errors.New("provider was stopped")
+
+// Stop stops polling the provider and aborts a running scan before it saves its next dashboard file. Dashboards
+// missing on disk are not removed by an aborted scan. The provider can't be started again.
+func (fr *fileReader) Stop() {
+	fr.stopOnce.Do(func() {
+		close(fr.stopped)
+	})
+}
+
+// isStopped returns true once the provider was stopped.
+func (fr *fileReader) isStopped() bool {
+	select {
+	case <-fr.stopped:
+		return true
+	default:
+		return false
+	}
+}
+
+// untilStopped returns a context cancelled with ctx or when the provider is stopped, whichever comes first.
+func (fr *fileReader) untilStopped(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-fr.stopped:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// Stop stops all providers, see Stop of a single provider.
+func (provider *DashboardProvisionerImpl) Stop() {
+	for _, reader := range provider.fileReaders {
+		reader.Stop()
+	}
+}
@@ -0,0 +1,117 @@
+package dashboards
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStoppingProviders(t *testing.T) {
+	Convey("Stopping a provider", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		cfg := &DashboardsAsConfig{
+			Name:                  "Default",
+			Type:                  "file",
+			OrgId:                 1,
+			UpdateIntervalSeconds: 1,
+			Options:               map[string]interface{}{"path": defaultDashboards},
+		}
+		reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+		So(err, ShouldBeNil)
+
+		returnsPromptly := func(poll func()) bool {
+			returned := make(chan struct{})
+			go func() {
+				poll()
+				close(returned)
+			}()
+
+			select {
+			case <-returned:
+				return true
+			case <-time.After(time.Second):
+				return false
+			}
+		}
+
+		Convey("should end polling", func() {
+			So(returnsPromptly(func() {
+				time.AfterFunc(10*time.Millisecond, reader.Stop)
+				reader.pollChanges(context.Background())
+			}), ShouldBeTrue)
+		})
+
+		Convey("should end polling when the context is cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			So(returnsPromptly(func() {
+				time.AfterFunc(10*time.Millisecond, cancel)
+				reader.pollChanges(ctx)
+			}), ShouldBeTrue)
+		})
+
+		Convey("should abort scans before saving dashboards", func() {
+			reader.Stop()
+			So(reader.startWalkingDisk(context.Background()), ShouldEqual, errProviderStopped)
+			So(fakeService.inserted, ShouldBeEmpty)
+		})
+
+		Convey("should be safe to stop more than once", func() {
+			provisioner := &DashboardProvisionerImpl{log: log.New("test-logger"), fileReaders: []*fileReader{reader}}
+			provisioner.Stop()
+			provisioner.Stop()
+			So(reader.isStopped(), ShouldBeTrue)
+		})
+	})
+}
+
+func TestCancellingScans(t *testing.T) {
+	Convey("Cancelling the context of a scan", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		cfg := &DashboardsAsConfig{
+			Name:    "Default",
+			Type:    "file",
+			OrgId:   1,
+			Options: map[string]interface{}{"path": defaultDashboards},
+		}
+		reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+		So(err, ShouldBeNil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		Convey("should stop the walk with the error of the context", func() {
+			_, err := reader.findDashboardFiles(ctx)
+			So(err, ShouldEqual, context.Canceled)
+		})
+
+		Convey("should stop the scan before saving dashboards", func() {
+			So(reader.startWalkingDisk(ctx), ShouldEqual, context.Canceled)
+			So(fakeService.inserted, ShouldBeEmpty)
+		})
+
+		Convey("should stop reloading all providers", func() {
+			provisioner := &DashboardProvisionerImpl{log: log.New("test-logger"), fileReaders: []*fileReader{reader}}
+			So(provisioner.Reload(ctx), ShouldNotBeNil)
+			So(fakeService.inserted, ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,60 @@
+package dashboards
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// getTemplateDelims returns the delimiters of dashboard templates set by the templateDelims option, text/template
+// uses {{ and }} by default.
+func getTemplateDelims(options map[string]interface{}) (string, string, error) {
+	delims := getStringSliceOption(options, "templateDelims")
+	if len(delims) == 0 {
+		return "", "", nil
+	}
+	if len(delims) != 2 || delims[0] == "" || delims[1] == "" {
+		return "", "", fmt.Errorf("templateDelims must list the left and the right delimiter, got %q", delims)
+	}
+	return delims[0], delims[1], nil
+}
+
+// renderDashboardTemplate runs the content of the dashboard file at path through text/template for the templating
+// option. The keys of the templateData option are available as fields and the environment variables as .Env, so
+// {{ .service }} and {{ .Env.REGION }} both work. Referencing a missing key fails instead of rendering <no value>.
+func (fr *fileReader) renderDashboardTemplate(path string, content []byte) ([]byte, error) {
+	left, right, err := getTemplateDelims(fr.Cfg.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).Delims(left, right).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{}
+	for key, value := range getMapOption(fr.Cfg.Options, "templateData") {
+		data[key] = value
+	}
+	data["Env"] = environmentVariables()
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return nil, err
+	}
+	return rendered.Bytes(), nil
+}
+
+func environmentVariables() map[string]string {
+	env := map[string]string{}
+	for _, variable := range os.Environ() {
+		if i := strings.Index(variable, "="); i > 0 {
+			env[variable[:i]] = variable[i+1:]
+		}
+	}
+	return env
+}
@@ -0,0 +1,98 @@
+package dashboards
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var templatedDashboards = "testdata/test-dashboards/templated"
+
+func TestDashboardTemplating(t *testing.T) {
+	Convey("Dashboard templating", t, func() {
+		os.Setenv("TEMPLATING_TEST_REGION", "eu-west")
+		defer os.Unsetenv("TEMPLATING_TEST_REGION")
+
+		cfg := &DashboardsAsConfig{
+			Name:  "Default",
+			Type:  "file",
+			OrgId: 1,
+			Options: map[string]interface{}{
+				"path":         templatedDashboards,
+				"templating":   true,
+				"templateData": map[interface{}]interface{}{"service": "checkout"},
+			},
+		}
+		logger := log.New("test-logger")
+
+		Convey("should render template data and environment variables", func() {
+			reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			rendered, err := reader.renderDashboardTemplate("a.json", []byte(`{"title": "{{ .service }} in {{ .Env.TEMPLATING_TEST_REGION }}"}`))
+			So(err, ShouldBeNil)
+			So(string(rendered), ShouldEqual, `{"title": "checkout in eu-west"}`)
+		})
+
+		Convey("should fail on missing keys", func() {
+			reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			_, err = reader.renderDashboardTemplate("a.json", []byte(`{"title": "{{ .team }}"}`))
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "team")
+
+			_, err = reader.renderDashboardTemplate("a.json", []byte(`{"title": "{{ .Env.TEMPLATING_TEST_MISSING }}"}`))
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("should use configured delimiters", func() {
+			cfg.Options["templateDelims"] = []interface{}{"[[", "]]"}
+			reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			rendered, err := reader.renderDashboardTemplate("a.json", []byte(`{"title": "[[ .service ]]", "legendFormat": "{{instance}}"}`))
+			So(err, ShouldBeNil)
+			So(string(rendered), ShouldEqual, `{"title": "checkout", "legendFormat": "{{instance}}"}`)
+		})
+
+		Convey("should reject incomplete delimiters", func() {
+			cfg.Options["templateDelims"] = []interface{}{"[["}
+			_, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("should provision rendered dashboards", func() {
+			bus.ClearBusHandlers()
+			origNewDashboardProvisioningService := dashboards.NewProvisioningService
+			fakeService = mockDashboardProvisioningService()
+			bus.AddHandler("test", mockGetDashboardQuery)
+			defer func() {
+				dashboards.NewProvisioningService = origNewDashboardProvisioningService
+			}()
+
+			reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+			So(err, ShouldBeNil)
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			So(len(fakeService.inserted), ShouldEqual, 1)
+			dash := fakeService.inserted[0].Dashboard
+			So(dash.Title, ShouldEqual, "checkout overview")
+			So(dash.Uid, ShouldEqual, "checkout-overview")
+			So(dash.GetTags(), ShouldResemble, []string{"eu-west"})
+
+			Convey("and save them again when the template data changes", func() {
+				cfg.Options["templateData"] = map[interface{}]interface{}{"service": "payment"}
+				So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+				So(fakeService.provisioned["Default"][0].CheckSum, ShouldNotBeEmpty)
+				So(fakeService.inserted[len(fakeService.inserted)-1].Dashboard.Title, ShouldEqual, "payment overview")
+			})
+		})
+	})
+}
@@ -0,0 +1,19 @@
+#!/bin/sh
+# Stands in for the jsonnet binary in tests. Prints a dashboard titled after the evaluated file with the jpath
+# arguments as description, files containing "error" fail like a jsonnet runtime error.
+jpath=""
+while [ $# -gt 1 ]; do
+  if [ "$1" = "--jpath" ]; then
+    jpath="$jpath$2:"
+    shift
+  fi
+  shift
+done
+
+if grep -q error "$1"; then
+  echo "RUNTIME ERROR: $1 failed" >&2
+  exit 1
+fi
+
+name=$(basename "$1" .jsonnet)
+printf '{"title": "%s", "uid": "%s", "description": "%s"}\n' "$name" "$name" "$jpath"
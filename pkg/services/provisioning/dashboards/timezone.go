@@ -0,0 +1,25 @@
+package dashboards
+
+import (
+	"fmt"
+	"time"
+)
+
+// Timezones of dashboards that are not IANA zones.
+const (
+	timezoneUtc     = "utc"
+	timezoneBrowser = "browser"
+)
+
+// validateTimezone returns an error unless timezone is utc, browser or an IANA zone like Europe/Berlin. The zones Go
+// resolves without being IANA zones, empty for UTC and Local, are rejected as the frontend doesn't know them.
+func validateTimezone(timezone string) error {
+	if timezone == timezoneUtc || timezone == timezoneBrowser {
+		return nil
+	}
+
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "" || timezone == "Local" {
+		return fmt.Errorf("must be %q, %q or an IANA zone like Europe/Berlin, got %q", timezoneUtc, timezoneBrowser, timezone)
+	}
+	return nil
+}
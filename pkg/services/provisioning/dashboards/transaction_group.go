@@ -0,0 +1,156 @@
+package dashboards
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+// provisionTransactionGroup scans providers sharing a transaction group so either all or none of their changes are
+// saved. Every dashboard file of the group is read and validated before anything is saved. If saving still fails
+// part way, the dashboards inserted by the group are deleted again and the dashboards it updated are saved again as
+// they were before. Dashboards missing on disk are only removed once all providers of the group are saved.
+func provisionTransactionGroup(ctx context.Context, name string, readers []*fileReader, opts ScanOptions) error {
+	for _, reader := range readers {
+		if err := reader.stageDashboards(ctx); err != nil {
+			return errutil.Wrapf(err, "Failed to stage config %v of transaction group %v", reader.Cfg.Name, name)
+		}
+	}
+
+	commitOpts := opts
+	commitOpts.SkipDelete = true
+	commitOpts.atomic = true
+	for i, reader := range readers {
+		if err := reader.startWalkingDiskWithOptions(ctx, commitOpts); err != nil {
+			for _, committed := range readers[:i+1] {
+				committed.rollback()
+			}
+			return errutil.Wrapf(err, "Failed to provision config %v of transaction group %v", reader.Cfg.Name, name)
+		}
+	}
+
+	if opts.SkipDelete {
+		return nil
+	}
+
+	// all dashboards are up to date now so this only removes the dashboards missing on disk
+	for _, reader := range readers {
+		if err := reader.startWalkingDiskWithOptions(ctx, opts); err != nil {
+			return errutil.Wrapf(err, "Failed to provision config %v of transaction group %v", reader.Cfg.Name, name)
+		}
+	}
+
+	return nil
+}
+
+// pollTransactionGroupChanges periodically scans the providers of a transaction group together, using the shortest
+// update interval of the group.
+func pollTransactionGroupChanges(ctx context.Context, name string, readers []*fileReader) {
+	interval := readers[0].Cfg.UpdateIntervalSeconds
+	for _, reader := range readers[1:] {
+		if reader.Cfg.UpdateIntervalSeconds < interval {
+			interval = reader.Cfg.UpdateIntervalSeconds
+		}
+	}
+
+	// the providers of a group are stopped together
+	ctx, cancel := readers[0].untilStopped(ctx)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Duration(int64(time.Second) * interval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := reloadTransactionGroup(ctx, name, readers); err != nil && ctx.Err() == nil {
+				readers[0].log.Error("failed to provision transaction group", "group", name, "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// stageDashboards reads and validates all dashboard files of the provider without saving anything.
+func (fr *fileReader) stageDashboards(ctx context.Context) error {
+	filesFoundOnDisk, err := fr.findDashboardFiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range sortedDashboardPaths(fr.resolvedPaths(), filesFoundOnDisk) {
+		fileInfo := filesFoundOnDisk[path]
+		resolvedFileInfo, err := resolveSymlink(fr.fs, fileInfo, path)
+		if err != nil {
+			return err
+		}
+
+		if _, err := fr.readDashboardFromFile(path, resolvedFileInfo.ModTime(), dashboardFolder{title: fr.Cfg.Folder}, false); err != nil {
+			return fmt.Errorf("could not read dashboard %s: %v", path, err)
+		}
+	}
+
+	return nil
+}
+
+// previousDashboard is a provisioned dashboard as it was before an atomic scan updated it.
+type previousDashboard struct {
+	dashboard    *models.Dashboard
+	provisioning models.DashboardProvisioning
+}
+
+// getPreviousDashboard returns the provisioned dashboard as it is saved now, so it can be restored by rollback.
+func (fr *fileReader) getPreviousDashboard(provisioned *models.DashboardProvisioning) (*previousDashboard, error) {
+	query := &models.GetDashboardQuery{Id: provisioned.DashboardId, OrgId: fr.Cfg.OrgId}
+	if err := bus.Dispatch(query); err != nil {
+		return nil, fmt.Errorf("could not read dashboard %d to roll back to: %v", provisioned.DashboardId, err)
+	}
+	return &previousDashboard{dashboard: query.Result, provisioning: *provisioned}, nil
+}
+
+// rollback undoes the last scan: the dashboards it inserted are deleted and those it updated are saved as they were
+// before, together with their provisioning data, so the next scan sees their files as changed again.
+func (fr *fileReader) rollback() {
+	for _, reader := range fr.orgReaders {
+		reader.rollback()
+	}
+
+	for i := len(fr.updatedDashboards) - 1; i >= 0; i-- {
+		previous := fr.updatedDashboards[i]
+		dto := &dashboards.SaveDashboardDTO{
+			OrgId:     fr.Cfg.OrgId,
+			UpdatedAt: previous.dashboard.Updated,
+			Overwrite: true,
+			Dashboard: previous.dashboard,
+		}
+		provisioning := previous.provisioning
+		if _, err := fr.dashboardProvisioningService.SaveProvisionedDashboard(dto, &provisioning); err != nil {
+			fr.log.Error("failed to roll back updated dashboard", "id", previous.dashboard.Id, "error", err)
+			continue
+		}
+		fr.recordJournal(journalEntry{
+			DashboardId: previous.dashboard.Id,
+			Uid:         previous.dashboard.Uid,
+			Action:      journalActionUpdated,
+			Source:      provisioning.ExternalId,
+			Hash:        provisioning.CheckSum,
+		})
+	}
+	fr.updatedDashboards = nil
+
+	for _, id := range fr.insertedDashboardIds {
+		if err := fr.dashboardProvisioningService.DeleteProvisionedDashboard(id, fr.Cfg.OrgId); err != nil {
+			fr.log.Error("failed to roll back inserted dashboard", "id", id, "error", err)
+			continue
+		}
+		fr.renders.forget(id)
+		fr.recordJournal(journalEntry{DashboardId: id, Action: journalActionDeleted})
+	}
+	fr.insertedDashboardIds = nil
+	fr.flushJournal()
+}
@@ -0,0 +1,101 @@
+package dashboards
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTransactionGroup(t *testing.T) {
+	Convey("Providers in a transaction group", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		logger := log.New("test.logger")
+
+		newReader := func(name string, path string) *fileReader {
+			cfg := &DashboardsAsConfig{
+				Name:             name,
+				Type:             "file",
+				OrgId:            1,
+				Options:          map[string]interface{}{"path": path},
+				TransactionGroup: "release",
+			}
+			reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+			So(err, ShouldBeNil)
+			return reader
+		}
+
+		Convey("should provision all providers of the group", func() {
+			provisioner := &DashboardProvisionerImpl{
+				log:         logger,
+				fileReaders: []*fileReader{newReader("one", oneDashboard), newReader("default", defaultDashboards)},
+			}
+
+			err := provisioner.Provision()
+			So(err, ShouldBeNil)
+			So(len(fakeService.inserted), ShouldEqual, 3)
+		})
+
+		Convey("should not save anything when a provider has a broken dashboard", func() {
+			provisioner := &DashboardProvisionerImpl{
+				log:         logger,
+				fileReaders: []*fileReader{newReader("one", oneDashboard), newReader("broken", brokenDashboards)},
+			}
+
+			err := provisioner.Provision()
+			So(err, ShouldNotBeNil)
+			So(len(fakeService.inserted), ShouldEqual, 0)
+		})
+
+		Convey("should roll back inserts of other providers when saving fails", func() {
+			fakeService.saveErrors = map[string]error{"containing-id": errors.New("database is locked")}
+			provisioner := &DashboardProvisionerImpl{
+				log:         logger,
+				fileReaders: []*fileReader{newReader("one", oneDashboard), newReader("containing-id", containingId)},
+			}
+
+			err := provisioner.Provision()
+			So(err, ShouldNotBeNil)
+			So(len(fakeService.inserted), ShouldEqual, 0)
+			So(len(fakeService.provisioned["one"]), ShouldEqual, 0)
+		})
+
+		Convey("should restore dashboards updated by other providers when saving fails", func() {
+			provisioner := &DashboardProvisionerImpl{
+				log:         logger,
+				fileReaders: []*fileReader{newReader("one", oneDashboard), newReader("default", defaultDashboards)},
+			}
+			err := provisioner.Provision()
+			So(err, ShouldBeNil)
+
+			previous := fakeService.inserted[0].Dashboard
+			for _, name := range []string{"one", "default"} {
+				for _, provisioned := range fakeService.provisioned[name] {
+					provisioned.CheckSum = "stale"
+					provisioned.Updated = 0
+				}
+			}
+			fakeService.saveErrors = map[string]error{"default": errors.New("database is locked")}
+
+			err = provisioner.Provision()
+			So(err, ShouldNotBeNil)
+			So(len(fakeService.provisioned["one"]), ShouldEqual, 1)
+			So(fakeService.provisioned["one"][0].CheckSum, ShouldEqual, "stale")
+
+			query := &models.GetDashboardQuery{Id: previous.Id, OrgId: 1}
+			So(bus.Dispatch(query), ShouldBeNil)
+			So(query.Result, ShouldEqual, previous)
+		})
+
+		Reset(func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		})
+	})
+}
@@ -0,0 +1,669 @@
+package dashboards
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// gridColumnCount is the number of columns of the dashboard grid.
+const gridColumnCount = 24
+
+const (
+	dashboardStyleDark  = "dark"
+	dashboardStyleLight = "light"
+)
+
+// transformDashboard applies the dashboard transformations enabled in the provider options to the parsed json of
+// the dashboard before it is turned into a dashboard model and saved. folder is the title of the folder the dashboard
+// goes into. Returns the tags added by the provider.
+func (fr *fileReader) transformDashboard(path string, folder string, data *simplejson.Json) []string {
+	if mappings := getStringMapOption(fr.Cfg.Options, "datasourceMappings"); len(mappings) > 0 {
+		if replaced := mapDatasources(data, mappings); replaced > 0 {
+			fr.log.Debug("replaced mapped datasources", "file", path, "references", replaced)
+		}
+	}
+
+	if ds := getStringOption(fr.Cfg.Options, "defaultAnnotationDatasource"); ds != "" {
+		if setDefaultAnnotationDatasource(data, ds) {
+			fr.log.Debug("set datasource of built in annotation query", "file", path, "datasource", ds)
+		}
+	}
+
+	if getBoolOption(fr.Cfg.Options, "variablizeDatasources") {
+		for _, name := range variablizeDatasources(data, fr.Cfg.OrgId) {
+			fr.log.Debug("replaced datasource with variable", "file", path, "datasource", name)
+		}
+	}
+
+	if prefix := getStringOption(fr.Cfg.Options, "cacheTimeoutTagPrefix"); prefix != "" {
+		if timeout, changed := applyCacheTimeoutFromTags(data, prefix); changed > 0 {
+			fr.log.Debug("applied cache timeout from tag", "file", path, "cacheTimeout", timeout, "panels", changed)
+		}
+	}
+
+	if limit := getInt64Option(fr.Cfg.Options, "maxDataPointsCap"); limit > 0 {
+		for _, panelId := range capMaxDataPoints(data, limit) {
+			fr.log.Info("capped maxDataPoints of panel", "file", path, "panelId", panelId, "maxDataPoints", limit)
+		}
+	}
+
+	if timezone, ok := getStringMapOption(fr.Cfg.Options, "folderTimezones")[folder]; ok {
+		if data.Get("timezone").MustString() == "" {
+			data.Set("timezone", timezone)
+		}
+	}
+
+	if timezone := getStringOption(fr.Cfg.Options, "timezoneOverride"); timezone != "" {
+		data.Set("timezone", timezone)
+	}
+
+	if getBoolOption(fr.Cfg.Options, "normalizeTimeToRelative") {
+		from, to := data.GetPath("time", "from").Interface(), data.GetPath("time", "to").Interface()
+		if normalizeTimeToRelative(data) {
+			fr.log.Info("converted absolute time range to relative", "file", path, "from", from, "to", to,
+				"relativeFrom", data.GetPath("time", "from").MustString())
+		}
+	}
+
+	if prefix, suffix := getStringOption(fr.Cfg.Options, "titlePrefix"), getStringOption(fr.Cfg.Options, "titleSuffix"); prefix != "" || suffix != "" {
+		data.Set("title", affixTitle(data.Get("title").MustString(), prefix, suffix))
+	}
+
+	if refresh := getStringOption(fr.Cfg.Options, "refreshOverride"); refresh != "" {
+		previous := data.Get("refresh").Interface()
+		if overrideRefresh(data, refresh, getBoolOption(fr.Cfg.Options, "clampRefresh")) {
+			fr.log.Debug("overrode refresh interval", "file", path, "refresh", refresh, "previous", previous)
+		}
+	}
+
+	if fr.Cfg.EditableSet {
+		data.Set("editable", fr.Cfg.Editable)
+	}
+
+	if style := getStringOption(fr.Cfg.Options, "forceStyle"); style != "" {
+		data.Set("style", style)
+	}
+
+	if links := getMapSliceOption(fr.Cfg.Options, "defaultPanelDataLinks"); len(links) > 0 {
+		types := getStringSliceOption(fr.Cfg.Options, "defaultPanelDataLinkTypes")
+		if len(types) == 0 {
+			types = defaultPanelDataLinkTypes
+		}
+		if added := addDefaultPanelLinks(data, links, types); added > 0 {
+			fr.log.Debug("added default panel links", "file", path, "links", added)
+		}
+	}
+
+	if fr.revision != "" && getBoolOption(fr.Cfg.Options, "tagWithCommit") {
+		setRevisionTag(data, fr.revision)
+	}
+
+	if getBoolOption(fr.Cfg.Options, "pruneUnusedVariables") {
+		for _, name := range pruneUnusedVariables(data) {
+			fr.log.Info("removed unused template variable", "file", path, "variable", name)
+		}
+	}
+
+	if getBoolOption(fr.Cfg.Options, "normalizeGridLayout") {
+		if normalizeGridLayout(data) {
+			fr.log.Info("repacked overlapping panels", "file", path)
+		}
+	}
+
+	return addTags(data, fr.injectedTags())
+}
+
+// injectedTags returns the tags added to every dashboard, the tags of the provider followed by the addTags option.
+func (fr *fileReader) injectedTags() []string {
+	tags := append([]string{}, fr.Cfg.Tags...)
+	return append(tags, getStringSliceOption(fr.Cfg.Options, "addTags")...)
+}
+
+// affixTitle adds the prefix and the suffix to the title unless it already starts or ends with them, so dashboards
+// exported from an instance provisioned with the same options don't get them twice.
+func affixTitle(title string, prefix string, suffix string) string {
+	if !strings.HasPrefix(title, prefix) {
+		title = prefix + title
+	}
+	if !strings.HasSuffix(title, suffix) {
+		title += suffix
+	}
+	return title
+}
+
+// overrideRefresh sets the auto refresh interval of the dashboard. With clamp only intervals shorter than refresh are
+// replaced, and dashboards without auto refresh keep it disabled. Returns true if the interval was changed.
+func overrideRefresh(data *simplejson.Json, refresh string, clamp bool) bool {
+	current, _ := data.Get("refresh").Interface().(string)
+	if current == refresh {
+		return false
+	}
+
+	if clamp {
+		interval, ok := parseRefreshInterval(current)
+		minInterval, _ := parseRefreshInterval(refresh)
+		if !ok || interval >= minInterval {
+			return false
+		}
+	}
+
+	data.Set("refresh", refresh)
+	return true
+}
+
+// parseRefreshInterval parses a dashboard refresh interval like 30s, 5m, 1h or 1d.
+func parseRefreshInterval(interval string) (time.Duration, bool) {
+	if days := strings.TrimSuffix(interval, "d"); days != interval {
+		count, err := strconv.ParseInt(days, 10, 64)
+		return time.Duration(count) * 24 * time.Hour, err == nil && count > 0
+	}
+	duration, err := time.ParseDuration(interval)
+	return duration, err == nil && duration > 0
+}
+
+// mapDatasources replaces datasource names found in mappings with the name they are mapped to. The whole json tree
+// is walked, so panels nested in rows, library panels, queries, annotations and template variables are all covered.
+// Names not found in mappings are left alone. Returns the number of replaced references.
+func mapDatasources(data *simplejson.Json, mappings map[string]string) int {
+	return mapDatasourcesIn(data.Interface(), mappings)
+}
+
+func mapDatasourcesIn(value interface{}, mappings map[string]string) int {
+	replaced := 0
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if name, ok := item.(string); ok && key == "datasource" {
+				if to, ok := mappings[name]; ok {
+					v[key] = to
+					replaced++
+				}
+				continue
+			}
+			replaced += mapDatasourcesIn(item, mappings)
+		}
+	case []interface{}:
+		for _, item := range v {
+			replaced += mapDatasourcesIn(item, mappings)
+		}
+	}
+	return replaced
+}
+
+// setDefaultAnnotationDatasource sets the datasource of the built in annotation query if the dashboard does not
+// specify one. If the dashboard has no built in annotation query it adds one the same way the frontend would.
+// Returns true if the dashboard was changed.
+func setDefaultAnnotationDatasource(data *simplejson.Json, datasource string) bool {
+	list := data.GetPath("annotations", "list").MustArray()
+	for _, item := range list {
+		annotation := simplejson.NewFromAny(item)
+		if annotation.Get("builtIn").MustInt() != 1 {
+			continue
+		}
+
+		if annotation.Get("datasource").MustString() != "" {
+			return false
+		}
+
+		annotation.Set("datasource", datasource)
+		return true
+	}
+
+	builtIn := map[string]interface{}{
+		"builtIn":    1,
+		"datasource": datasource,
+		"enable":     true,
+		"hide":       true,
+		"iconColor":  "rgba(0, 211, 255, 1)",
+		"name":       "Annotations & Alerts",
+		"type":       "dashboard",
+	}
+	data.SetPath([]string{"annotations", "list"}, append([]interface{}{builtIn}, list...))
+	return true
+}
+
+// revisionTagPrefix prefixes the tag holding the commit a dashboard was provisioned from.
+const revisionTagPrefix = "rev:"
+
+// setRevisionTag replaces any revision tag of the dashboard with one for the given commit so tags don't pile up
+// across scans.
+func setRevisionTag(data *simplejson.Json, sha string) {
+	tags := []interface{}{}
+	for _, tag := range data.Get("tags").MustStringArray() {
+		if !strings.HasPrefix(tag, revisionTagPrefix) {
+			tags = append(tags, tag)
+		}
+	}
+	data.Set("tags", append(tags, revisionTagPrefix+shortRevision(sha)))
+}
+
+// defaultPanelDataLinkTypes are the panel types default panel links are added to when no types are configured.
+var defaultPanelDataLinkTypes = []string{"graph"}
+
+// addDefaultPanelLinks adds the links to every panel of one of the types that does not have a link with the same
+// url yet, so links are only added once no matter how often the dashboard is provisioned. The tokens
+// {{panel.title}}, {{panel.id}}, {{dashboard.title}} and {{dashboard.uid}} in the link values are replaced first.
+// Returns the number of added links.
+func addDefaultPanelLinks(data *simplejson.Json, links []map[string]interface{}, types []string) int {
+	added := 0
+	forEachPanel(data, func(panel *simplejson.Json) {
+		if !containsString(types, panel.Get("type").MustString()) {
+			return
+		}
+
+		replacer := strings.NewReplacer(
+			"{{panel.title}}", panel.Get("title").MustString(),
+			"{{panel.id}}", strconv.FormatInt(panel.Get("id").MustInt64(), 10),
+			"{{dashboard.title}}", data.Get("title").MustString(),
+			"{{dashboard.uid}}", data.Get("uid").MustString(),
+		)
+
+		existing := panel.Get("links").MustArray()
+		for _, link := range links {
+			expanded := map[string]interface{}{}
+			for key, value := range link {
+				if s, ok := value.(string); ok {
+					value = replacer.Replace(s)
+				}
+				expanded[key] = value
+			}
+
+			if hasPanelLinkWithUrl(existing, expanded["url"]) {
+				continue
+			}
+			existing = append(existing, expanded)
+			added++
+		}
+		panel.Set("links", existing)
+	})
+	return added
+}
+
+func hasPanelLinkWithUrl(links []interface{}, url interface{}) bool {
+	for _, link := range links {
+		if simplejson.NewFromAny(link).Get("url").Interface() == url {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
+// sameStrings returns true if both lists hold the same strings in any order.
+func sameStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, item := range a {
+		if !containsString(b, item) {
+			return false
+		}
+	}
+	return true
+}
+
+// addTags adds the tags the dashboard does not have yet and returns them.
+func addTags(data *simplejson.Json, tags []string) []string {
+	existing := data.Get("tags").MustStringArray()
+	merged := make([]interface{}, 0, len(existing)+len(tags))
+	for _, tag := range existing {
+		merged = append(merged, tag)
+	}
+
+	var added []string
+	for _, tag := range tags {
+		if !containsString(existing, tag) && !containsString(added, tag) {
+			merged = append(merged, tag)
+			added = append(added, tag)
+		}
+	}
+
+	if len(added) > 0 {
+		data.Set("tags", merged)
+	}
+	return added
+}
+
+// removeTags removes the tags from the dashboard. Returns true if the dashboard had any of them.
+func removeTags(data *simplejson.Json, tags []string) bool {
+	var kept []interface{}
+	removed := false
+	for _, tag := range data.Get("tags").MustStringArray() {
+		if containsString(tags, tag) {
+			removed = true
+			continue
+		}
+		kept = append(kept, tag)
+	}
+
+	if removed {
+		if kept == nil {
+			kept = []interface{}{}
+		}
+		data.Set("tags", kept)
+	}
+	return removed
+}
+
+// forEachPanel calls fn for every panel of the dashboard, including panels inside collapsed rows and panels of
+// dashboards still using the old rows schema.
+func forEachPanel(data *simplejson.Json, fn func(panel *simplejson.Json)) {
+	for _, item := range data.Get("panels").MustArray() {
+		panel := simplejson.NewFromAny(item)
+		fn(panel)
+		for _, nested := range panel.Get("panels").MustArray() {
+			fn(simplejson.NewFromAny(nested))
+		}
+	}
+
+	for _, row := range data.Get("rows").MustArray() {
+		for _, item := range simplejson.NewFromAny(row).Get("panels").MustArray() {
+			fn(simplejson.NewFromAny(item))
+		}
+	}
+}
+
+// applyCacheTimeoutFromTags looks for a dashboard tag like `<prefix><timeout>` and sets the query cache timeout of
+// every panel that does not set one itself. Returns the timeout and the number of changed panels.
+func applyCacheTimeoutFromTags(data *simplejson.Json, prefix string) (string, int) {
+	timeout := ""
+	for _, tag := range data.Get("tags").MustStringArray() {
+		if strings.HasPrefix(tag, prefix) {
+			timeout = strings.TrimPrefix(tag, prefix)
+			break
+		}
+	}
+
+	if timeout == "" {
+		return "", 0
+	}
+
+	changed := 0
+	forEachPanel(data, func(panel *simplejson.Json) {
+		if panel.Get("cacheTimeout").MustString() != "" || panel.Get("type").MustString() == "row" {
+			return
+		}
+		panel.Set("cacheTimeout", timeout)
+		changed++
+	})
+
+	return timeout, changed
+}
+
+// capMaxDataPoints lowers the maxDataPoints of every panel requesting more than limit to limit. Panels within the
+// limit are left alone. Returns the ids of the changed panels.
+func capMaxDataPoints(data *simplejson.Json, limit int64) []int64 {
+	var changed []int64
+	forEachPanel(data, func(panel *simplejson.Json) {
+		value, ok := panel.CheckGet("maxDataPoints")
+		if !ok {
+			return
+		}
+
+		maxDataPoints, err := value.Int64()
+		if err != nil {
+			// the query options editor stores the value as a string
+			maxDataPoints, err = strconv.ParseInt(value.MustString(), 10, 64)
+			if err != nil {
+				return
+			}
+		}
+
+		if maxDataPoints > limit {
+			panel.Set("maxDataPoints", limit)
+			changed = append(changed, panel.Get("id").MustInt64())
+		}
+	})
+	return changed
+}
+
+// pruneUnusedVariables removes template variables that are not referenced anywhere in the dashboard, either
+// directly or through other referenced variables. Ad hoc filters apply to queries without being referenced so they
+// are always kept. Returns the names of the removed variables.
+func pruneUnusedVariables(data *simplejson.Json) []string {
+	variables := data.GetPath("templating", "list").MustArray()
+	if len(variables) == 0 {
+		return nil
+	}
+
+	// everything but the variables themselves
+	rest := map[string]interface{}{}
+	for key, value := range data.MustMap() {
+		if key != "templating" {
+			rest[key] = value
+		}
+	}
+	restText, err := json.Marshal(rest)
+	if err != nil {
+		return nil
+	}
+
+	repeated := map[string]bool{}
+	collectRepeatedVariables(data.Get("panels"), repeated)
+	collectRepeatedVariables(data.Get("rows"), repeated)
+
+	names := make([]string, len(variables))
+	texts := make([]string, len(variables))
+	used := map[string]bool{}
+	for i, item := range variables {
+		variable := simplejson.NewFromAny(item)
+		names[i] = variable.Get("name").MustString()
+		text, err := variable.Encode()
+		if err != nil {
+			return nil
+		}
+		texts[i] = string(text)
+
+		if variable.Get("type").MustString() == "adhoc" || repeated[names[i]] || referencesVariable(string(restText), names[i]) {
+			used[names[i]] = true
+		}
+	}
+
+	// keep variables referenced by used variables, for example in a chained query
+	for changed := true; changed; {
+		changed = false
+		for i, name := range names {
+			if !used[name] {
+				continue
+			}
+			for _, other := range names {
+				if !used[other] && other != name && referencesVariable(texts[i], other) {
+					used[other] = true
+					changed = true
+				}
+			}
+		}
+	}
+
+	var kept []interface{}
+	var removed []string
+	for i, item := range variables {
+		if used[names[i]] {
+			kept = append(kept, item)
+		} else {
+			removed = append(removed, names[i])
+		}
+	}
+
+	if len(removed) > 0 {
+		if kept == nil {
+			kept = []interface{}{}
+		}
+		data.SetPath([]string{"templating", "list"}, kept)
+	}
+	return removed
+}
+
+// collectRepeatedVariables adds the variables panels and rows repeat by to repeated. Repeats name the variable
+// without $, so they are not found by referencesVariable. Panels of rows are walked too.
+func collectRepeatedVariables(panels *simplejson.Json, repeated map[string]bool) {
+	for _, item := range panels.MustArray() {
+		panel := simplejson.NewFromAny(item)
+		if name := panel.Get("repeat").MustString(); name != "" {
+			repeated[name] = true
+		}
+		collectRepeatedVariables(panel.Get("panels"), repeated)
+	}
+}
+
+// referencesVariable returns true if text contains $name, ${name}, ${name:format} or [[name]].
+func referencesVariable(text string, name string) bool {
+	if name == "" {
+		return false
+	}
+	quoted := regexp.QuoteMeta(name)
+	pattern := regexp.MustCompile(`\$` + quoted + `\b|\$\{` + quoted + `(:[^}]*)?\}|\[\[` + quoted + `(:[^\]]*)?\]\]`)
+	return pattern.MatchString(text)
+}
+
+type gridPos struct {
+	json       *simplejson.Json
+	x, y, w, h int
+}
+
+func (a *gridPos) overlaps(b *gridPos) bool {
+	return a.x < b.x+b.w && b.x < a.x+a.w && a.y < b.y+b.h && b.y < a.y+a.h
+}
+
+// normalizeGridLayout repacks the top level panels if any of them overlap. Panels are placed in their original
+// reading order and each one is moved down until it no longer overlaps a panel placed before it, so the result is
+// deterministic and stays close to the authored layout. Returns true if the layout was changed.
+func normalizeGridLayout(data *simplejson.Json) bool {
+	var positions []*gridPos
+	for _, item := range data.Get("panels").MustArray() {
+		pos, ok := simplejson.NewFromAny(item).CheckGet("gridPos")
+		if !ok {
+			continue
+		}
+
+		positions = append(positions, &gridPos{
+			json: pos,
+			x:    pos.Get("x").MustInt(),
+			y:    pos.Get("y").MustInt(),
+			w:    pos.Get("w").MustInt(),
+			h:    pos.Get("h").MustInt(),
+		})
+	}
+
+	if !hasOverlappingPanels(positions) {
+		return false
+	}
+
+	sort.SliceStable(positions, func(i, j int) bool {
+		if positions[i].y != positions[j].y {
+			return positions[i].y < positions[j].y
+		}
+		return positions[i].x < positions[j].x
+	})
+
+	var placed []*gridPos
+	for _, pos := range positions {
+		if pos.w > gridColumnCount {
+			pos.w = gridColumnCount
+		}
+		if pos.x+pos.w > gridColumnCount {
+			pos.x = gridColumnCount - pos.w
+		}
+
+		for moved := true; moved; {
+			moved = false
+			for _, other := range placed {
+				if pos.overlaps(other) {
+					pos.y = other.y + other.h
+					moved = true
+				}
+			}
+		}
+		placed = append(placed, pos)
+
+		pos.json.Set("x", pos.x)
+		pos.json.Set("y", pos.y)
+		pos.json.Set("w", pos.w)
+	}
+
+	return true
+}
+
+func hasOverlappingPanels(positions []*gridPos) bool {
+	for i := range positions {
+		for j := i + 1; j < len(positions); j++ {
+			if positions[i].overlaps(positions[j]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// defaultRelativeTimeWindow is used when an absolute time range cannot be turned into a window of the same length.
+const defaultRelativeTimeWindow = 6 * time.Hour
+
+// normalizeTimeToRelative replaces an absolute time range of the dashboard with a relative one ending now. The window
+// keeps the length of the absolute range if both ends are absolute, otherwise the default window is used. Returns
+// false if the dashboard only uses relative times.
+func normalizeTimeToRelative(data *simplejson.Json) bool {
+	from, fromAbsolute := parseAbsoluteTime(data.GetPath("time", "from").Interface())
+	to, toAbsolute := parseAbsoluteTime(data.GetPath("time", "to").Interface())
+	if !fromAbsolute && !toAbsolute {
+		return false
+	}
+
+	window := defaultRelativeTimeWindow
+	if fromAbsolute && toAbsolute && to.After(from) {
+		window = to.Sub(from)
+	}
+
+	data.SetPath([]string{"time", "from"}, "now-"+formatRelativeWindow(window))
+	data.SetPath([]string{"time", "to"}, "now")
+	return true
+}
+
+// parseAbsoluteTime parses the absolute times the frontend stores in dashboards, either as ISO 8601 string or as
+// epoch in milliseconds.
+func parseAbsoluteTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		ms, err := v.Int64()
+		return time.Unix(0, ms*int64(time.Millisecond)), err == nil
+	case float64:
+		return time.Unix(0, int64(v)*int64(time.Millisecond)), true
+	case string:
+		if strings.HasPrefix(v, "now") {
+			return time.Time{}, false
+		}
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Unix(0, ms*int64(time.Millisecond)), true
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+// formatRelativeWindow formats the window in the largest unit of days, hours and minutes that expresses it exactly,
+// rounding up to whole minutes.
+func formatRelativeWindow(window time.Duration) string {
+	minutes := int64((window + time.Minute - 1) / time.Minute)
+	switch {
+	case minutes%(24*60) == 0:
+		return strconv.FormatInt(minutes/(24*60), 10) + "d"
+	case minutes%60 == 0:
+		return strconv.FormatInt(minutes/60, 10) + "h"
+	default:
+		return strconv.FormatInt(minutes, 10) + "m"
+	}
+}
@@ -0,0 +1,640 @@
+package dashboards
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDashboardTransforms(t *testing.T) {
+	Convey("Dashboard transforms", t, func() {
+		Convey("Default annotation datasource", func() {
+			Convey("should be set on built in annotation query without datasource", func() {
+				data, err := simplejson.NewJson([]byte(`{"annotations": {"list": [{"builtIn": 1, "name": "Annotations & Alerts"}]}}`))
+				So(err, ShouldBeNil)
+
+				So(setDefaultAnnotationDatasource(data, "Loki"), ShouldBeTrue)
+				So(data.GetPath("annotations", "list").GetIndex(0).Get("datasource").MustString(), ShouldEqual, "Loki")
+			})
+
+			Convey("should add built in annotation query if missing", func() {
+				data, err := simplejson.NewJson([]byte(`{"annotations": {"list": [{"name": "Deploys", "datasource": "Elastic"}]}}`))
+				So(err, ShouldBeNil)
+
+				So(setDefaultAnnotationDatasource(data, "Loki"), ShouldBeTrue)
+				list := data.GetPath("annotations", "list")
+				So(len(list.MustArray()), ShouldEqual, 2)
+				So(list.GetIndex(0).Get("builtIn").MustInt(), ShouldEqual, 1)
+				So(list.GetIndex(0).Get("datasource").MustString(), ShouldEqual, "Loki")
+				So(list.GetIndex(1).Get("datasource").MustString(), ShouldEqual, "Elastic")
+			})
+
+			Convey("should preserve datasource set by author", func() {
+				data, err := simplejson.NewJson([]byte(`{"annotations": {"list": [{"builtIn": 1, "datasource": "-- Grafana --"}]}}`))
+				So(err, ShouldBeNil)
+
+				So(setDefaultAnnotationDatasource(data, "Loki"), ShouldBeFalse)
+				So(data.GetPath("annotations", "list").GetIndex(0).Get("datasource").MustString(), ShouldEqual, "-- Grafana --")
+			})
+		})
+
+		Convey("Grid layout normalization", func() {
+			gridPosOf := func(data *simplejson.Json, i int) []int {
+				pos := data.Get("panels").GetIndex(i).Get("gridPos")
+				return []int{pos.Get("x").MustInt(), pos.Get("y").MustInt(), pos.Get("w").MustInt(), pos.Get("h").MustInt()}
+			}
+
+			Convey("should repack overlapping panels", func() {
+				data, err := simplejson.NewJson([]byte(`{"panels": [
+					{"id": 1, "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}},
+					{"id": 2, "gridPos": {"x": 6, "y": 4, "w": 12, "h": 8}},
+					{"id": 3, "gridPos": {"x": 12, "y": 0, "w": 12, "h": 4}}
+				]}`))
+				So(err, ShouldBeNil)
+
+				So(normalizeGridLayout(data), ShouldBeTrue)
+				So(gridPosOf(data, 0), ShouldResemble, []int{0, 0, 12, 8})
+				So(gridPosOf(data, 1), ShouldResemble, []int{6, 8, 12, 8})
+				So(gridPosOf(data, 2), ShouldResemble, []int{12, 0, 12, 4})
+			})
+
+			Convey("should leave valid layout untouched", func() {
+				data, err := simplejson.NewJson([]byte(`{"panels": [
+					{"id": 1, "gridPos": {"x": 0, "y": 0, "w": 12, "h": 8}},
+					{"id": 2, "gridPos": {"x": 12, "y": 0, "w": 12, "h": 8}}
+				]}`))
+				So(err, ShouldBeNil)
+
+				So(normalizeGridLayout(data), ShouldBeFalse)
+				So(gridPosOf(data, 1), ShouldResemble, []int{12, 0, 12, 8})
+			})
+		})
+
+		Convey("Cache timeout from tags", func() {
+			data, err := simplejson.NewJson([]byte(`{
+				"tags": ["network", "cache:5m"],
+				"panels": [
+					{"id": 1, "type": "graph"},
+					{"id": 2, "type": "graph", "cacheTimeout": "60"},
+					{"id": 3, "type": "row", "collapsed": true, "panels": [{"id": 4, "type": "table"}]}
+				]
+			}`))
+			So(err, ShouldBeNil)
+
+			timeout, changed := applyCacheTimeoutFromTags(data, "cache:")
+			So(timeout, ShouldEqual, "5m")
+			So(changed, ShouldEqual, 2)
+
+			panels := data.Get("panels")
+			So(panels.GetIndex(0).Get("cacheTimeout").MustString(), ShouldEqual, "5m")
+			So(panels.GetIndex(1).Get("cacheTimeout").MustString(), ShouldEqual, "60")
+			So(panels.GetIndex(2).Get("panels").GetIndex(0).Get("cacheTimeout").MustString(), ShouldEqual, "5m")
+
+			Convey("should be idempotent", func() {
+				_, changed := applyCacheTimeoutFromTags(data, "cache:")
+				So(changed, ShouldEqual, 0)
+			})
+		})
+
+		Convey("Max data points cap", func() {
+			data, err := simplejson.NewJson([]byte(`{"panels": [
+				{"id": 1, "maxDataPoints": 10000},
+				{"id": 2, "maxDataPoints": 300},
+				{"id": 3, "maxDataPoints": "5000"},
+				{"id": 4}
+			]}`))
+			So(err, ShouldBeNil)
+
+			So(capMaxDataPoints(data, 1000), ShouldResemble, []int64{1, 3})
+
+			panels := data.Get("panels")
+			So(panels.GetIndex(0).Get("maxDataPoints").MustInt64(), ShouldEqual, 1000)
+			So(panels.GetIndex(1).Get("maxDataPoints").MustInt64(), ShouldEqual, 300)
+			So(panels.GetIndex(2).Get("maxDataPoints").MustInt64(), ShouldEqual, 1000)
+			_, hasMaxDataPoints := panels.GetIndex(3).CheckGet("maxDataPoints")
+			So(hasMaxDataPoints, ShouldBeFalse)
+		})
+
+		Convey("Folder timezones", func() {
+			cfg := &DashboardsAsConfig{
+				Name:  "Default",
+				Type:  "file",
+				OrgId: 1,
+				Options: map[string]interface{}{
+					"path": oneDashboard,
+					"folderTimezones": map[interface{}]interface{}{
+						"Ops": "utc",
+						"US":  "America/New_York",
+					},
+				},
+			}
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			Convey("should set timezone mapped to the folder", func() {
+				ops := simplejson.NewFromAny(map[string]interface{}{"title": "Ops"})
+				reader.transformDashboard("ops.json", "Ops", ops)
+				So(ops.Get("timezone").MustString(), ShouldEqual, "utc")
+
+				us := simplejson.NewFromAny(map[string]interface{}{"title": "US", "timezone": ""})
+				reader.transformDashboard("us.json", "US", us)
+				So(us.Get("timezone").MustString(), ShouldEqual, "America/New_York")
+			})
+
+			Convey("should preserve timezone set by author", func() {
+				data := simplejson.NewFromAny(map[string]interface{}{"title": "Ops", "timezone": "browser"})
+				reader.transformDashboard("ops.json", "Ops", data)
+				So(data.Get("timezone").MustString(), ShouldEqual, "browser")
+			})
+
+			Convey("should leave dashboards in unmapped folders alone", func() {
+				data := simplejson.NewFromAny(map[string]interface{}{"title": "Dev"})
+				reader.transformDashboard("dev.json", "Dev", data)
+				_, hasTimezone := data.CheckGet("timezone")
+				So(hasTimezone, ShouldBeFalse)
+			})
+
+			Convey("should reject unknown zones", func() {
+				cfg.Options["folderTimezones"] = map[interface{}]interface{}{"Ops": "utc", "US": "Mars/Olympus"}
+				_, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, `folderTimezones of folder "US"`)
+			})
+		})
+
+		Convey("With pruneUnusedVariables", func() {
+			cfg := &DashboardsAsConfig{
+				Name:    "Default",
+				Type:    "file",
+				OrgId:   1,
+				Options: map[string]interface{}{"path": oneDashboard, "pruneUnusedVariables": true},
+			}
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			data := simplejson.NewFromAny(map[string]interface{}{
+				"title": "Test",
+				"templating": map[string]interface{}{
+					"list": []interface{}{
+						map[string]interface{}{"name": "region", "type": "query", "query": "regions()"},
+						map[string]interface{}{"name": "host", "type": "query", "query": "hosts(${region:regex})"},
+						map[string]interface{}{"name": "unused", "type": "custom", "query": "a,b"},
+						map[string]interface{}{"name": "interval", "type": "interval", "query": "1m,5m"},
+						map[string]interface{}{"name": "filters", "type": "adhoc"},
+					},
+				},
+				"panels": []interface{}{
+					map[string]interface{}{"id": 1, "type": "row", "collapsed": true, "panels": []interface{}{
+						map[string]interface{}{"id": 2, "targets": []interface{}{
+							map[string]interface{}{"expr": "rate(cpu{host=~\"$host\"}[[[interval]]])"},
+						}},
+					}},
+				},
+			})
+
+			reader.transformDashboard("dash.json", "", data)
+
+			var names []string
+			for _, variable := range data.GetPath("templating", "list").MustArray() {
+				names = append(names, simplejson.NewFromAny(variable).Get("name").MustString())
+			}
+			So(names, ShouldResemble, []string{"region", "host", "interval", "filters"})
+		})
+
+		Convey("With pruneUnusedVariables and repeated panels and rows", func() {
+			cfg := &DashboardsAsConfig{
+				Name:    "Default",
+				Type:    "file",
+				OrgId:   1,
+				Options: map[string]interface{}{"path": oneDashboard, "pruneUnusedVariables": true},
+			}
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			data, err := simplejson.NewJson([]byte(`{
+				"title": "Test",
+				"templating": {"list": [
+					{"name": "server", "type": "query"},
+					{"name": "dc", "type": "query"},
+					{"name": "disk", "type": "query"},
+					{"name": "legacy", "type": "query"},
+					{"name": "unused", "type": "custom"}
+				]},
+				"panels": [
+					{"id": 1, "type": "graph", "repeat": "server"},
+					{"id": 2, "type": "row", "repeat": "dc", "collapsed": true, "panels": [
+						{"id": 3, "type": "graph", "repeat": "disk"}
+					]}
+				],
+				"rows": [{"repeat": "legacy", "panels": []}]
+			}`))
+			So(err, ShouldBeNil)
+
+			reader.transformDashboard("dash.json", "", data)
+
+			var names []string
+			for _, variable := range data.GetPath("templating", "list").MustArray() {
+				names = append(names, simplejson.NewFromAny(variable).Get("name").MustString())
+			}
+			So(names, ShouldResemble, []string{"server", "dc", "disk", "legacy"})
+		})
+
+		Convey("With datasourceMappings", func() {
+			cfg := &DashboardsAsConfig{
+				Name:  "Default",
+				Type:  "file",
+				OrgId: 1,
+				Options: map[string]interface{}{
+					"path":               oneDashboard,
+					"datasourceMappings": map[string]interface{}{"prom-old": "Prometheus", "es-old": "Elastic"},
+				},
+			}
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			data, err := simplejson.NewJson([]byte(`{
+				"annotations": {"list": [{"name": "Deploys", "datasource": "es-old"}]},
+				"templating": {"list": [{"name": "host", "type": "query", "datasource": "prom-old"}]},
+				"panels": [
+					{"type": "graph", "datasource": "prom-old", "targets": [{"expr": "up", "datasource": "es-old"}]},
+					{"type": "row", "panels": [{"type": "table", "datasource": "prom-old"}]},
+					{"type": "singlestat", "libraryPanel": {"model": {"datasource": "es-old"}}},
+					{"type": "graph", "datasource": "Graphite"}
+				]
+			}`))
+			So(err, ShouldBeNil)
+
+			reader.transformDashboard("dash.json", "", data)
+
+			Convey("should replace mapped datasources anywhere in the dashboard", func() {
+				So(data.GetPath("annotations", "list").GetIndex(0).Get("datasource").MustString(), ShouldEqual, "Elastic")
+				So(data.GetPath("templating", "list").GetIndex(0).Get("datasource").MustString(), ShouldEqual, "Prometheus")
+
+				panels := data.Get("panels")
+				So(panels.GetIndex(0).Get("datasource").MustString(), ShouldEqual, "Prometheus")
+				So(panels.GetIndex(0).Get("targets").GetIndex(0).Get("datasource").MustString(), ShouldEqual, "Elastic")
+				So(panels.GetIndex(1).Get("panels").GetIndex(0).Get("datasource").MustString(), ShouldEqual, "Prometheus")
+				So(panels.GetIndex(2).GetPath("libraryPanel", "model", "datasource").MustString(), ShouldEqual, "Elastic")
+			})
+
+			Convey("should leave unmapped datasources alone", func() {
+				So(data.Get("panels").GetIndex(3).Get("datasource").MustString(), ShouldEqual, "Graphite")
+			})
+		})
+
+		Convey("With normalizeTimeToRelative", func() {
+			cfg := &DashboardsAsConfig{
+				Name:    "Default",
+				Type:    "file",
+				OrgId:   1,
+				Options: map[string]interface{}{"path": oneDashboard, "normalizeTimeToRelative": true},
+			}
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			Convey("should convert absolute time range to relative window of the same length", func() {
+				data, err := simplejson.NewJson([]byte(`{"time": {"from": "2019-05-01T06:00:00.000Z", "to": "2019-05-01T18:00:00.000Z"}}`))
+				So(err, ShouldBeNil)
+
+				reader.transformDashboard("dash.json", "", data)
+				So(data.GetPath("time", "from").MustString(), ShouldEqual, "now-12h")
+				So(data.GetPath("time", "to").MustString(), ShouldEqual, "now")
+			})
+
+			Convey("should convert epoch time range", func() {
+				data, err := simplejson.NewJson([]byte(`{"time": {"from": 1556668800000, "to": "1556841600000"}}`))
+				So(err, ShouldBeNil)
+
+				reader.transformDashboard("dash.json", "", data)
+				So(data.GetPath("time", "from").MustString(), ShouldEqual, "now-2d")
+				So(data.GetPath("time", "to").MustString(), ShouldEqual, "now")
+			})
+
+			Convey("should preserve relative time range", func() {
+				data, err := simplejson.NewJson([]byte(`{"time": {"from": "now-7d", "to": "now-1h"}}`))
+				So(err, ShouldBeNil)
+
+				reader.transformDashboard("dash.json", "", data)
+				So(data.GetPath("time", "from").MustString(), ShouldEqual, "now-7d")
+				So(data.GetPath("time", "to").MustString(), ShouldEqual, "now-1h")
+			})
+		})
+
+		Convey("With forceStyle", func() {
+			cfg := &DashboardsAsConfig{
+				Name:    "Default",
+				Type:    "file",
+				OrgId:   1,
+				Options: map[string]interface{}{"path": oneDashboard, "forceStyle": "dark"},
+			}
+
+			Convey("should override style set by author", func() {
+				reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				data := simplejson.NewFromAny(map[string]interface{}{"title": "TV", "style": "light"})
+				reader.transformDashboard("tv.json", "", data)
+				So(data.Get("style").MustString(), ShouldEqual, "dark")
+			})
+
+			Convey("should reject unknown style", func() {
+				cfg.Options["forceStyle"] = "blue"
+				_, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("With titlePrefix and titleSuffix", func() {
+			cfg := &DashboardsAsConfig{
+				Name:    "Default",
+				Type:    "file",
+				OrgId:   1,
+				Options: map[string]interface{}{"path": oneDashboard, "titlePrefix": "[STAGING] ", "titleSuffix": " (eu)"},
+			}
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			Convey("should add them to the title but not the uid", func() {
+				data := simplejson.NewFromAny(map[string]interface{}{"uid": "cpu", "title": "CPU"})
+				reader.transformDashboard("cpu.json", "", data)
+				So(data.Get("title").MustString(), ShouldEqual, "[STAGING] CPU (eu)")
+				So(data.Get("uid").MustString(), ShouldEqual, "cpu")
+			})
+
+			Convey("should not add them twice", func() {
+				data := simplejson.NewFromAny(map[string]interface{}{"title": "CPU"})
+				reader.transformDashboard("cpu.json", "", data)
+				reader.transformDashboard("cpu.json", "", data)
+				So(data.Get("title").MustString(), ShouldEqual, "[STAGING] CPU (eu)")
+
+				exported := simplejson.NewFromAny(map[string]interface{}{"title": "[STAGING] CPU"})
+				reader.transformDashboard("cpu.json", "", exported)
+				So(exported.Get("title").MustString(), ShouldEqual, "[STAGING] CPU (eu)")
+			})
+		})
+
+		Convey("With refreshOverride", func() {
+			cfg := &DashboardsAsConfig{
+				Name:    "Default",
+				Type:    "file",
+				OrgId:   1,
+				Options: map[string]interface{}{"path": oneDashboard, "refreshOverride": "1m"},
+			}
+
+			refreshOf := func(reader *fileReader, refresh interface{}) interface{} {
+				data := simplejson.NewFromAny(map[string]interface{}{"title": "CPU"})
+				if refresh != nil {
+					data.Set("refresh", refresh)
+				}
+				reader.transformDashboard("cpu.json", "", data)
+				return data.Get("refresh").Interface()
+			}
+
+			Convey("should replace the refresh interval set by the author", func() {
+				reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				So(refreshOf(reader, "5s"), ShouldEqual, "1m")
+				So(refreshOf(reader, "1h"), ShouldEqual, "1m")
+				So(refreshOf(reader, false), ShouldEqual, "1m")
+				So(refreshOf(reader, nil), ShouldEqual, "1m")
+			})
+
+			Convey("and clampRefresh should only raise shorter intervals", func() {
+				cfg.Options["clampRefresh"] = true
+				reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				So(refreshOf(reader, "5s"), ShouldEqual, "1m")
+				So(refreshOf(reader, "5m"), ShouldEqual, "5m")
+				So(refreshOf(reader, "1d"), ShouldEqual, "1d")
+				So(refreshOf(reader, false), ShouldEqual, false)
+			})
+
+			Convey("should reject invalid intervals", func() {
+				cfg.Options["refreshOverride"] = "often"
+				_, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("With timezoneOverride", func() {
+			cfg := &DashboardsAsConfig{
+				Name:    "Default",
+				Type:    "file",
+				OrgId:   1,
+				Options: map[string]interface{}{"path": oneDashboard, "timezoneOverride": "utc"},
+			}
+
+			Convey("should override the timezone of the dashboard and of its folder", func() {
+				cfg.Options["folderTimezones"] = map[interface{}]interface{}{"Ops": "America/New_York"}
+				reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				data := simplejson.NewFromAny(map[string]interface{}{"title": "CPU", "timezone": "browser"})
+				reader.transformDashboard("cpu.json", "Ops", data)
+				So(data.Get("timezone").MustString(), ShouldEqual, "utc")
+
+				data = simplejson.NewFromAny(map[string]interface{}{"title": "CPU"})
+				reader.transformDashboard("cpu.json", "Ops", data)
+				So(data.Get("timezone").MustString(), ShouldEqual, "utc")
+			})
+
+			Convey("should accept browser and IANA zones", func() {
+				for _, timezone := range []string{"browser", "Europe/Berlin", "UTC"} {
+					cfg.Options["timezoneOverride"] = timezone
+					_, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+					So(err, ShouldBeNil)
+				}
+			})
+
+			Convey("should reject unknown zones", func() {
+				for _, timezone := range []string{"Mars/Olympus", "Local", "+02:00"} {
+					cfg.Options["timezoneOverride"] = timezone
+					_, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+					So(err, ShouldNotBeNil)
+				}
+			})
+		})
+
+		Convey("With editable", func() {
+			cfg := &DashboardsAsConfig{
+				Name:    "Default",
+				Type:    "file",
+				OrgId:   1,
+				Options: map[string]interface{}{"path": oneDashboard},
+			}
+
+			editableOf := func(editable interface{}) interface{} {
+				reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				data := simplejson.NewFromAny(map[string]interface{}{"title": "CPU", "editable": editable})
+				reader.transformDashboard("cpu.json", "", data)
+				return data.Get("editable").Interface()
+			}
+
+			Convey("set to false should make every dashboard read-only", func() {
+				cfg.EditableSet = true
+				So(editableOf(true), ShouldEqual, false)
+				So(editableOf(false), ShouldEqual, false)
+			})
+
+			Convey("not set should keep the editable field of the file", func() {
+				So(editableOf(true), ShouldEqual, true)
+				So(editableOf(false), ShouldEqual, false)
+			})
+
+			Convey("read from a config file", func() {
+				dir, err := ioutil.TempDir("", "provisioning-editable")
+				So(err, ShouldBeNil)
+				defer os.RemoveAll(dir)
+
+				config := filepath.Join(dir, "dashboards.yaml")
+				content := "apiVersion: 1\nproviders:\n" +
+					"- name: unset\n  type: file\n  options:\n    path: " + oneDashboard + "\n" +
+					"- name: editable\n  type: file\n  editable: true\n  options:\n    path: " + oneDashboard + "\n"
+				So(ioutil.WriteFile(config, []byte(content), 0644), ShouldBeNil)
+
+				configs, err := ReadConfigFile(config, log.New("test-logger"))
+				So(err, ShouldBeNil)
+				So(len(configs), ShouldEqual, 2)
+
+				Convey("without editable should leave the field of the file alone", func() {
+					cfg = configs[0]
+					So(editableOf(false), ShouldEqual, false)
+					So(editableOf(true), ShouldEqual, true)
+				})
+
+				Convey("with editable true should override a file saying false", func() {
+					cfg = configs[1]
+					So(editableOf(false), ShouldEqual, true)
+				})
+			})
+		})
+
+		Convey("With defaultPanelDataLinks", func() {
+			cfg := &DashboardsAsConfig{
+				Name:  "Default",
+				Type:  "file",
+				OrgId: 1,
+				Options: map[string]interface{}{
+					"path": oneDashboard,
+					"defaultPanelDataLinks": []interface{}{
+						map[interface{}]interface{}{
+							"title":       "Traces",
+							"url":         "/d/traces?var-panel={{panel.title}}&var-dashboard={{dashboard.uid}}",
+							"targetBlank": true,
+						},
+					},
+				},
+			}
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			data := simplejson.NewFromAny(map[string]interface{}{
+				"title": "Test",
+				"uid":   "abc",
+				"panels": []interface{}{
+					map[string]interface{}{"id": 1, "type": "graph", "title": "Requests"},
+					map[string]interface{}{"id": 2, "type": "table", "title": "Top"},
+				},
+			})
+
+			Convey("should add link to graph panels only once across scans", func() {
+				reader.transformDashboard("dash.json", "", data)
+				reader.transformDashboard("dash.json", "", data)
+
+				graph := data.Get("panels").GetIndex(0)
+				So(len(graph.Get("links").MustArray()), ShouldEqual, 1)
+				So(graph.Get("links").GetIndex(0).Get("url").MustString(), ShouldEqual, "/d/traces?var-panel=Requests&var-dashboard=abc")
+				So(graph.Get("links").GetIndex(0).Get("targetBlank").MustBool(), ShouldBeTrue)
+
+				_, hasLinks := data.Get("panels").GetIndex(1).CheckGet("links")
+				So(hasLinks, ShouldBeFalse)
+			})
+
+			Convey("should add link to configured panel types", func() {
+				cfg.Options["defaultPanelDataLinkTypes"] = []interface{}{"table"}
+				reader.transformDashboard("dash.json", "", data)
+
+				_, hasLinks := data.Get("panels").GetIndex(0).CheckGet("links")
+				So(hasLinks, ShouldBeFalse)
+				So(len(data.Get("panels").GetIndex(1).Get("links").MustArray()), ShouldEqual, 1)
+			})
+		})
+
+		Convey("With variablizeDatasources", func() {
+			bus.ClearBusHandlers()
+			bus.AddHandler("test", func(query *models.GetDataSourceByNameQuery) error {
+				if query.Name != "Graphite" {
+					return models.ErrDataSourceNotFound
+				}
+				query.Result = &models.DataSource{Name: "Graphite", Type: "graphite"}
+				return nil
+			})
+
+			cfg := &DashboardsAsConfig{
+				Name:    "Default",
+				Type:    "file",
+				OrgId:   1,
+				Options: map[string]interface{}{"path": oneDashboard, "variablizeDatasources": true},
+			}
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			data := simplejson.NewFromAny(map[string]interface{}{
+				"title": "Exported",
+				"__requires": []interface{}{
+					map[string]interface{}{"type": "panel", "id": "graph"},
+					map[string]interface{}{"type": "datasource", "id": "prometheus"},
+				},
+				"annotations": map[string]interface{}{
+					"list": []interface{}{
+						map[string]interface{}{"builtIn": 1, "datasource": "-- Grafana --"},
+						map[string]interface{}{"name": "Deploys", "datasource": "prod-prometheus"},
+					},
+				},
+				"templating": map[string]interface{}{
+					"list": []interface{}{
+						map[string]interface{}{"name": "job", "type": "query", "datasource": "prod-prometheus"},
+					},
+				},
+				"panels": []interface{}{
+					map[string]interface{}{"id": 1, "type": "graph", "datasource": "prod-prometheus"},
+					map[string]interface{}{"id": 2, "type": "graph", "datasource": "-- Mixed --", "targets": []interface{}{
+						map[string]interface{}{"refId": "A", "datasource": "prod-prometheus"},
+						map[string]interface{}{"refId": "B", "datasource": "Graphite"},
+					}},
+					map[string]interface{}{"id": 3, "type": "graph", "datasource": nil},
+				},
+			})
+
+			reader.transformDashboard("exported.json", "", data)
+
+			panels := data.Get("panels")
+			So(panels.GetIndex(0).Get("datasource").MustString(), ShouldEqual, "$datasource")
+			So(panels.GetIndex(1).Get("datasource").MustString(), ShouldEqual, "-- Mixed --")
+			So(panels.GetIndex(1).Get("targets").GetIndex(0).Get("datasource").MustString(), ShouldEqual, "$datasource")
+			So(panels.GetIndex(1).Get("targets").GetIndex(1).Get("datasource").MustString(), ShouldEqual, "$datasource_graphite")
+			So(panels.GetIndex(2).Get("datasource").Interface(), ShouldBeNil)
+			So(data.GetPath("annotations", "list").GetIndex(0).Get("datasource").MustString(), ShouldEqual, "-- Grafana --")
+			So(data.GetPath("annotations", "list").GetIndex(1).Get("datasource").MustString(), ShouldEqual, "$datasource")
+
+			variables := data.GetPath("templating", "list")
+			So(len(variables.MustArray()), ShouldEqual, 3)
+			So(variables.GetIndex(0).Get("name").MustString(), ShouldEqual, "datasource")
+			So(variables.GetIndex(0).Get("type").MustString(), ShouldEqual, "datasource")
+			So(variables.GetIndex(0).Get("query").MustString(), ShouldEqual, "prometheus")
+			So(variables.GetIndex(1).Get("name").MustString(), ShouldEqual, "datasource_graphite")
+			So(variables.GetIndex(2).Get("datasource").MustString(), ShouldEqual, "$datasource")
+		})
+	})
+}
@@ -10,16 +10,44 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 )
 
+const (
+	// DefaultUpdateIntervalSeconds is used for providers that don't set updateIntervalSeconds.
+	DefaultUpdateIntervalSeconds = 10
+	// defaultMinUpdateIntervalSeconds is the shortest update interval allowed unless the minUpdateIntervalSeconds
+	// option sets another one.
+	defaultMinUpdateIntervalSeconds = 5
+)
+
 type DashboardsAsConfig struct {
-	Name                  string
-	Type                  string
-	OrgId                 int64
-	Folder                string
-	FolderUid             string
-	Editable              bool
-	Options               map[string]interface{}
-	DisableDeletion       bool
+	Name            string
+	Type            string
+	OrgId           int64
+	Folder          string
+	FolderUid       string
+	Options         map[string]interface{}
+	DisableDeletion bool
+	// Editable is written into the editable field of every dashboard when EditableSet is true, which it is when the
+	// provider sets editable. Otherwise the field is left as set in the files.
+	Editable    bool
+	EditableSet bool
+	// UpdateIntervalSeconds is how often the provider is scanned for changes. 0 means DefaultUpdateIntervalSeconds,
+	// intervals shorter than the minUpdateIntervalSeconds option, 5 seconds by default, are raised to it.
 	UpdateIntervalSeconds int64
+	// TransactionGroup names a group of providers that are scanned together so either all or none of their
+	// changes are saved.
+	TransactionGroup string
+	// Jpath lists the library directories jsonnet dashboards import from. Relative directories are relative to the
+	// path of the provider.
+	Jpath []string
+	// OrgIds lists the orgs the dashboards are provisioned into instead of OrgId. AllOrgs, set by orgId "*",
+	// provisions them into every org, including orgs created later. Their provisioning data is kept per org.
+	OrgIds  []int64
+	AllOrgs bool
+	// Tags are added to every dashboard of the provider like the addTags option.
+	Tags []string
+	// ConfigPath is the config file the provider was read from. Relative paths of the provider are relative to its
+	// directory, or to the working directory for providers not read from a file.
+	ConfigPath string
 }
 
 type DashboardsAsConfigV0 struct {
@@ -28,10 +56,13 @@ type DashboardsAsConfigV0 struct {
 	OrgId                 int64                  `json:"org_id" yaml:"org_id"`
 	Folder                string                 `json:"folder" yaml:"folder"`
 	FolderUid             string                 `json:"folderUid" yaml:"folderUid"`
-	Editable              bool                   `json:"editable" yaml:"editable"`
+	Editable              *bool                  `json:"editable" yaml:"editable"`
 	Options               map[string]interface{} `json:"options" yaml:"options"`
 	DisableDeletion       bool                   `json:"disableDeletion" yaml:"disableDeletion"`
 	UpdateIntervalSeconds int64                  `json:"updateIntervalSeconds" yaml:"updateIntervalSeconds"`
+	TransactionGroup      string                 `json:"transactionGroup" yaml:"transactionGroup"`
+	Jpath                 []string               `json:"jpath" yaml:"jpath"`
+	Tags                  []string               `json:"tags" yaml:"tags"`
 }
 
 type ConfigVersion struct {
@@ -43,15 +74,19 @@ type DashboardAsConfigV1 struct {
 }
 
 type DashboardProviderConfigs struct {
-	Name                  values.StringValue `json:"name" yaml:"name"`
-	Type                  values.StringValue `json:"type" yaml:"type"`
-	OrgId                 values.Int64Value  `json:"orgId" yaml:"orgId"`
-	Folder                values.StringValue `json:"folder" yaml:"folder"`
-	FolderUid             values.StringValue `json:"folderUid" yaml:"folderUid"`
-	Editable              values.BoolValue   `json:"editable" yaml:"editable"`
-	Options               values.JSONValue   `json:"options" yaml:"options"`
-	DisableDeletion       values.BoolValue   `json:"disableDeletion" yaml:"disableDeletion"`
-	UpdateIntervalSeconds values.Int64Value  `json:"updateIntervalSeconds" yaml:"updateIntervalSeconds"`
+	Name                  values.StringValue      `json:"name" yaml:"name"`
+	Type                  values.StringValue      `json:"type" yaml:"type"`
+	OrgId                 orgIdValue              `json:"orgId" yaml:"orgId"`
+	OrgIds                values.Int64SliceValue  `json:"orgIds" yaml:"orgIds"`
+	Folder                values.StringValue      `json:"folder" yaml:"folder"`
+	FolderUid             values.StringValue      `json:"folderUid" yaml:"folderUid"`
+	Editable              values.BoolValue        `json:"editable" yaml:"editable"`
+	Options               values.JSONValue        `json:"options" yaml:"options"`
+	DisableDeletion       values.BoolValue        `json:"disableDeletion" yaml:"disableDeletion"`
+	UpdateIntervalSeconds values.Int64Value       `json:"updateIntervalSeconds" yaml:"updateIntervalSeconds"`
+	TransactionGroup      values.StringValue      `json:"transactionGroup" yaml:"transactionGroup"`
+	Jpath                 values.StringSliceValue `json:"jpath" yaml:"jpath"`
+	Tags                  values.StringSliceValue `json:"tags" yaml:"tags"`
 }
 
 func createDashboardJson(data *simplejson.Json, lastModified time.Time, cfg *DashboardsAsConfig, folderId int64) (*dashboards.SaveDashboardDTO, error) {
@@ -80,10 +115,14 @@ func mapV0ToDashboardAsConfig(v0 []*DashboardsAsConfigV0) []*DashboardsAsConfig
 			OrgId:                 v.OrgId,
 			Folder:                v.Folder,
 			FolderUid:             v.FolderUid,
-			Editable:              v.Editable,
+			Editable:              v.Editable != nil && *v.Editable,
+			EditableSet:           v.Editable != nil,
 			Options:               v.Options,
 			DisableDeletion:       v.DisableDeletion,
 			UpdateIntervalSeconds: v.UpdateIntervalSeconds,
+			TransactionGroup:      v.TransactionGroup,
+			Jpath:                 v.Jpath,
+			Tags:                  v.Tags,
 		})
 	}
 
@@ -94,18 +133,48 @@ func (dc *DashboardAsConfigV1) mapToDashboardAsConfig() []*DashboardsAsConfig {
 	var r []*DashboardsAsConfig
 
 	for _, v := range dc.Providers {
+		// paths are expanded by providerPaths instead, which fails for environment variables that are not set rather
+		// than leaving an empty segment
+		options := v.Options.Value()
+		for _, key := range []string{"path", "paths"} {
+			if raw, ok := v.Options.Raw[key]; ok {
+				options[key] = raw
+			}
+		}
+
 		r = append(r, &DashboardsAsConfig{
 			Name:                  v.Name.Value(),
 			Type:                  v.Type.Value(),
 			OrgId:                 v.OrgId.Value(),
+			OrgIds:                v.OrgIds.Value(),
+			AllOrgs:               v.OrgId.all,
 			Folder:                v.Folder.Value(),
 			FolderUid:             v.FolderUid.Value(),
 			Editable:              v.Editable.Value(),
-			Options:               v.Options.Value(),
+			EditableSet:           v.Editable.Raw != "",
+			Options:               options,
 			DisableDeletion:       v.DisableDeletion.Value(),
 			UpdateIntervalSeconds: v.UpdateIntervalSeconds.Value(),
+			TransactionGroup:      v.TransactionGroup.Value(),
+			Jpath:                 v.Jpath.Value(),
+			Tags:                  v.Tags.Value(),
 		})
 	}
 
 	return r
 }
+
+// orgIdValue is the orgId of a provider, an org id or "*" for all orgs.
+type orgIdValue struct {
+	values.Int64Value
+	all bool
+}
+
+func (val *orgIdValue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw string
+	if err := unmarshal(&raw); err == nil && raw == allOrgs {
+		val.all = true
+		return nil
+	}
+	return val.Int64Value.UnmarshalYAML(unmarshal)
+}
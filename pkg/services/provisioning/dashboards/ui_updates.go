@@ -0,0 +1,27 @@
+package dashboards
+
+import (
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+// allowsUiUpdates returns true if users may save the dashboards of the provider in the UI. With allowUiUpdates their
+// changes are kept until the file changes, with preventUpdate the dashboards are never saved from their files again.
+func (fr *fileReader) allowsUiUpdates() bool {
+	return getBoolOption(fr.Cfg.Options, "allowUiUpdates") || getBoolOption(fr.Cfg.Options, "preventUpdate")
+}
+
+// warnAboutUiChanges logs a warning if the dashboard about to be saved from its changed file was also saved by a user
+// since it was provisioned. Provisioning saves dashboards without a user, so a dashboard last updated by a user was
+// changed in the UI. The file wins and the changes made in the UI are overwritten.
+func (fr *fileReader) warnAboutUiChanges(path string, dashboardId int64) {
+	query := &models.GetDashboardQuery{Id: dashboardId, OrgId: fr.Cfg.OrgId}
+	if err := bus.Dispatch(query); err != nil {
+		return
+	}
+
+	if query.Result.UpdatedBy > 0 {
+		fr.log.Warn("dashboard was changed in the UI and in its file, overwriting the changes made in the UI", "file", path,
+			"uid", query.Result.Uid, "updatedBy", query.Result.UpdatedBy, "updated", query.Result.Updated)
+	}
+}
@@ -0,0 +1,93 @@
+package dashboards
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Values of the uidConflictLogLevel option.
+const (
+	uidConflictLogLevelWarn  = "warn"
+	uidConflictLogLevelError = "error"
+)
+
+// uidOwners tracks which provider provisions the dashboard with a uid in an org, across all providers of a
+// provisioner, so a provider overwriting the dashboard of another provider is reported. Providers are scanned
+// concurrently.
+type uidOwners struct {
+	mu     sync.Mutex
+	owners map[uidOwnerKey]uidOwner
+}
+
+type uidOwnerKey struct {
+	orgId int64
+	uid   string
+}
+
+// uidOwner is the provider and the file a dashboard is provisioned from.
+type uidOwner struct {
+	provider string
+	path     string
+}
+
+func newUidOwners() *uidOwners {
+	return &uidOwners{owners: map[uidOwnerKey]uidOwner{}}
+}
+
+// claim records owner as the owner of the uid unless another provider owns it already. Returns the other provider
+// if it does.
+func (o *uidOwners) claim(orgId int64, uid string, owner uidOwner) (uidOwner, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	key := uidOwnerKey{orgId: orgId, uid: uid}
+	if current, ok := o.owners[key]; ok && current.provider != owner.provider {
+		return current, true
+	}
+	o.owners[key] = owner
+	return uidOwner{}, false
+}
+
+// release forgets the uids owned by the provider. Providers release their uids at the start of every scan, so
+// dashboards they stopped provisioning are not reported anymore.
+func (o *uidOwners) release(provider string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for key, owner := range o.owners {
+		if owner.provider == provider {
+			delete(o.owners, key)
+		}
+	}
+}
+
+// claimUid claims the uid of a dashboard provisioned from the file at path, logging a conflict if another provider
+// provisions a dashboard with the same uid into the org.
+func (fr *fileReader) claimUid(uid string, path string) {
+	if fr.uidOwners == nil || uid == "" {
+		return
+	}
+
+	other, conflict := fr.uidOwners.claim(fr.Cfg.OrgId, uid, uidOwner{provider: fr.Cfg.Name, path: path})
+	if !conflict {
+		return
+	}
+
+	msg := "dashboard uid is also provisioned by another provider, the dashboards overwrite each other"
+	args := []interface{}{"uid", uid, "file", path, "otherProvider", other.provider, "otherFile", other.path}
+	if getStringOption(fr.Cfg.Options, "uidConflictLogLevel") == uidConflictLogLevelError {
+		fr.log.Error(msg, args...)
+	} else {
+		fr.log.Warn(msg, args...)
+	}
+}
+
+// validateUidConflictLogLevel returns an error if the uidConflictLogLevel option is set to an unknown level.
+func validateUidConflictLogLevel(options map[string]interface{}) error {
+	switch level := getStringOption(options, "uidConflictLogLevel"); level {
+	case "", uidConflictLogLevelWarn, uidConflictLogLevelError:
+		return nil
+	default:
+		return fmt.Errorf("uidConflictLogLevel must be %q or %q, got %q", uidConflictLogLevelWarn, uidConflictLogLevelError, level)
+	}
+}
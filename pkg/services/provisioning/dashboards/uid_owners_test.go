@@ -0,0 +1,91 @@
+package dashboards
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUidOwners(t *testing.T) {
+	Convey("Tracking uid owners", t, func() {
+		owners := newUidOwners()
+		first := uidOwner{provider: "first", path: "a.json"}
+		second := uidOwner{provider: "second", path: "b.json"}
+
+		_, conflict := owners.claim(1, "shared", first)
+		So(conflict, ShouldBeFalse)
+
+		Convey("should report a uid claimed by another provider in the same org", func() {
+			other, conflict := owners.claim(1, "shared", second)
+			So(conflict, ShouldBeTrue)
+			So(other, ShouldResemble, first)
+		})
+
+		Convey("should allow the same uid in another org", func() {
+			_, conflict := owners.claim(2, "shared", second)
+			So(conflict, ShouldBeFalse)
+		})
+
+		Convey("should allow a provider to claim its uid again", func() {
+			_, conflict := owners.claim(1, "shared", first)
+			So(conflict, ShouldBeFalse)
+		})
+
+		Convey("should forget the uids of a provider released", func() {
+			owners.release("first")
+			_, conflict := owners.claim(1, "shared", second)
+			So(conflict, ShouldBeFalse)
+		})
+	})
+
+	Convey("Two providers provisioning the same uid", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		owners := newUidOwners()
+		newReader := func(name string, file string) (*fileReader, *recordingLogger) {
+			recorder := &recordingLogger{Logger: log.New("test-logger")}
+			cfg := &DashboardsAsConfig{
+				Name:  name,
+				Type:  "file",
+				OrgId: 1,
+				Options: map[string]interface{}{
+					"path":            duplicateUids,
+					"includePatterns": []interface{}{file},
+				},
+			}
+			reader, err := NewDashboardFileReader(cfg, recorder, ReaderOptions{})
+			So(err, ShouldBeNil)
+			reader.uidOwners = owners
+			return reader, recorder
+		}
+
+		first, firstLog := newReader("first", "a.json")
+		second, secondLog := newReader("second", "b.json")
+		So(first.startWalkingDisk(context.Background()), ShouldBeNil)
+		So(second.startWalkingDisk(context.Background()), ShouldBeNil)
+
+		Convey("should warn about the conflict in the provider scanned second", func() {
+			So(firstLog.messages, ShouldBeEmpty)
+			So(secondLog.messages, ShouldContain, "dashboard uid is also provisioned by another provider, the dashboards overwrite each other")
+		})
+
+		Convey("should reject unknown log levels", func() {
+			_, err := NewDashboardFileReader(&DashboardsAsConfig{
+				Name:    "Default",
+				Type:    "file",
+				Options: map[string]interface{}{"path": duplicateUids, "uidConflictLogLevel": "debug"},
+			}, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
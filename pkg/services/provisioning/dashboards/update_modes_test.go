@@ -0,0 +1,117 @@
+package dashboards
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDashboardUpdateModes(t *testing.T) {
+	Convey("Updating provisioned dashboards", t, func() {
+		bus.ClearBusHandlers()
+		origNewDashboardProvisioningService := dashboards.NewProvisioningService
+		fakeService = mockDashboardProvisioningService()
+		bus.AddHandler("test", mockGetDashboardQuery)
+		defer func() {
+			dashboards.NewProvisioningService = origNewDashboardProvisioningService
+		}()
+
+		dir, err := ioutil.TempDir("", "provisioning-updates")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "cpu.json")
+
+		writeDashboard := func(content string, modTime time.Time) {
+			So(ioutil.WriteFile(path, []byte(content), 0644), ShouldBeNil)
+			So(os.Chtimes(path, modTime, modTime), ShouldBeNil)
+		}
+
+		cfg := &DashboardsAsConfig{
+			Name:    "Default",
+			Type:    "file",
+			OrgId:   1,
+			Options: map[string]interface{}{"path": dir},
+		}
+
+		Convey("With preventUpdate", func() {
+			cfg.Options["preventUpdate"] = true
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			writeDashboard(`{"uid": "cpu", "title": "CPU"}`, time.Now().Add(-time.Hour))
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			Convey("should save new dashboards and let users save them", func() {
+				So(len(fakeService.inserted), ShouldEqual, 1)
+				So(fakeService.provisioned["Default"][0].AllowUiUpdates, ShouldBeTrue)
+			})
+
+			Convey("should not save dashboards again when their file changes", func() {
+				writeDashboard(`{"uid": "cpu", "title": "CPU usage"}`, time.Now())
+				So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+				So(len(fakeService.inserted), ShouldEqual, 1)
+				So(fakeService.inserted[0].Dashboard.Title, ShouldEqual, "CPU")
+			})
+
+			Convey("should still remove dashboards whose file is removed", func() {
+				So(os.Remove(path), ShouldBeNil)
+				So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+				So(fakeService.provisioned["Default"], ShouldBeEmpty)
+			})
+		})
+
+		Convey("With allowUiUpdates", func() {
+			cfg.Options["allowUiUpdates"] = true
+			logger := &recordingLogger{Logger: log.New("test-logger")}
+			reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			writeDashboard(`{"uid": "cpu", "title": "CPU"}`, time.Now().Add(-time.Hour))
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+			So(fakeService.provisioned["Default"][0].AllowUiUpdates, ShouldBeTrue)
+
+			// provisioning saves without a user, a user saving the dashboard in the UI becomes its updater
+			fakeService.inserted[0].Dashboard.UpdatedBy = 7
+
+			Convey("should keep changes made in the UI while the file doesn't change", func() {
+				writeDashboard(`{"uid": "cpu", "title": "CPU"}`, time.Now())
+				So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+				So(len(fakeService.inserted), ShouldEqual, 1)
+				So(fakeService.inserted[0].Dashboard.UpdatedBy, ShouldEqual, 7)
+				So(logger.messages, ShouldBeEmpty)
+			})
+
+			Convey("should overwrite changes made in the UI with a warning when the file changes", func() {
+				writeDashboard(`{"uid": "cpu", "title": "CPU usage"}`, time.Now())
+				So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+				So(len(fakeService.inserted), ShouldEqual, 1)
+				So(fakeService.inserted[0].Dashboard.Title, ShouldEqual, "CPU usage")
+				So(logger.messages, ShouldContain, "dashboard was changed in the UI and in its file, overwriting the changes made in the UI")
+			})
+		})
+
+		Convey("By default", func() {
+			reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldBeNil)
+
+			writeDashboard(`{"uid": "cpu", "title": "CPU"}`, time.Now())
+			So(reader.startWalkingDisk(context.Background()), ShouldBeNil)
+
+			Convey("should not let users save dashboards", func() {
+				So(fakeService.provisioned["Default"][0].AllowUiUpdates, ShouldBeFalse)
+			})
+		})
+	})
+}
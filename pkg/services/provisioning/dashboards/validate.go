@@ -0,0 +1,288 @@
+package dashboards
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// Validate checks the provider config without creating the provider: the presence of a path, the update interval
+// and the values of the options that only take some values. It doesn't change the config.
+func (cfg *DashboardsAsConfig) Validate() error {
+	switch cfg.Type {
+	case "", "file", archiveSourceType, httpSourceType, s3SourceType, gitSourceType:
+	default:
+		return fmt.Errorf("type %s is not supported", cfg.Type)
+	}
+
+	paths, err := providerPaths(cfg, nil)
+	if err != nil {
+		return err
+	}
+	if len(paths) > 1 && cfg.Type != "file" {
+		return fmt.Errorf("Only providers of type file can have more than one path")
+	}
+
+	if cfg.UpdateIntervalSeconds < 0 {
+		return fmt.Errorf("updateIntervalSeconds must not be negative, got %d", cfg.UpdateIntervalSeconds)
+	}
+
+	switch validateMode := getStringOption(cfg.Options, "validate"); validateMode {
+	case "", validateModeWarn, validateModeStrict:
+	default:
+		return fmt.Errorf("validate must be %q or %q, got %q", validateModeWarn, validateModeStrict, validateMode)
+	}
+
+	switch onDuplicateUid := getStringOption(cfg.Options, "onDuplicateUid"); onDuplicateUid {
+	case "", duplicateUidError, duplicateUidFirstWins:
+	default:
+		return fmt.Errorf("onDuplicateUid must be %q or %q, got %q", duplicateUidError, duplicateUidFirstWins, onDuplicateUid)
+	}
+
+	switch onMissingTitle := getStringOption(cfg.Options, "onMissingTitle"); onMissingTitle {
+	case "", missingTitleSkip, missingTitleFilename:
+	default:
+		return fmt.Errorf("onMissingTitle must be %q or %q, got %q", missingTitleSkip, missingTitleFilename, onMissingTitle)
+	}
+
+	if err := validateUidConflictLogLevel(cfg.Options); err != nil {
+		return err
+	}
+
+	if _, _, err := getTemplateDelims(cfg.Options); err != nil {
+		return err
+	}
+
+	switch style := getStringOption(cfg.Options, "forceStyle"); style {
+	case "", dashboardStyleDark, dashboardStyleLight:
+	default:
+		return fmt.Errorf("forceStyle must be %q or %q, got %q", dashboardStyleDark, dashboardStyleLight, style)
+	}
+
+	if refresh := getStringOption(cfg.Options, "refreshOverride"); refresh != "" {
+		if _, ok := parseRefreshInterval(refresh); !ok {
+			return fmt.Errorf("refreshOverride must be an interval like 1m, got %q", refresh)
+		}
+	}
+
+	folderTimezones := getStringMapOption(cfg.Options, "folderTimezones")
+	folders := make([]string, 0, len(folderTimezones))
+	for folder := range folderTimezones {
+		folders = append(folders, folder)
+	}
+	sort.Strings(folders)
+	for _, folder := range folders {
+		if err := validateTimezone(folderTimezones[folder]); err != nil {
+			return fmt.Errorf("folderTimezones of folder %q %v", folder, err)
+		}
+	}
+
+	if timezone := getStringOption(cfg.Options, "timezoneOverride"); timezone != "" {
+		if err := validateTimezone(timezone); err != nil {
+			return fmt.Errorf("timezoneOverride %v", err)
+		}
+	}
+
+	if root := getStringOption(cfg.Options, "allowedRoot"); root != "" {
+		allowedRoot, err := resolveAllowedRoot(root)
+		if err != nil {
+			return fmt.Errorf("Could not resolve allowedRoot. %v", err)
+		}
+		for _, path := range paths {
+			if err := checkPathWithinRoot(path, allowedRoot); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := getDashboardFileFormats(getStringSliceOption(cfg.Options, "formats")); err != nil {
+		return err
+	}
+	if _, err := compileGlobPatterns("includePatterns", getStringSliceOption(cfg.Options, "includePatterns")); err != nil {
+		return err
+	}
+	if _, err := compileGlobPatterns("excludePatterns", getStringSliceOption(cfg.Options, "excludePatterns")); err != nil {
+		return err
+	}
+
+	if concurrency := getInt64Option(cfg.Options, "concurrency"); concurrency < 0 {
+		return fmt.Errorf("concurrency must not be negative, got %d", concurrency)
+	}
+
+	if retries := getInt64Option(cfg.Options, "saveRetries"); retries < 0 {
+		return fmt.Errorf("saveRetries must not be negative, got %d", retries)
+	}
+
+	return nil
+}
+
+// ProviderValidation is the outcome of validating a dashboard provider without starting it.
+type ProviderValidation struct {
+	Provider string
+	// Error is the error creating the provider failed with, its dashboards are not read then.
+	Error error
+	// UnknownOptions are the options of the provider no provider type reads, most likely typos.
+	UnknownOptions []string
+	// Files are the dashboard files of a file provider and whether they could be read. Providers fetching their
+	// dashboards from elsewhere, like git repositories, are not fetched.
+	Files []LintResult
+}
+
+// Valid returns true if the provider can be created, sets only known options and all of its files can be read.
+func (v ProviderValidation) Valid() bool {
+	if v.Error != nil || len(v.UnknownOptions) > 0 {
+		return false
+	}
+	for _, file := range v.Files {
+		if file.Error != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateConfigs validates the providers of the dashboard provisioning config file at path, or of all config files
+// if path is a directory, the way the server would create them. Dashboard files are read but nothing is saved.
+func ValidateConfigs(path string, logger log.Logger) ([]ProviderValidation, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []*DashboardsAsConfig
+	if stat.IsDir() {
+		cr := &configReader{path: path, log: logger}
+		configs, err = cr.readConfig()
+	} else {
+		configs, err = ReadConfigFile(path, logger)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var validations []ProviderValidation
+	for _, cfg := range configs {
+		validations = append(validations, validateProvider(cfg, logger))
+	}
+	return validations, nil
+}
+
+func validateProvider(cfg *DashboardsAsConfig, logger log.Logger) ProviderValidation {
+	validation := ProviderValidation{Provider: cfg.Name, UnknownOptions: unknownOptions(cfg.Options)}
+
+	reader, err := NewDashboardFileReader(cfg, logger, ReaderOptions{})
+	if err != nil {
+		validation.Error = err
+		return validation
+	}
+
+	if reader.source == nil {
+		if validation.Files, err = reader.readDashboardFiles(context.Background()); err != nil {
+			validation.Error = fmt.Errorf("failed to read dashboards: %v", err)
+		}
+	}
+	return validation
+}
+
+// unknownOptions returns the sorted names of the options no provider type reads.
+func unknownOptions(options map[string]interface{}) []string {
+	var unknown []string
+	for name := range options {
+		if !knownOptions[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// knownOptions are the names of all options read by any provider type. New options have to be added here, or
+// validating a provider setting them reports them as unknown.
+var knownOptions = map[string]bool{
+	"accessKey":                      true,
+	"addTags":                        true,
+	"allowComments":                  true,
+	"allowMissingPath":               true,
+	"allowUiUpdates":                 true,
+	"allowedRoot":                    true,
+	"backupDir":                      true,
+	"backupRetention":                true,
+	"branch":                         true,
+	"bucket":                         true,
+	"cacheTimeoutTagPrefix":          true,
+	"clampRefresh":                   true,
+	"concurrency":                    true,
+	"datasourceMappings":             true,
+	"defaultAnnotationDatasource":    true,
+	"defaultPanelDataLinkTypes":      true,
+	"defaultPanelDataLinks":          true,
+	"dryRun":                         true,
+	"endpoint":                       true,
+	"excludePatterns":                true,
+	"expandEnv":                      true,
+	"folder":                         true,
+	"folderTimezones":                true,
+	"foldersFromFilesStructure":      true,
+	"followSymlinks":                 true,
+	"forceStyle":                     true,
+	"formats":                        true,
+	"generateUidFromPath":            true,
+	"gitBinary":                      true,
+	"headers":                        true,
+	"includePatterns":                true,
+	"inputs":                         true,
+	"installSelfMonitoringDashboard": true,
+	"journalMaxSizeBytes":            true,
+	"journalPath":                    true,
+	"jsonnetBinary":                  true,
+	"lockMessage":                    true,
+	"logRateLimit":                   true,
+	"logRateLimitWindowSeconds":      true,
+	"maxDataPointsCap":               true,
+	"maxDepth":                       true,
+	"maxPanels":                      true,
+	"minSchemaVersion":               true,
+	"minUpdateIntervalSeconds":       true,
+	"normalizeGridLayout":            true,
+	"normalizeTimeToRelative":        true,
+	"onDuplicateUid":                 true,
+	"onMissingTitle":                 true,
+	"path":                           true,
+	"paths":                          true,
+	"prefix":                         true,
+	"preventUpdate":                  true,
+	"pruneUnusedVariables":           true,
+	"refreshOverride":                true,
+	"region":                         true,
+	"renderCheck":                    true,
+	"requireHealthyDatasources":      true,
+	"requireTagPrefix":               true,
+	"saveRetries":                    true,
+	"saveRetryBackoffMillis":         true,
+	"scanBackoffMaxSeconds":          true,
+	"scanFailureThreshold":           true,
+	"secretKey":                      true,
+	"softMaxDashboards":              true,
+	"sshKeyPath":                     true,
+	"strictEnv":                      true,
+	"subdirectory":                   true,
+	"tagWithCommit":                  true,
+	"templateData":                   true,
+	"templateDelims":                 true,
+	"templating":                     true,
+	"timeoutSeconds":                 true,
+	"timezoneOverride":               true,
+	"titlePrefix":                    true,
+	"titleSuffix":                    true,
+	"token":                          true,
+	"uidConflictLogLevel":            true,
+	"url":                            true,
+	"username":                       true,
+	"validate":                       true,
+	"validateSchema":                 true,
+	"variablizeDatasources":          true,
+	"watch":                          true,
+}
@@ -0,0 +1,97 @@
+package dashboards
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidateConfigs(t *testing.T) {
+	Convey("Validating provisioning configs", t, func() {
+		validations, err := ValidateConfigs("testdata/test-configs/validate", log.New("test-logger"))
+		So(err, ShouldBeNil)
+		So(len(validations), ShouldEqual, 4)
+
+		Convey("should pass a valid provider", func() {
+			So(validations[0].Provider, ShouldEqual, "valid")
+			So(validations[0].Valid(), ShouldBeTrue)
+			So(len(validations[0].Files), ShouldEqual, 2)
+		})
+
+		Convey("should report unknown options", func() {
+			So(validations[1].Valid(), ShouldBeFalse)
+			So(validations[1].Error, ShouldBeNil)
+			So(validations[1].UnknownOptions, ShouldResemble, []string{"foldersFromFileStructure"})
+		})
+
+		Convey("should report unreadable dashboards", func() {
+			So(validations[2].Valid(), ShouldBeFalse)
+			So(validations[2].Error, ShouldBeNil)
+		})
+
+		Convey("should report providers that can't be created", func() {
+			So(validations[3].Valid(), ShouldBeFalse)
+			So(validations[3].Error, ShouldNotBeNil)
+			So(validations[3].Files, ShouldBeEmpty)
+		})
+
+		Convey("should validate a single config file", func() {
+			validations, err := ValidateConfigs("testdata/test-configs/validate/providers.yaml", log.New("test-logger"))
+			So(err, ShouldBeNil)
+			So(len(validations), ShouldEqual, 4)
+		})
+
+		Convey("should fail for a missing config path", func() {
+			_, err := ValidateConfigs("testdata/test-configs/missing", log.New("test-logger"))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestDashboardsAsConfigValidate(t *testing.T) {
+	Convey("Validating a provider config", t, func() {
+		cfg := &DashboardsAsConfig{
+			Name:    "Default",
+			Type:    "file",
+			OrgId:   1,
+			Options: map[string]interface{}{"path": defaultDashboards},
+		}
+
+		Convey("should pass a valid config without changing it", func() {
+			So(cfg.Validate(), ShouldBeNil)
+			So(cfg.UpdateIntervalSeconds, ShouldEqual, 0)
+		})
+
+		Convey("should require a path", func() {
+			cfg.Options = map[string]interface{}{}
+			So(cfg.Validate(), ShouldNotBeNil)
+		})
+
+		Convey("should reject a negative update interval", func() {
+			cfg.UpdateIntervalSeconds = -1
+			So(cfg.Validate().Error(), ShouldEqual, "updateIntervalSeconds must not be negative, got -1")
+		})
+
+		Convey("should reject several paths for other provider types", func() {
+			cfg.Type = archiveSourceType
+			cfg.Options["path"] = []interface{}{"a.zip", "b.zip"}
+			So(cfg.Validate(), ShouldNotBeNil)
+		})
+
+		Convey("should reject unsupported option values", func() {
+			cfg.Options["onDuplicateUid"] = "last-wins"
+			So(cfg.Validate(), ShouldNotBeNil)
+		})
+
+		Convey("should report every invalid provider when creating the provisioner", func() {
+			other := &DashboardsAsConfig{Name: "Other", Type: "file", Options: map[string]interface{}{"path": defaultDashboards, "concurrency": -1}}
+			cfg.UpdateIntervalSeconds = -1
+
+			_, err := getFileReaders([]*DashboardsAsConfig{cfg, other}, ReaderOptions{}, log.New("test-logger"))
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "Default: updateIntervalSeconds")
+			So(err.Error(), ShouldContainSubstring, "Other: concurrency")
+		})
+	})
+}
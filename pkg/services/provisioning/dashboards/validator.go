@@ -0,0 +1,73 @@
+package dashboards
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/models"
+)
+
+const (
+	// validateModeWarn logs dashboards violating the provider rules but still provisions them. This is the default.
+	validateModeWarn = "warn"
+	// validateModeStrict skips dashboards violating the provider rules.
+	validateModeStrict = "strict"
+)
+
+// validateDashboard checks the dashboard against the rules configured in the provider options. Depending on the
+// validate option violations are either logged or returned as an error so the dashboard is skipped.
+func (fr *fileReader) validateDashboard(path string, dash *models.Dashboard) error {
+	var violations []string
+
+	if prefix := getStringOption(fr.Cfg.Options, "requireTagPrefix"); prefix != "" && !hasTagWithPrefix(dash, prefix) {
+		violations = append(violations, fmt.Sprintf("no tag with required prefix %q", prefix))
+	}
+
+	if maxPanels := getInt64Option(fr.Cfg.Options, "maxPanels"); maxPanels > 0 {
+		if count := countPanels(dash.Data); int64(count) > maxPanels {
+			violations = append(violations, fmt.Sprintf("%d panels exceed the limit of %d", count, maxPanels))
+		}
+	}
+
+	if minVersion := getInt64Option(fr.Cfg.Options, "minSchemaVersion"); minVersion > 0 {
+		// dashboards without schemaVersion predate it and count as version 0
+		if version := dash.Data.Get("schemaVersion").MustInt64(); version < minVersion {
+			violations = append(violations, fmt.Sprintf("schemaVersion %d is below the minimum of %d", version, minVersion))
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	if getStringOption(fr.Cfg.Options, "validate") == validateModeStrict {
+		return fmt.Errorf("dashboard failed validation: %s", strings.Join(violations, ", "))
+	}
+
+	for _, violation := range violations {
+		fr.log.Warn("dashboard failed validation", "file", path, "violation", violation)
+	}
+
+	return nil
+}
+
+func hasTagWithPrefix(dash *models.Dashboard, prefix string) bool {
+	for _, tag := range dash.GetTags() {
+		if strings.HasPrefix(tag, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// countPanels returns the number of panels of the dashboard not counting rows.
+func countPanels(data *simplejson.Json) int {
+	count := 0
+	forEachPanel(data, func(panel *simplejson.Json) {
+		if panel.Get("type").MustString() != "row" {
+			count++
+		}
+	})
+	return count
+}
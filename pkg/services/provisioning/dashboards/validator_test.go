@@ -0,0 +1,130 @@
+package dashboards
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDashboardValidation(t *testing.T) {
+	Convey("Dashboard validation", t, func() {
+		cfg := &DashboardsAsConfig{
+			Name:    "Default",
+			Type:    "file",
+			OrgId:   1,
+			Options: map[string]interface{}{"path": oneDashboard},
+		}
+
+		newDashboard := func(tags ...interface{}) *models.Dashboard {
+			return models.NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+				"title": "Test",
+				"tags":  tags,
+			}))
+		}
+
+		Convey("Should reject unknown validate mode", func() {
+			cfg.Options["validate"] = "sometimes"
+			_, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("With required tag prefix", func() {
+			cfg.Options["requireTagPrefix"] = "owner:"
+
+			Convey("and strict mode should flag dashboard without owner tag", func() {
+				cfg.Options["validate"] = validateModeStrict
+				reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				So(reader.validateDashboard("dash.json", newDashboard("network")), ShouldNotBeNil)
+				So(reader.validateDashboard("dash.json", newDashboard("network", "owner:team-a")), ShouldBeNil)
+			})
+
+			Convey("and warn mode should only log", func() {
+				reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				So(reader.validateDashboard("dash.json", newDashboard("network")), ShouldBeNil)
+			})
+		})
+
+		Convey("With max panels", func() {
+			cfg.Options["maxPanels"] = 2
+			dash := models.NewDashboardFromJson(simplejson.NewFromAny(map[string]interface{}{
+				"title": "Test",
+				"panels": []interface{}{
+					map[string]interface{}{"id": 1, "type": "graph"},
+					map[string]interface{}{"id": 2, "type": "row", "collapsed": true, "panels": []interface{}{
+						map[string]interface{}{"id": 3, "type": "graph"},
+						map[string]interface{}{"id": 4, "type": "table"},
+					}},
+				},
+			}))
+
+			Convey("and strict mode should skip dashboard over the limit", func() {
+				cfg.Options["validate"] = validateModeStrict
+				reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				So(reader.validateDashboard("dash.json", dash), ShouldNotBeNil)
+			})
+
+			Convey("and warn mode should only log", func() {
+				reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				So(reader.validateDashboard("dash.json", dash), ShouldBeNil)
+			})
+
+			Convey("should pass dashboard within the limit", func() {
+				cfg.Options["validate"] = validateModeStrict
+				cfg.Options["maxPanels"] = 3
+				reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				So(reader.validateDashboard("dash.json", dash), ShouldBeNil)
+			})
+		})
+
+		Convey("With min schema version", func() {
+			cfg.Options["minSchemaVersion"] = 16
+			newVersionedDashboard := func(version interface{}) *models.Dashboard {
+				data := map[string]interface{}{"title": "Test"}
+				if version != nil {
+					data["schemaVersion"] = version
+				}
+				return models.NewDashboardFromJson(simplejson.NewFromAny(data))
+			}
+
+			Convey("and strict mode should skip older dashboards", func() {
+				cfg.Options["validate"] = validateModeStrict
+				reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				So(reader.validateDashboard("dash.json", newVersionedDashboard(14)), ShouldNotBeNil)
+				So(reader.validateDashboard("dash.json", newVersionedDashboard(16)), ShouldBeNil)
+				So(reader.validateDashboard("dash.json", newVersionedDashboard(22)), ShouldBeNil)
+			})
+
+			Convey("and strict mode should treat dashboards without schema version as version 0", func() {
+				cfg.Options["validate"] = validateModeStrict
+				reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				err = reader.validateDashboard("dash.json", newVersionedDashboard(nil))
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "schemaVersion 0 is below the minimum of 16")
+			})
+
+			Convey("and warn mode should only log", func() {
+				reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+				So(err, ShouldBeNil)
+
+				So(reader.validateDashboard("dash.json", newVersionedDashboard(14)), ShouldBeNil)
+			})
+		})
+	})
+}
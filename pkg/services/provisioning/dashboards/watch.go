@@ -0,0 +1,240 @@
+package dashboards
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long the watch option waits for further changes before scanning, so a burst of writes ends up
+// in a single scan.
+const watchDebounce = 500 * time.Millisecond
+
+// fileWatcher reports changes below a directory, including directories created after the watch started.
+type fileWatcher interface {
+	// Changes receives a value when something changed. Changes coming in faster than they are received are
+	// coalesced.
+	Changes() <-chan struct{}
+	// Errors receives the error that stopped the watcher, for example running out of watches.
+	Errors() <-chan error
+	Close() error
+}
+
+// newFileWatcher returns a watcher for the directory at root.
+var newFileWatcher = newFsnotifyWatcher
+
+// newFileWatchers returns a watcher for the directories at roots, combining the watchers of the roots if there is
+// more than one.
+func newFileWatchers(roots []string) (fileWatcher, error) {
+	if len(roots) == 1 {
+		return newFileWatcher(roots[0])
+	}
+
+	combined := &combinedFileWatcher{
+		changes: make(chan struct{}, 1),
+		errors:  make(chan error, 1),
+		done:    make(chan struct{}),
+	}
+	for _, root := range roots {
+		watcher, err := newFileWatcher(root)
+		if err != nil {
+			combined.Close()
+			return nil, err
+		}
+		combined.watchers = append(combined.watchers, watcher)
+	}
+	for _, watcher := range combined.watchers {
+		go combined.forward(watcher)
+	}
+	return combined, nil
+}
+
+// combinedFileWatcher reports the changes of several watchers and the first error stopping one of them.
+type combinedFileWatcher struct {
+	watchers []fileWatcher
+	changes  chan struct{}
+	errors   chan error
+	done     chan struct{}
+}
+
+func (w *combinedFileWatcher) forward(watcher fileWatcher) {
+	for {
+		select {
+		case <-watcher.Changes():
+			select {
+			case w.changes <- struct{}{}:
+			default:
+			}
+		case err := <-watcher.Errors():
+			select {
+			case w.errors <- err:
+			default:
+			}
+			return
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *combinedFileWatcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+func (w *combinedFileWatcher) Errors() <-chan error {
+	return w.errors
+}
+
+func (w *combinedFileWatcher) Close() error {
+	close(w.done)
+	var closeErr error
+	for _, watcher := range w.watchers {
+		if err := watcher.Close(); err != nil && closeErr == nil {
+			closeErr = err
+		}
+	}
+	return closeErr
+}
+
+// fsnotifyWatcher watches a directory tree with fsnotify. Every directory needs its own watch, directories created
+// later are added when their creation is reported.
+type fsnotifyWatcher struct {
+	watcher *fsnotify.Watcher
+	changes chan struct{}
+	errors  chan error
+}
+
+func newFsnotifyWatcher(root string) (fileWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &fsnotifyWatcher{
+		watcher: watcher,
+		changes: make(chan struct{}, 1),
+		errors:  make(chan error, 1),
+	}
+	if err := w.addTree(root); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	go w.readEvents()
+	return w, nil
+}
+
+func (w *fsnotifyWatcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+func (w *fsnotifyWatcher) Errors() <-chan error {
+	return w.errors
+}
+
+func (w *fsnotifyWatcher) Close() error {
+	return w.watcher.Close()
+}
+
+// addTree watches dir and every directory below it, skipping hidden directories like the walk does.
+func (w *fsnotifyWatcher) addTree(dir string) error {
+	return filepath.Walk(dir, func(path string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				// removed again before it could be watched
+				return nil
+			}
+			return err
+		}
+
+		if !fileInfo.IsDir() {
+			return nil
+		}
+		if path != dir && strings.HasPrefix(fileInfo.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		if err := w.watcher.Add(path); err != nil {
+			if err == syscall.ENOSPC {
+				return fmt.Errorf("too many watches, raise fs.inotify.max_user_watches: %v", err)
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+// readEvents reports the events of the watcher until it is closed. Changes of permissions only are ignored.
+func (w *fsnotifyWatcher) readEvents() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+
+			if event.Op&fsnotify.Create != 0 {
+				if fileInfo, err := os.Lstat(event.Name); err == nil && fileInfo.IsDir() {
+					if err := w.addTree(event.Name); err != nil {
+						w.stop(err)
+						return
+					}
+				}
+			}
+
+			select {
+			case w.changes <- struct{}{}:
+			default:
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.stop(err)
+			return
+		}
+	}
+}
+
+// stop reports the error that stopped reading events.
+func (w *fsnotifyWatcher) stop(err error) {
+	select {
+	case w.errors <- err:
+	default:
+	}
+}
+
+// watchChanges runs scan after changes below the provider paths, once no further change came in for watchDebounce.
+// Returns nil once ctx is done or the error that stopped the watcher.
+func (fr *fileReader) watchChanges(ctx context.Context, scan func()) error {
+	watcher, err := newFileWatchers(fr.resolvedPaths())
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	fr.log.Debug("watching for changes", "path", fr.Path)
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-watcher.Changes():
+			debounce = time.After(watchDebounce)
+		case <-debounce:
+			debounce = nil
+			scan()
+		case err := <-watcher.Errors():
+			return err
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
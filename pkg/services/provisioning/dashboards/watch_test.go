@@ -0,0 +1,123 @@
+package dashboards
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type fakeFileWatcher struct {
+	changes chan struct{}
+	errors  chan error
+	closed  bool
+}
+
+func (w *fakeFileWatcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+func (w *fakeFileWatcher) Errors() <-chan error {
+	return w.errors
+}
+
+func (w *fakeFileWatcher) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestWatchChanges(t *testing.T) {
+	Convey("Watching for changes", t, func() {
+		watcher := &fakeFileWatcher{changes: make(chan struct{}), errors: make(chan error, 1)}
+		origNewFileWatcher := newFileWatcher
+		newFileWatcher = func(root string) (fileWatcher, error) {
+			return watcher, nil
+		}
+		defer func() { newFileWatcher = origNewFileWatcher }()
+
+		cfg := &DashboardsAsConfig{
+			Name:    "Default",
+			Type:    "file",
+			OrgId:   1,
+			Options: map[string]interface{}{"path": defaultDashboards, "watch": true},
+		}
+		reader, err := NewDashboardFileReader(cfg, log.New("test-logger"), ReaderOptions{})
+		So(err, ShouldBeNil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		scans := make(chan struct{}, 10)
+		done := make(chan error, 1)
+		go func() {
+			done <- reader.watchChanges(ctx, func() { scans <- struct{}{} })
+		}()
+
+		Convey("should coalesce a burst of changes into one scan", func() {
+			for i := 0; i < 5; i++ {
+				watcher.changes <- struct{}{}
+			}
+
+			select {
+			case <-scans:
+			case <-time.After(5 * time.Second):
+				t.Fatal("no scan after changes")
+			}
+
+			select {
+			case <-scans:
+				t.Fatal("more than one scan for a burst of changes")
+			case <-time.After(2 * watchDebounce):
+			}
+
+			cancel()
+			So(<-done, ShouldBeNil)
+			So(watcher.closed, ShouldBeTrue)
+		})
+
+		Convey("should return the error stopping the watcher", func() {
+			watcher.errors <- errors.New("too many watches")
+
+			So(<-done, ShouldNotBeNil)
+			So(len(scans), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestFsnotifyWatcher(t *testing.T) {
+	Convey("Fsnotify watcher", t, func() {
+		dir, err := ioutil.TempDir("", "provisioning-watch")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		watcher, err := newFsnotifyWatcher(dir)
+		So(err, ShouldBeNil)
+		defer watcher.Close()
+
+		waitForChange := func() bool {
+			select {
+			case <-watcher.Changes():
+				return true
+			case err := <-watcher.Errors():
+				t.Fatalf("watcher failed: %v", err)
+			case <-time.After(5 * time.Second):
+			}
+			return false
+		}
+
+		Convey("should report files written in directories created after the watch started", func() {
+			sub := filepath.Join(dir, "team-a")
+			So(os.Mkdir(sub, 0750), ShouldBeNil)
+			So(waitForChange(), ShouldBeTrue)
+
+			So(ioutil.WriteFile(filepath.Join(sub, "dashboard.json"), []byte(`{"title": "Watched"}`), 0640), ShouldBeNil)
+			So(waitForChange(), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,68 @@
+package dashboards
+
+import (
+	"runtime"
+	"sync"
+)
+
+// defaultConcurrency is the number of dashboard files read at the same time unless the concurrency option is set.
+func defaultConcurrency() int {
+	return runtime.GOMAXPROCS(0)
+}
+
+// processInOrder calls load for every path with up to concurrency workers and save with the results in the order of
+// paths, so a slow file only holds back the saving but not the reading of the files after it. At most twice as many
+// files as workers are loaded ahead of the one being saved. Loading stops as soon as save returns false.
+func processInOrder(paths []string, concurrency int, load func(path string) *loadedDashboard, save func(loaded *loadedDashboard) bool) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]chan *loadedDashboard, len(paths))
+	for i := range results {
+		results[i] = make(chan *loadedDashboard, 1)
+	}
+
+	jobs := make(chan int)
+	slots := make(chan struct{}, 2*concurrency)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(jobs)
+		for i := range paths {
+			select {
+			case slots <- struct{}{}:
+			case <-done:
+				return
+			}
+
+			select {
+			case jobs <- i:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] <- load(paths[i])
+			}
+		}()
+	}
+
+	for i := range paths {
+		loaded := <-results[i]
+		<-slots
+		if !save(loaded) {
+			break
+		}
+	}
+
+	close(done)
+	wg.Wait()
+}
@@ -0,0 +1,58 @@
+package dashboards
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProcessInOrder(t *testing.T) {
+	Convey("Processing dashboard files with workers", t, func() {
+		var paths []string
+		for i := 0; i < 20; i++ {
+			paths = append(paths, fmt.Sprintf("dashboard-%02d.json", i))
+		}
+
+		var loading, maxLoading int32
+		load := func(path string) *loadedDashboard {
+			current := atomic.AddInt32(&loading, 1)
+			for {
+				seen := atomic.LoadInt32(&maxLoading)
+				if current <= seen || atomic.CompareAndSwapInt32(&maxLoading, seen, current) {
+					break
+				}
+			}
+			// the first file is the slowest so the others are loaded while waiting for it
+			if path == paths[0] {
+				time.Sleep(20 * time.Millisecond)
+			}
+			atomic.AddInt32(&loading, -1)
+			return &loadedDashboard{path: path}
+		}
+
+		Convey("should save in the order of the paths", func() {
+			var saved []string
+			processInOrder(paths, 4, load, func(loaded *loadedDashboard) bool {
+				saved = append(saved, loaded.path)
+				return true
+			})
+
+			So(saved, ShouldResemble, paths)
+			So(maxLoading, ShouldBeGreaterThan, 1)
+			So(maxLoading, ShouldBeLessThanOrEqualTo, 4)
+		})
+
+		Convey("should stop when saving fails", func() {
+			var saved []string
+			processInOrder(paths, 4, load, func(loaded *loadedDashboard) bool {
+				saved = append(saved, loaded.path)
+				return len(saved) < 3
+			})
+
+			So(saved, ShouldResemble, paths[:3])
+		})
+	})
+}
@@ -3,6 +3,7 @@ package provisioning
 import (
 	"context"
 	"path"
+	"path/filepath"
 	"sync"
 
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -12,21 +13,26 @@ import (
 	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
 	"github.com/grafana/grafana/pkg/services/provisioning/datasources"
 	"github.com/grafana/grafana/pkg/services/provisioning/notifiers"
+	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
 type DashboardProvisioner interface {
 	Provision() error
+	ProvisionWithOptions(ctx context.Context, opts dashboards.ScanOptions) error
 	PollChanges(ctx context.Context)
+	Reload(ctx context.Context) error
+	Stop()
+	Status() []dashboards.ProviderStatus
 	GetProvisionerResolvedPath(name string) string
 }
 
-type DashboardProvisionerFactory func(string) (DashboardProvisioner, error)
+type DashboardProvisionerFactory func(string, dashboards.ReaderOptions) (DashboardProvisioner, error)
 
 func init() {
 	registry.RegisterService(NewProvisioningServiceImpl(
-		func(path string) (DashboardProvisioner, error) {
-			return dashboards.NewDashboardProvisionerImpl(path)
+		func(path string, opts dashboards.ReaderOptions) (DashboardProvisioner, error) {
+			return dashboards.NewDashboardProvisionerImpl(path, opts)
 		},
 		notifiers.Provision,
 		datasources.Provision,
@@ -47,7 +53,8 @@ func NewProvisioningServiceImpl(
 }
 
 type provisioningServiceImpl struct {
-	Cfg                     *setting.Cfg `inject:""`
+	Cfg                     *setting.Cfg      `inject:""`
+	RenderService           rendering.Service `inject:""`
 	log                     log.Logger
 	pollingCtxCancel        context.CancelFunc
 	newDashboardProvisioner DashboardProvisionerFactory
@@ -93,8 +100,11 @@ func (ps *provisioningServiceImpl) Run(ctx context.Context) error {
 			// Polling was canceled.
 			continue
 		case <-ctx.Done():
-			// Root server context was cancelled so cancel polling and leave.
+			// Root server context was cancelled so cancel polling, abort running scans and leave.
 			ps.cancelPolling()
+			ps.mutex.Lock()
+			ps.dashboardProvisioner.Stop()
+			ps.mutex.Unlock()
 			return ctx.Err()
 		}
 	}
@@ -113,8 +123,17 @@ func (ps *provisioningServiceImpl) ProvisionNotifications() error {
 }
 
 func (ps *provisioningServiceImpl) ProvisionDashboards() error {
+	return ps.ProvisionDashboardsWithOptions(dashboards.ScanOptions{})
+}
+
+// ProvisionDashboardsWithOptions reloads the dashboard provisioning configs and runs the first scan with the
+// behaviour changed by opts.
+func (ps *provisioningServiceImpl) ProvisionDashboardsWithOptions(opts dashboards.ScanOptions) error {
 	dashboardPath := path.Join(ps.Cfg.ProvisioningPath, "dashboards")
-	dashProvisioner, err := ps.newDashboardProvisioner(dashboardPath)
+	dashProvisioner, err := ps.newDashboardProvisioner(dashboardPath, dashboards.ReaderOptions{
+		RenderService: ps.RenderService,
+		WorkDir:       filepath.Join(ps.Cfg.DataPath, "provisioning"),
+	})
 	if err != nil {
 		return errutil.Wrap("Failed to create provisioner", err)
 	}
@@ -124,11 +143,15 @@ func (ps *provisioningServiceImpl) ProvisionDashboards() error {
 
 	ps.cancelPolling()
 
-	if err := dashProvisioner.Provision(); err != nil {
+	if err := dashProvisioner.ProvisionWithOptions(context.Background(), opts); err != nil {
 		// If we fail to provision with the new provisioner, mutex will unlock and the polling we restart with the
 		// old provisioner as we did not switch them yet.
 		return errutil.Wrap("Failed to provision dashboards", err)
 	}
+	if ps.dashboardProvisioner != nil {
+		// scans of the old provisioner still running must not overlap the new one
+		ps.dashboardProvisioner.Stop()
+	}
 	ps.dashboardProvisioner = dashProvisioner
 	return nil
 }
@@ -137,6 +160,11 @@ func (ps *provisioningServiceImpl) GetDashboardProvisionerResolvedPath(name stri
 	return ps.dashboardProvisioner.GetProvisionerResolvedPath(name)
 }
 
+// GetDashboardProvisioningStatus returns the status of the last scan of every dashboard provider.
+func (ps *provisioningServiceImpl) GetDashboardProvisioningStatus() []dashboards.ProviderStatus {
+	return ps.dashboardProvisioner.Status()
+}
+
 func (ps *provisioningServiceImpl) cancelPolling() {
 	if ps.pollingCtxCancel != nil {
 		ps.log.Debug("Stop polling for dashboard changes")
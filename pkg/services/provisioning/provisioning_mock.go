@@ -1,10 +1,14 @@
 package provisioning
 
+import "github.com/grafana/grafana/pkg/services/provisioning/dashboards"
+
 type Calls struct {
 	ProvisionDatasources                []interface{}
 	ProvisionNotifications              []interface{}
 	ProvisionDashboards                 []interface{}
+	ProvisionDashboardsWithOptions      []interface{}
 	GetDashboardProvisionerResolvedPath []interface{}
+	GetDashboardProvisioningStatus      []interface{}
 }
 
 type ProvisioningServiceMock struct {
@@ -12,7 +16,9 @@ type ProvisioningServiceMock struct {
 	ProvisionDatasourcesFunc                func() error
 	ProvisionNotificationsFunc              func() error
 	ProvisionDashboardsFunc                 func() error
+	ProvisionDashboardsWithOptionsFunc      func(opts dashboards.ScanOptions) error
 	GetDashboardProvisionerResolvedPathFunc func(name string) string
+	GetDashboardProvisioningStatusFunc      func() []dashboards.ProviderStatus
 }
 
 func NewProvisioningServiceMock() *ProvisioningServiceMock {
@@ -45,6 +51,14 @@ func (mock *ProvisioningServiceMock) ProvisionDashboards() error {
 	return nil
 }
 
+func (mock *ProvisioningServiceMock) ProvisionDashboardsWithOptions(opts dashboards.ScanOptions) error {
+	mock.Calls.ProvisionDashboardsWithOptions = append(mock.Calls.ProvisionDashboardsWithOptions, opts)
+	if mock.ProvisionDashboardsWithOptionsFunc != nil {
+		return mock.ProvisionDashboardsWithOptionsFunc(opts)
+	}
+	return nil
+}
+
 func (mock *ProvisioningServiceMock) GetDashboardProvisionerResolvedPath(name string) string {
 	mock.Calls.GetDashboardProvisionerResolvedPath = append(mock.Calls.GetDashboardProvisionerResolvedPath, name)
 	if mock.GetDashboardProvisionerResolvedPathFunc != nil {
@@ -52,3 +66,11 @@ func (mock *ProvisioningServiceMock) GetDashboardProvisionerResolvedPath(name st
 	}
 	return ""
 }
+
+func (mock *ProvisioningServiceMock) GetDashboardProvisioningStatus() []dashboards.ProviderStatus {
+	mock.Calls.GetDashboardProvisioningStatus = append(mock.Calls.GetDashboardProvisioningStatus, nil)
+	if mock.GetDashboardProvisioningStatusFunc != nil {
+		return mock.GetDashboardProvisioningStatusFunc()
+	}
+	return nil
+}
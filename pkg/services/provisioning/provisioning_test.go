@@ -37,6 +37,7 @@ func TestProvisioningServiceImpl(t *testing.T) {
 
 		assert.False(t, serviceTest.serviceRunning, "Service should not be running")
 		assert.Equal(t, context.Canceled, serviceTest.serviceError, "Service should have returned canceled error")
+		assert.Equal(t, 2, len(serviceTest.mock.Calls.Stop), "Replaced provisioner and provisioner at shutdown should have been stopped")
 
 	})
 
@@ -92,7 +93,7 @@ func setup() *serviceTestStruct {
 	}
 
 	serviceTest.service = NewProvisioningServiceImpl(
-		func(path string) (DashboardProvisioner, error) {
+		func(path string, opts dashboards.ReaderOptions) (DashboardProvisioner, error) {
 			return serviceTest.mock, nil
 		},
 		nil,
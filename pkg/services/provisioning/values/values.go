@@ -150,6 +150,58 @@ func (val *StringMapValue) Value() map[string]string {
 	return val.value
 }
 
+type StringSliceValue struct {
+	value []string
+	Raw   []string
+}
+
+func (val *StringSliceValue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var unmarshaled []string
+	err := unmarshal(&unmarshaled)
+	if err != nil {
+		return err
+	}
+	val.Raw = unmarshaled
+	interpolated := make([]string, 0, len(unmarshaled))
+	for _, val := range unmarshaled {
+		interpolated = append(interpolated, interpolateValue(val))
+	}
+	val.value = interpolated
+	return err
+}
+
+func (val *StringSliceValue) Value() []string {
+	return val.value
+}
+
+type Int64SliceValue struct {
+	value []int64
+	Raw   []string
+}
+
+func (val *Int64SliceValue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var unmarshaled []string
+	err := unmarshal(&unmarshaled)
+	if err != nil {
+		return err
+	}
+	val.Raw = unmarshaled
+	parsed := make([]int64, 0, len(unmarshaled))
+	for _, raw := range unmarshaled {
+		value, err := strconv.ParseInt(interpolateValue(raw), 10, 64)
+		if err != nil {
+			return err
+		}
+		parsed = append(parsed, value)
+	}
+	val.value = parsed
+	return nil
+}
+
+func (val *Int64SliceValue) Value() []int64 {
+	return val.value
+}
+
 // tranformInterface tries to transform any interface type into proper value with env expansion. It travers maps and
 // slices and the actual interpolation is done on all simple string values in the structure. It returns a copy of any
 // map or slice value instead of modifying them in place.
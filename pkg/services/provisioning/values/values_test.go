@@ -196,6 +196,48 @@ func TestValues(t *testing.T) {
 			})
 		})
 
+		Convey("StringSliceValue", func() {
+			type Data struct {
+				Val StringSliceValue `yaml:"val"`
+			}
+			d := &Data{}
+
+			Convey("Should unmarshal sequence", func() {
+				doc := `
+                 val:
+                   - test string
+                   - $STRING/lib
+                   - 1
+               `
+				unmarshalingTest(doc, d)
+				So(d.Val.Value(), ShouldResemble, []string{"test string", "test/lib", "1"})
+				So(d.Val.Raw, ShouldResemble, []string{"test string", "$STRING/lib", "1"})
+			})
+		})
+
+		Convey("Int64SliceValue", func() {
+			type Data struct {
+				Val Int64SliceValue `yaml:"val"`
+			}
+			d := &Data{}
+
+			Convey("Should unmarshal sequence", func() {
+				doc := `
+                 val:
+                   - 1
+                   - $INT
+               `
+				unmarshalingTest(doc, d)
+				So(d.Val.Value(), ShouldResemble, []int64{1, 1})
+				So(d.Val.Raw, ShouldResemble, []string{"1", "$INT"})
+			})
+
+			Convey("Should fail on items that are not numbers", func() {
+				err := yaml.Unmarshal([]byte("val: [1, two]"), d)
+				So(err, ShouldNotBeNil)
+			})
+		})
+
 		Reset(func() {
 			os.Unsetenv("INT")
 			os.Unsetenv("STRING")
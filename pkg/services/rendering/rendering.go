@@ -106,7 +106,7 @@ func (rs *RenderingService) Render(ctx context.Context, opts Opts) (*RenderResul
 	if rs.renderAction != nil {
 		return rs.renderAction(ctx, opts)
 	}
-	return nil, fmt.Errorf("No renderer found")
+	return nil, ErrNoRenderer
 }
 
 func (rs *RenderingService) getFilePathForNewImage() string {
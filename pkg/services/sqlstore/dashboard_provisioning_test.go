@@ -42,9 +42,14 @@ func TestDashboardProvisioningTest(t *testing.T) {
 			cmd := &models.SaveProvisionedDashboardCommand{
 				DashboardCmd: saveDashboardCmd,
 				DashboardProvisioning: &models.DashboardProvisioning{
-					Name:       "default",
-					ExternalId: "/var/grafana.json",
-					Updated:    now.Unix(),
+					Name:           "default",
+					ExternalId:     "/var/grafana.json",
+					Updated:        now.Unix(),
+					LockMessage:    "Managed by team-a",
+					InjectedTags:   []string{"provisioned", "team:a"},
+					Revision:       "3f786850e387550fdab836ed7e6dc881de23001b",
+					AllowUiUpdates: true,
+					SourcePath:     "/var/grafana.json.gz",
 				},
 			}
 
@@ -62,6 +67,21 @@ func TestDashboardProvisioningTest(t *testing.T) {
 				So(len(query.Result), ShouldEqual, 1)
 				So(query.Result[0].DashboardId, ShouldEqual, dashId)
 				So(query.Result[0].Updated, ShouldEqual, now.Unix())
+				So(query.Result[0].LockMessage, ShouldEqual, "Managed by team-a")
+				So(query.Result[0].InjectedTags, ShouldResemble, []string{"provisioned", "team:a"})
+				So(query.Result[0].Revision, ShouldEqual, "3f786850e387550fdab836ed7e6dc881de23001b")
+				So(query.Result[0].AllowUiUpdates, ShouldBeTrue)
+				So(query.Result[0].SourcePath, ShouldEqual, "/var/grafana.json.gz")
+			})
+
+			Convey("Can query for provisioned dashboards saved without a source path", func() {
+				_, err := x.Exec("UPDATE dashboard_provisioning SET source_path = NULL WHERE dashboard_id = ?", dashId)
+				So(err, ShouldBeNil)
+
+				query := &models.GetProvisionedDashboardDataByIdQuery{DashboardId: dashId}
+				err = GetProvisionedDataByDashboardId(query)
+				So(err, ShouldBeNil)
+				So(query.Result.SourcePath, ShouldBeEmpty)
 			})
 
 			Convey("Can query for one provisioned dashboard", func() {
@@ -0,0 +1,148 @@
+package sqlstore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/bus"
+	m "github.com/grafana/grafana/pkg/models"
+)
+
+func init() {
+	bus.AddHandler("sql", MergeDuplicateFolders)
+}
+
+type folderMergeCandidate struct {
+	folder     m.Dashboard
+	dashboards int64
+}
+
+func MergeDuplicateFolders(cmd *m.MergeDuplicateFoldersCommand) error {
+	return inTransaction(func(sess *DBSession) error {
+		folders := make([]m.Dashboard, 0)
+		err := sess.Where("is_folder = ?", dialect.BooleanStr(true)).Asc("org_id", "id").Find(&folders)
+		if err != nil {
+			return err
+		}
+
+		groups := map[string][]*folderMergeCandidate{}
+		var keys []string
+		for _, folder := range folders {
+			provisioned, err := isProvisionedFolder(sess, folder.Id)
+			if err != nil {
+				return err
+			}
+			if !provisioned {
+				continue
+			}
+
+			dashboards, err := sess.Where("folder_id = ?", folder.Id).Count(&m.Dashboard{})
+			if err != nil {
+				return err
+			}
+
+			key := fmt.Sprintf("%d/%s", folder.OrgId, normalizeFolderTitle(folder.Title))
+			if _, exists := groups[key]; !exists {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], &folderMergeCandidate{folder: folder, dashboards: dashboards})
+		}
+
+		cmd.Result = []*m.FolderMerge{}
+		for _, key := range keys {
+			group := groups[key]
+			if len(group) < 2 {
+				continue
+			}
+
+			merge, err := mergeFolders(sess, group, cmd.DryRun)
+			if err != nil {
+				return err
+			}
+			cmd.Result = append(cmd.Result, merge)
+		}
+
+		return nil
+	})
+}
+
+// mergeFolders moves all dashboards of the group into the folder with the most dashboards and deletes the others.
+func mergeFolders(sess *DBSession, group []*folderMergeCandidate, dryRun bool) (*m.FolderMerge, error) {
+	sort.SliceStable(group, func(i, j int) bool {
+		return group[i].dashboards > group[j].dashboards
+	})
+
+	target := group[0].folder
+	merge := &m.FolderMerge{OrgId: target.OrgId, FolderId: target.Id, Title: target.Title}
+
+	ids := make([]interface{}, len(group))
+	for i, candidate := range group {
+		ids[i] = candidate.folder.Id
+		if i > 0 {
+			merge.MergedTitles = append(merge.MergedTitles, candidate.folder.Title)
+			merge.DashboardsMoved += candidate.dashboards
+		}
+	}
+
+	// dashboard titles are unique per folder
+	clashes := make([]struct{ Title string }, 0)
+	err := sess.SQL("SELECT title FROM dashboard WHERE folder_id IN (?"+strings.Repeat(",?", len(ids)-1)+") GROUP BY title HAVING COUNT(*) > 1", ids...).Find(&clashes)
+	if err != nil {
+		return nil, err
+	}
+	if len(clashes) > 0 {
+		merge.Skipped = fmt.Sprintf("dashboard title %q exists in more than one of the folders", clashes[0].Title)
+		return merge, nil
+	}
+
+	if dryRun {
+		return merge, nil
+	}
+
+	for _, candidate := range group[1:] {
+		if _, err := sess.Exec("UPDATE dashboard SET folder_id = ? WHERE folder_id = ?", target.Id, candidate.folder.Id); err != nil {
+			return nil, err
+		}
+
+		deletes := []string{
+			"DELETE FROM dashboard_tag WHERE dashboard_id = ? ",
+			"DELETE FROM star WHERE dashboard_id = ? ",
+			"DELETE FROM dashboard_acl WHERE dashboard_id = ?",
+			"DELETE FROM dashboard WHERE id = ?",
+			"DELETE FROM playlist_item WHERE type = 'dashboard_by_id' AND value = ?",
+			"DELETE FROM dashboard_version WHERE dashboard_id = ?",
+		}
+		for _, sql := range deletes {
+			if _, err := sess.Exec(sql, candidate.folder.Id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return merge, nil
+}
+
+// isProvisionedFolder returns true if the folder only holds provisioned dashboards. Empty folders are not
+// considered provisioned as nothing tells who created them.
+func isProvisionedFolder(sess *DBSession, folderId int64) (bool, error) {
+	counts := struct {
+		Total       int64
+		Provisioned int64
+	}{}
+	_, err := sess.SQL(`SELECT
+		COUNT(*) AS total,
+		COUNT(dashboard_provisioning.id) AS provisioned
+		FROM dashboard
+		LEFT OUTER JOIN dashboard_provisioning ON dashboard_provisioning.dashboard_id = dashboard.id
+		WHERE dashboard.folder_id = ?`, folderId).Get(&counts)
+	if err != nil {
+		return false, err
+	}
+	return counts.Total > 0 && counts.Total == counts.Provisioned, nil
+}
+
+// normalizeFolderTitle returns the title folders are compared by, ignoring case and whitespace.
+func normalizeFolderTitle(title string) string {
+	return strings.Join(strings.Fields(strings.ToLower(title)), " ")
+}
@@ -0,0 +1,106 @@
+package sqlstore
+
+import (
+	"testing"
+	"time"
+
+	m "github.com/grafana/grafana/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMergeDuplicateFolders(t *testing.T) {
+	Convey("Testing merge of duplicate folders", t, func() {
+		InitTestDB(t)
+
+		provision := func(dash *m.Dashboard) {
+			err := saveProvisionedTestDashboard(dash)
+			So(err, ShouldBeNil)
+		}
+
+		ops := insertTestDashboard("Ops", 1, 0, true)
+		opsSpace := insertTestDashboard("ops ", 1, 0, true)
+		opsUpper := insertTestDashboard("OPS", 1, 0, true)
+		provision(insertTestDashboard("cpu", 1, ops.Id, false))
+		provision(insertTestDashboard("memory", 1, ops.Id, false))
+		provision(insertTestDashboard("disk", 1, opsSpace.Id, false))
+		provision(insertTestDashboard("network", 1, opsUpper.Id, false))
+
+		manual := insertTestDashboard("Dev", 1, 0, true)
+		insertTestDashboard("dev dashboard", 1, manual.Id, false)
+		manualDuplicate := insertTestDashboard("dev", 1, 0, true)
+		provision(insertTestDashboard("builds", 1, manualDuplicate.Id, false))
+
+		folderIds := func() map[string]int64 {
+			dashboards := make([]m.Dashboard, 0)
+			err := x.Find(&dashboards)
+			So(err, ShouldBeNil)
+
+			ids := map[string]int64{}
+			for _, dash := range dashboards {
+				ids[dash.Title] = dash.FolderId
+			}
+			return ids
+		}
+
+		Convey("should merge near duplicate folders keeping all dashboards", func() {
+			cmd := &m.MergeDuplicateFoldersCommand{}
+			err := MergeDuplicateFolders(cmd)
+			So(err, ShouldBeNil)
+
+			So(len(cmd.Result), ShouldEqual, 1)
+			So(cmd.Result[0].FolderId, ShouldEqual, ops.Id)
+			So(cmd.Result[0].MergedTitles, ShouldResemble, []string{"ops ", "OPS"})
+			So(cmd.Result[0].DashboardsMoved, ShouldEqual, 2)
+
+			ids := folderIds()
+			So(ids["cpu"], ShouldEqual, ops.Id)
+			So(ids["memory"], ShouldEqual, ops.Id)
+			So(ids["disk"], ShouldEqual, ops.Id)
+			So(ids["network"], ShouldEqual, ops.Id)
+			So(ids, ShouldContainKey, "Ops")
+			So(ids, ShouldNotContainKey, "ops ")
+			So(ids, ShouldNotContainKey, "OPS")
+
+			Convey("should leave folders with dashboards not provisioned alone", func() {
+				So(ids["dev dashboard"], ShouldEqual, manual.Id)
+				So(ids["builds"], ShouldEqual, manualDuplicate.Id)
+			})
+		})
+
+		Convey("should only report merges on dry run", func() {
+			cmd := &m.MergeDuplicateFoldersCommand{DryRun: true}
+			err := MergeDuplicateFolders(cmd)
+			So(err, ShouldBeNil)
+
+			So(len(cmd.Result), ShouldEqual, 1)
+			So(cmd.Result[0].DashboardsMoved, ShouldEqual, 2)
+
+			ids := folderIds()
+			So(ids["disk"], ShouldEqual, opsSpace.Id)
+			So(ids["network"], ShouldEqual, opsUpper.Id)
+			So(ids, ShouldContainKey, "OPS")
+		})
+
+		Convey("should skip folders with clashing dashboard titles", func() {
+			provision(insertTestDashboard("cpu", 1, opsUpper.Id, false))
+
+			cmd := &m.MergeDuplicateFoldersCommand{}
+			err := MergeDuplicateFolders(cmd)
+			So(err, ShouldBeNil)
+
+			So(len(cmd.Result), ShouldEqual, 1)
+			So(cmd.Result[0].Skipped, ShouldNotBeEmpty)
+			So(folderIds()["network"], ShouldEqual, opsUpper.Id)
+		})
+	})
+}
+
+func saveProvisionedTestDashboard(dash *m.Dashboard) error {
+	return inTransaction(func(sess *DBSession) error {
+		return saveProvisionedData(sess, &m.DashboardProvisioning{
+			Name:       "default",
+			ExternalId: dash.Title + ".json",
+			Updated:    time.Now().Unix(),
+		}, dash)
+	})
+}
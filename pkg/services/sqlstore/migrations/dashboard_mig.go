@@ -215,4 +215,29 @@ func addDashboardMigration(mg *Migrator) {
 	mg.AddMigration("Add check_sum column", NewAddColumnMigration(dashboardExtrasTableV2, &Column{
 		Name: "check_sum", Type: DB_NVarchar, Length: 32, Nullable: true,
 	}))
+
+	mg.AddMigration("Add lock_message column", NewAddColumnMigration(dashboardExtrasTableV2, &Column{
+		Name: "lock_message", Type: DB_Text, Nullable: true,
+	}))
+
+	mg.AddMigration("Add injected_tags column", NewAddColumnMigration(dashboardExtrasTableV2, &Column{
+		Name: "injected_tags", Type: DB_Text, Nullable: true,
+	}))
+
+	mg.AddMigration("Add revision column", NewAddColumnMigration(dashboardExtrasTableV2, &Column{
+		Name: "revision", Type: DB_NVarchar, Length: 64, Nullable: true,
+	}))
+
+	mg.AddMigration("Add allow_ui_updates column", NewAddColumnMigration(dashboardExtrasTableV2, &Column{
+		Name: "allow_ui_updates", Type: DB_Bool, Nullable: false, Default: "0",
+	}))
+
+	// change column type of dashboard_provisioning.check_sum to fit sha256 checksums
+	mg.AddMigration("alter dashboard_provisioning.check_sum to varchar(64)", NewRawSqlMigration("").
+		Mysql("ALTER TABLE dashboard_provisioning MODIFY check_sum VARCHAR(64) NULL;").
+		Postgres("ALTER TABLE dashboard_provisioning ALTER COLUMN check_sum TYPE VARCHAR(64);"))
+
+	mg.AddMigration("Add source_path column", NewAddColumnMigration(dashboardExtrasTableV2, &Column{
+		Name: "source_path", Type: DB_Text, Nullable: true,
+	}))
 }
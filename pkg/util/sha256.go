@@ -0,0 +1,23 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+)
+
+// Sha256Sum calculates the sha256sum of a stream
+func Sha256Sum(reader io.Reader) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Sha256SumString calculates the sha256sum of a string
+func Sha256SumString(input string) (string, error) {
+	buffer := strings.NewReader(input)
+	return Sha256Sum(buffer)
+}
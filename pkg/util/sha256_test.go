@@ -0,0 +1,17 @@
+package util
+
+import "testing"
+
+func TestSha256Sum(t *testing.T) {
+	input := "don't hash passwords with sha256 either"
+
+	have, err := Sha256SumString(input)
+	if err != nil {
+		t.Fatal("expected err to be nil")
+	}
+
+	want := "6de43f37db6b99d4ce616042a6a4749005b83e4c0e4b2729e346a651bc83009f"
+	if have != want {
+		t.Fatalf("expected: %s got: %s", want, have)
+	}
+}
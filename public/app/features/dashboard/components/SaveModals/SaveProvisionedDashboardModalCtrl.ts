@@ -22,6 +22,9 @@ const template = `
       <i>See <a class="external-link" href="http://docs.grafana.org/administration/provisioning/#dashboards" target="_blank">
       documentation</a> for more information about provisioning.</i>
     </small>
+    <div class="p-t-1" ng-if="ctrl.dashboardModel.meta.provisionedLockMessage">
+      {{ctrl.dashboardModel.meta.provisionedLockMessage}}
+    </div>
     <div class="p-t-1">
       File path: {{ctrl.dashboardModel.meta.provisionedExternalId}}
     </div>
@@ -27,6 +27,7 @@ export interface DashboardMeta {
   submenuEnabled?: boolean;
   provisioned?: boolean;
   provisionedExternalId?: string;
+  provisionedLockMessage?: string;
   focusPanelId?: number;
   isStarred?: boolean;
   showSettings?: boolean;